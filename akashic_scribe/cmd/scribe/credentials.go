@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"akashic_scribe/core"
+)
+
+// runCredentials implements "scribe credentials".
+func runCredentials(args []string) error {
+	flags := flag.NewFlagSet("credentials", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to config.json (default: user config directory)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: scribe credentials [flags] list")
+		fmt.Fprintln(flags.Output(), "       scribe credentials [flags] set <provider>     (reads the key from stdin)")
+		fmt.Fprintln(flags.Output(), "       scribe credentials [flags] delete <provider>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	config, configDir, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	store := core.NewCredentialStore(configDir, nil)
+
+	switch {
+	case flags.Arg(0) == "list" && flags.NArg() == 1:
+		for _, provider := range config.NewProviders().CredentialProviders() {
+			_, source, err := store.Lookup(provider)
+			switch {
+			case errors.Is(err, core.ErrCredentialNotFound):
+				fmt.Printf("%-10s not set\n", provider)
+			case err != nil:
+				fmt.Printf("%-10s error: %v\n", provider, err)
+			default:
+				fmt.Printf("%-10s set (%s)\n", provider, source.Name())
+			}
+		}
+		return nil
+
+	case flags.Arg(0) == "set" && flags.NArg() == 2:
+		fmt.Fprintf(os.Stderr, "API key for %s: ", flags.Arg(1))
+		key, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && key == "" {
+			return fmt.Errorf("failed to read key: %w", err)
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return errors.New("empty key")
+		}
+		return store.Set(flags.Arg(1), core.Secret(key))
+
+	case flags.Arg(0) == "delete" && flags.NArg() == 2:
+		return store.Delete(flags.Arg(1))
+	}
+
+	flags.Usage()
+	return errors.New("expected list, set <provider> or delete <provider>")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"akashic_scribe/core"
+)
+
+// runDoctor implements "scribe doctor".
+func runDoctor(args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to config.json (default: user config directory)")
+	toolsOnly := flags.Bool("tools", false, "check only the external tools, not the providers")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: scribe doctor [flags]")
+		fmt.Fprintln(flags.Output(), "Checks ffmpeg, ffprobe and yt-dlp, and the reachability and API keys of the configured providers,")
+		fmt.Fprintln(flags.Output(), "and suggests fixes for this system. Exits with status 1 if any check fails.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	config, configDir, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	ctx := core.WithProviders(context.Background(), config.NewProviders())
+	ctx = core.WithCredentials(ctx, core.NewCredentialStore(configDir, nil))
+	var report *core.DiagnosticsReport
+	if *toolsOnly {
+		report = core.DiagnoseTools(ctx)
+	} else {
+		report = core.Diagnose(ctx)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(report)
+	}
+
+	if !report.OK() {
+		return errors.New("some checks failed")
+	}
+	return nil
+}
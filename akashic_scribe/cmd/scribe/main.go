@@ -0,0 +1,100 @@
+// Command scribe is the headless command-line interface for Akashic Scribe.
+//
+// Usage:
+//
+//	scribe <command> [flags]
+//
+// Commands:
+//
+//	run         Estimate the cost of processing files or URLs, then process them
+//	serve       Run the HTTP/REST job server
+//	watch       Process media files dropped into watch folders
+//	pipelines   List the available processing pipelines
+//	credentials Manage provider API keys
+//	doctor      Check external tools and providers, and suggest fixes
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"akashic_scribe/core"
+)
+
+// command is a CLI subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+// commands lists all subcommands in the order they appear in the usage text.
+var commands = []command{
+	{"run", "Estimate the cost of processing files or URLs, then process them", runRun},
+	{"serve", "Run the HTTP/REST job server", runServe},
+	{"watch", "Process media files dropped into watch folders", runWatch},
+	{"pipelines", "List the available processing pipelines", runPipelines},
+	{"credentials", "Manage provider API keys", runCredentials},
+	{"doctor", "Check external tools and providers, and suggest fixes", runDoctor},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "-h" || name == "--help" || name == "help" {
+		usage()
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "scribe %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "scribe: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+// usage prints the list of commands.
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: scribe <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-11s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'scribe <command> -h' for command flags.")
+}
+
+// loadConfig loads the configuration from path, or from the default location
+// when path is empty, and installs a stderr logger at the configured log level.
+func loadConfig(path string) (*core.Config, string, error) {
+	if path == "" {
+		defaultPath, err := core.GetDefaultConfigPath()
+		if err != nil {
+			return nil, "", err
+		}
+		path = defaultPath
+	}
+
+	config, err := core.LoadConfig(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	slog.SetDefault(core.NewLogger(os.Stderr, config.SlogLevel()))
+	return config, filepath.Dir(path), nil
+}
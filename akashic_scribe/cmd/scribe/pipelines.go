@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"akashic_scribe/core"
+)
+
+// runPipelines implements "scribe pipelines".
+func runPipelines(args []string) error {
+	flags := flag.NewFlagSet("pipelines", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: scribe pipelines")
+		fmt.Fprintln(flags.Output(), "Lists the pipelines that jobs can select by name, with their stages in order.")
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	for _, name := range core.PipelineNames() {
+		pipeline, err := core.LookupPipeline(name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-12s %s\n", name, strings.Join(pipeline.StageNames(), " -> "))
+	}
+	return nil
+}
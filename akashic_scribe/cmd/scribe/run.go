@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"akashic_scribe/core"
+)
+
+// runRun implements "scribe run".
+func runRun(args []string) error {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	target := flags.String("target", "", "target language, e.g. ja-JP")
+	origin := flags.String("origin", "", "origin language, e.g. en-US")
+	template := flags.String("template", "", "template applied to every input")
+	output := flags.String("output", "", "output root; each input gets a folder named after it, or its video ID for URLs (default from config)")
+	pipeline := flags.String("pipeline", "", "pipeline to run (see 'scribe pipelines')")
+	subtitles := flags.Bool("subtitles", false, "generate subtitles")
+	dub := flags.Bool("dub", false, "generate dubbed audio")
+	estimateOnly := flags.Bool("estimate", false, "print the estimate and exit without processing")
+	yes := flags.Bool("yes", false, "process without asking for confirmation")
+	configPath := flags.String("config", "", "path to config.json (default: user config directory)")
+	mock := flags.Bool("mock", false, "use the mock engine instead of yt-dlp/ffmpeg")
+	playlistStart := flags.Int("playlist-start", 0, "first playlist entry to process (1-based)")
+	playlistEnd := flags.Int("playlist-end", 0, "last playlist entry to process (1-based, inclusive)")
+	playlistAfter := flags.String("playlist-after", "", "only playlist entries uploaded on or after this date (YYYY-MM-DD)")
+	playlistBefore := flags.String("playlist-before", "", "only playlist entries uploaded on or before this date (YYYY-MM-DD)")
+	playlistMax := flags.Int("playlist-max", 0, "maximum number of playlist entries to process")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: scribe run [flags] <file-or-url>...")
+		fmt.Fprintln(flags.Output(), "Prints the estimated cost and runtime, then processes the inputs after confirmation.")
+		flags.PrintDefaults()
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no inputs given")
+	}
+
+	filter := core.PlaylistFilter{Start: *playlistStart, End: *playlistEnd, MaxEntries: *playlistMax}
+	if filter.After, err = parseDateFlag("playlist-after", *playlistAfter); err != nil {
+		return err
+	}
+	if filter.Before, err = parseDateFlag("playlist-before", *playlistBefore); err != nil {
+		return err
+	}
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	config, configDir, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	templates, err := core.NewTemplateManager(configDir)
+	if err != nil {
+		return fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	outputRoot := *output
+	if outputRoot == "" {
+		outputRoot = config.DefaultOutputDir
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var engine core.ScribeEngine = core.NewRealScribeEngine()
+	if *mock {
+		engine = core.NewMockScribeEngine()
+	}
+
+	var jobs []core.ScribeOptions
+	for _, input := range flags.Args() {
+		var options core.ScribeOptions
+		if *template != "" {
+			if err := templates.ApplyTemplate(*template, &options); err != nil {
+				return err
+			}
+		}
+		if *pipeline != "" {
+			options.Pipeline = *pipeline
+		}
+		name := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+		if strings.HasPrefix(input, "http") {
+			options.InputURL = input
+			name = core.URLOutputName(input)
+		} else {
+			options.InputFile = input
+		}
+		options.OutputDir = filepath.Join(outputRoot, name)
+		if *target != "" {
+			options.TargetLanguage = *target
+		}
+		if *origin != "" {
+			options.OriginLanguage = *origin
+		}
+		options.CreateSubtitles = options.CreateSubtitles || *subtitles
+		options.CreateDubbing = options.CreateDubbing || *dub
+		if err := core.ApplyConfigToOptions(config, &options); err != nil {
+			return err
+		}
+		if options.InputURL == "" {
+			jobs = append(jobs, options)
+			continue
+		}
+
+		// Playlist and channel URLs become one job per video
+		expanded, err := core.ExpandPlaylist(ctx, engine, options, filter)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", input, err)
+		}
+		jobs = append(jobs, expanded...)
+	}
+
+	// Estimate every input before anything is processed
+	estimateCtx := core.WithPriceTable(ctx, config.PriceTable())
+	var total core.Estimate
+	for _, options := range jobs {
+		est, err := core.EstimateJob(estimateCtx, options)
+		if err != nil {
+			return fmt.Errorf("failed to estimate %s: %w", inputName(options), err)
+		}
+		total.Add(est)
+		fmt.Printf("%s: %s\n", inputName(options), est.Summary())
+	}
+	if len(jobs) > 1 {
+		fmt.Printf("Total: %s\n", total.Summary())
+	}
+	if config.BatchBudget > 0 && total.TotalCost > config.BatchBudget {
+		fmt.Printf("Warning: the estimated cost exceeds the batch budget of $%.4f; jobs will stop once it is spent.\n", config.BatchBudget)
+	}
+
+	if *estimateOnly {
+		return nil
+	}
+	if !*yes && !confirm("Proceed?") {
+		return errors.New("cancelled")
+	}
+
+	processor := core.NewBatchProcessorFromConfig(engine, config)
+	processor.SetCredentials(core.NewCredentialStore(configDir, nil))
+	defer processor.Shutdown()
+
+	ids := make([]string, len(jobs))
+	for i, options := range jobs {
+		ids[i] = processor.AddJob(options)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		processor.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		processor.CancelAll()
+		<-done
+	}
+
+	failed := 0
+	for i, id := range ids {
+		job, _ := processor.JobSnapshot(id)
+		if job.Status != core.JobCompleted {
+			failed++
+			fmt.Printf("%s: %s: %v\n", inputName(jobs[i]), job.Status, job.Error)
+			continue
+		}
+		fmt.Printf("%s: %s, cost $%.4f\n", inputName(jobs[i]), job.Status, job.Result.TotalCost())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs failed", failed, len(jobs))
+	}
+	return nil
+}
+
+// inputName returns the input file or URL of a job, for messages.
+func inputName(options core.ScribeOptions) string {
+	if options.InputFile != "" {
+		return options.InputFile
+	}
+	return options.InputURL
+}
+
+// confirm asks a yes/no question on stdin and reports whether it was answered yes.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// parseDateFlag parses a YYYY-MM-DD flag value; an empty value is the zero time.
+func parseDateFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -%s %q: want YYYY-MM-DD", name, value)
+	}
+	return date, nil
+}
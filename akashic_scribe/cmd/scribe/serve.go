@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"akashic_scribe/core"
+	"akashic_scribe/server"
+)
+
+// tokenEnvVar is the environment variable read when -token is not given.
+const tokenEnvVar = "AKASHIC_SCRIBE_TOKEN"
+
+// runServe implements "scribe serve".
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", "127.0.0.1:8080", "address to listen on")
+	token := flags.String("token", "", "API bearer token (default $"+tokenEnvVar+")")
+	configPath := flags.String("config", "", "path to config.json (default: user config directory)")
+	mock := flags.Bool("mock", false, "use the mock engine instead of yt-dlp/ffmpeg")
+	outputRoot := flags.String("output-root", "", "directory job outputs are written under (default: config default_output_dir, else "+server.DefaultOutputRoot+")")
+	inputRoot := flags.String("input-root", "", "directory local input files may be read from (default: URLs only)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *token == "" {
+		*token = os.Getenv(tokenEnvVar)
+	}
+
+	config, configDir, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if *outputRoot == "" {
+		*outputRoot = config.DefaultOutputDir
+	}
+	if *outputRoot == "" {
+		*outputRoot = server.DefaultOutputRoot
+	}
+
+	templates, err := core.NewTemplateManager(configDir)
+	if err != nil {
+		return fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	var engine core.ScribeEngine = core.NewRealScribeEngine()
+	if *mock {
+		engine = core.NewMockScribeEngine()
+	}
+
+	processor := core.NewBatchProcessorFromConfig(engine, config)
+	processor.SetCredentials(core.NewCredentialStore(configDir, nil))
+	defer processor.Shutdown()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := []server.Option{server.WithToken(*token), server.WithTemplates(templates), server.WithOutputRoot(*outputRoot)}
+	if *inputRoot != "" {
+		opts = append(opts, server.WithInputRoot(*inputRoot))
+	}
+	srv := server.New(processor, opts...)
+	return srv.ListenAndServe(ctx, *addr)
+}
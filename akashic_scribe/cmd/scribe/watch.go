@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"akashic_scribe/core"
+)
+
+// runWatch implements "scribe watch".
+func runWatch(args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	template := flags.String("template", "", "template applied to files in the folders given as arguments")
+	output := flags.String("output", "", "output root for the folders given as arguments (default <folder>/output)")
+	pipeline := flags.String("pipeline", "", "pipeline for the folders given as arguments (see 'scribe pipelines')")
+	stable := flags.Duration("stable", 0, "how long a file's size must stay unchanged before processing (default from config, 5s)")
+	configPath := flags.String("config", "", "path to config.json (default: user config directory)")
+	mock := flags.Bool("mock", false, "use the mock engine instead of yt-dlp/ffmpeg")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: scribe watch [flags] [folder...]")
+		fmt.Fprintln(flags.Output(), "Without folder arguments, the watch_folders from the config are used.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	config, configDir, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	folders := config.WatchFolders
+	if flags.NArg() > 0 {
+		folders = nil
+		for _, path := range flags.Args() {
+			folders = append(folders, core.WatchFolder{Path: path, Template: *template, OutputDir: *output, Pipeline: *pipeline})
+		}
+	}
+	if len(folders) == 0 {
+		return errors.New("no folders given and no watch_folders in config")
+	}
+
+	templates, err := core.NewTemplateManager(configDir)
+	if err != nil {
+		return fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	stableFor := config.WatchStableDuration()
+	if *stable > 0 {
+		stableFor = *stable
+	}
+
+	var engine core.ScribeEngine = core.NewRealScribeEngine()
+	if *mock {
+		engine = core.NewMockScribeEngine()
+	}
+
+	processor := core.NewBatchProcessorFromConfig(engine, config)
+	processor.SetCredentials(core.NewCredentialStore(configDir, nil))
+	defer processor.Shutdown()
+
+	watcher, err := core.NewFolderWatcher(processor, templates, config, folders, core.WithStableDuration(stableFor))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "Watching %d folder(s), files are processed after %s without changes. Press Ctrl+C to stop.\n",
+		len(folders), stableFor.Round(time.Millisecond))
+	return watcher.Run(ctx)
+}
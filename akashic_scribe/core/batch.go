@@ -2,9 +2,13 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,16 +17,84 @@ import (
 
 // BatchJob represents a single job in a batch processing queue.
 type BatchJob struct {
-	ID          string             // Unique identifier for the job
-	Options     ScribeOptions      // Processing options for this job
-	Status      JobStatus          // Current status of the job
-	Error       error              // Error if job failed
-	Result      *ScribeResult      // Result if job succeeded
-	StartTime   time.Time          // When the job started processing
-	EndTime     time.Time          // When the job completed
-	Progress    float64            // Current progress (0.0 to 1.0)
-	StatusMsg   string             // Current status message
-	jobCancel   context.CancelFunc // Function to cancel this specific job
+	ID         string             // Unique identifier for the job
+	Options    ScribeOptions      // Processing options for this job
+	Priority   JobPriority        // Scheduling priority (higher runs first)
+	Status     JobStatus          // Current status of the job
+	Error      error              // Error if job failed
+	ErrorClass ErrorClass         // Classification of the most recent error
+	LastError  error              // Most recent attempt error, kept even if a retry succeeded
+	Attempts   int                // Number of processing attempts made so far
+	Result     *ScribeResult      // Result if job succeeded
+	QueuedAt   time.Time          // When the job was added to the batch
+	Webhooks   []string           // Per-job webhook URLs notified when the job finishes
+	StartTime  time.Time          // When the job started processing
+	EndTime    time.Time          // When the job completed
+	Progress   float64            // Current progress (0.0 to 1.0)
+	StatusMsg  string             // Current status message
+	jobCancel  context.CancelFunc // Function to cancel this specific job
+	gate       *pauseGate         // Pauses this job at its next stage boundary
+}
+
+// JobPriority determines the order in which pending jobs are dispatched.
+type JobPriority int
+
+const (
+	PriorityLow    JobPriority = -1
+	PriorityNormal JobPriority = 0
+	PriorityHigh   JobPriority = 1
+	PriorityUrgent JobPriority = 2
+)
+
+// String returns the string representation of a JobPriority.
+func (p JobPriority) String() string {
+	switch p {
+	case PriorityLow:
+		return "Low"
+	case PriorityNormal:
+		return "Normal"
+	case PriorityHigh:
+		return "High"
+	case PriorityUrgent:
+		return "Urgent"
+	default:
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+}
+
+// ParseJobPriority parses a priority name such as "high" (case-insensitive).
+// An empty string yields PriorityNormal.
+func ParseJobPriority(name string) (JobPriority, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "low":
+		return PriorityLow, nil
+	case "", "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	case "urgent":
+		return PriorityUrgent, nil
+	default:
+		return PriorityNormal, fmt.Errorf("unknown priority: %s", name)
+	}
+}
+
+// JobOption customises a job when it is added to a BatchProcessor.
+type JobOption func(*BatchJob)
+
+// WithPriority sets the scheduling priority of a job.
+func WithPriority(priority JobPriority) JobOption {
+	return func(job *BatchJob) {
+		job.Priority = priority
+	}
+}
+
+// WithOutputDirPerJob nests the job's output directory in a directory named
+// after its ID, so jobs with the same OutputDir never share output files.
+func WithOutputDirPerJob() JobOption {
+	return func(job *BatchJob) {
+		job.Options.OutputDir = filepath.Join(job.Options.OutputDir, job.ID)
+	}
 }
 
 // JobStatus represents the current state of a batch job.
@@ -34,6 +106,7 @@ const (
 	JobCompleted
 	JobFailed
 	JobCancelled
+	JobPaused
 )
 
 // String returns the string representation of a JobStatus.
@@ -49,6 +122,8 @@ func (s JobStatus) String() string {
 		return "Failed"
 	case JobCancelled:
 		return "Cancelled"
+	case JobPaused:
+		return "Paused"
 	default:
 		return "Unknown"
 	}
@@ -56,16 +131,36 @@ func (s JobStatus) String() string {
 
 // BatchProcessor manages batch processing of multiple video files.
 type BatchProcessor struct {
-	engine         ScribeEngine
-	jobs           map[string]*BatchJob
-	jobQueue       chan *BatchJob
-	maxConcurrent  int
-	workers        sync.WaitGroup
-	jobWaitGroup   sync.WaitGroup // Tracks active jobs for efficient waiting
-	ctx            context.Context
-	cancel         context.CancelFunc
-	mu             sync.RWMutex
-	progressChan   chan BatchProgress
+	engine        ScribeEngine
+	jobs          map[string]*BatchJob
+	queue         []*BatchJob      // Pending and paused jobs in dispatch order
+	queueCond     *sync.Cond       // Signalled when the queue or pause state changes
+	closed        bool             // Set once the processor is shutting down
+	paused        bool             // Whether dispatching of new jobs is paused
+	gate          *pauseGate       // Pauses all running jobs at their next stage boundary
+	maxConcurrent int              // Maximum number of jobs running at once
+	running       int              // Number of jobs currently held by workers
+	workerCount   int              // Number of worker goroutines started
+	limiter       *ResourceLimiter // Per-resource limits shared by all jobs
+	retryPolicies map[ErrorClass]RetryPolicy
+	workers       sync.WaitGroup
+	jobWaitGroup  sync.WaitGroup // Tracks active jobs for efficient waiting
+	hookRuns      sync.WaitGroup // job_complete hooks still running
+	ctx           context.Context
+	cancel        context.CancelFunc
+	stopOnce      sync.Once // Guards shutdown so Wait and Shutdown can both be called
+	mu            sync.RWMutex
+	progressChan  chan BatchProgress // Legacy lossy snapshots; see Subscribe for events
+	subsMu        sync.Mutex
+	subscribers   []*Subscription
+	subsClosed    bool
+	webhooks      *webhookNotifier
+	commandHooks  []CommandHook   // Run at pipeline events and when jobs finish
+	prices        PriceTable      // Used to cost jobs (nil = DefaultPriceTable)
+	budget        float64         // Most the batch may spend in USD (0 = unlimited)
+	spent         float64         // Estimated cost of the jobs completed so far
+	credentials   CredentialStore // API keys for jobs (nil = environment variables)
+	providers     *Providers      // Provider endpoints and HTTP clients (nil = defaults)
 }
 
 // BatchProgress represents progress for the entire batch operation.
@@ -75,6 +170,7 @@ type BatchProgress struct {
 	FailedJobs     int
 	RunningJobs    int
 	PendingJobs    int
+	PausedJobs     int
 	OverallPercent float64
 	CurrentJobID   string
 	CurrentJobMsg  string
@@ -86,142 +182,458 @@ type BatchProgress struct {
 //   - engine: The ScribeEngine to use for processing
 //   - maxConcurrent: Maximum number of jobs to process simultaneously (0 = number of CPUs)
 func NewBatchProcessor(engine ScribeEngine, maxConcurrent int) *BatchProcessor {
-	if maxConcurrent <= 0 {
-		maxConcurrent = runtime.NumCPU() // Default to number of CPUs for performance
+	return newBatchProcessor(engine, maxConcurrent, NewResourceLimiter(ResourceLimits{}))
+}
+
+// NewBatchProcessorFromConfig creates a batch processor using the job and
+// resource limits from config. It also applies the config's provider limits,
+// which are shared by every job in the process.
+func NewBatchProcessorFromConfig(engine ScribeEngine, config *Config) *BatchProcessor {
+	if config == nil {
+		config = DefaultConfig()
 	}
+	bp := newBatchProcessor(engine, config.MaxConcurrentJobs, NewResourceLimiter(config.ResourceLimits()))
+	bp.webhooks.setConfig(config.WebhookConfig())
+	bp.commandHooks = config.CommandHooks
+	bp.prices = config.PriceTable()
+	bp.budget = config.BatchBudget
+	bp.providers = config.NewProviders()
+	ConfigureProviderLimits(config.ProviderLimits())
+	return bp
+}
 
+// newBatchProcessor creates a batch processor and starts its workers.
+func newBatchProcessor(engine ScribeEngine, maxConcurrent int, limiter *ResourceLimiter) *BatchProcessor {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	bp := &BatchProcessor{
 		engine:        engine,
 		jobs:          make(map[string]*BatchJob),
-		jobQueue:      make(chan *BatchJob, 100), // Buffer for queued jobs
-		maxConcurrent: maxConcurrent,
+		gate:          newPauseGate(),
+		limiter:       limiter,
+		retryPolicies: DefaultRetryPolicies(),
 		ctx:           ctx,
 		cancel:        cancel,
 		progressChan:  make(chan BatchProgress, 10),
 	}
+	bp.queueCond = sync.NewCond(&bp.mu)
+	bp.webhooks = newWebhookNotifier(bp)
 
-	// Start worker goroutines
-	for i := 0; i < maxConcurrent; i++ {
+	// Wake idle workers when the processor shuts down
+	go func() {
+		<-ctx.Done()
+		bp.mu.Lock()
+		bp.closed = true
+		bp.mu.Unlock()
+		bp.queueCond.Broadcast()
+	}()
+
+	bp.SetMaxConcurrent(maxConcurrent)
+
+	return bp
+}
+
+// SetMaxConcurrent changes the maximum number of jobs processed at once
+// (0 = number of CPUs). Lowering the limit lets running jobs finish; it only
+// delays dispatching of further jobs.
+func (bp *BatchProcessor) SetMaxConcurrent(maxConcurrent int) {
+	if maxConcurrent <= 0 {
+		maxConcurrent = runtime.NumCPU() // Default to number of CPUs for performance
+	}
+
+	bp.mu.Lock()
+	bp.maxConcurrent = maxConcurrent
+
+	// Start additional worker goroutines if the limit grew; surplus workers stay idle
+	for bp.workerCount < maxConcurrent && !bp.closed {
 		bp.workers.Add(1)
-		go bp.worker(i)
+		go bp.worker(bp.workerCount)
+		bp.workerCount++
 	}
+	bp.mu.Unlock()
 
-	return bp
+	bp.queueCond.Broadcast()
+	componentLogger("batch").Info("Max concurrent jobs set", "max_concurrent", maxConcurrent)
+}
+
+// MaxConcurrent returns the current maximum number of jobs processed at once.
+func (bp *BatchProcessor) MaxConcurrent() int {
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+
+	return bp.maxConcurrent
+}
+
+// Limiter returns the resource limiter shared by this processor's jobs.
+// Its limits can be adjusted at runtime.
+func (bp *BatchProcessor) Limiter() *ResourceLimiter {
+	return bp.limiter
+}
+
+// SetRetryPolicy overrides the retry policy used for errors of the given class.
+func (bp *BatchProcessor) SetRetryPolicy(class ErrorClass, policy RetryPolicy) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	bp.retryPolicies[class] = policy
+}
+
+// retryPolicy returns the policy for class. Callers must hold bp.mu.
+func (bp *BatchProcessor) retryPolicy(class ErrorClass) RetryPolicy {
+	if policy, ok := bp.retryPolicies[class]; ok {
+		return policy
+	}
+	return RetryPolicy{MaxAttempts: 1}
 }
 
-// AddJob adds a new job to the batch queue.
-// This method will block if the queue is full to prevent unbounded goroutine creation.
-func (bp *BatchProcessor) AddJob(options ScribeOptions) string {
+// AddJob adds a new job to the batch queue and returns its ID.
+// Jobs are queued behind any pending jobs of equal or higher priority.
+func (bp *BatchProcessor) AddJob(options ScribeOptions, opts ...JobOption) string {
 	bp.mu.Lock()
 
 	// Generate unique job ID using UUID to prevent collisions
 	jobID := fmt.Sprintf("job_%s", uuid.New().String())
 
 	job := &BatchJob{
-		ID:      jobID,
-		Options: options,
-		Status:  JobPending,
+		ID:       jobID,
+		Options:  options,
+		Priority: PriorityNormal,
+		Status:   JobPending,
+		QueuedAt: time.Now(),
+		gate:     newPauseGate(),
+	}
+	for _, opt := range opts {
+		opt(job)
 	}
 
 	bp.jobs[jobID] = job
+	bp.enqueue(job)
 	bp.jobWaitGroup.Add(1) // Track this job for Wait()
 	bp.mu.Unlock()
 
-	// Add to queue (blocks if full, preventing goroutine leak)
-	log.Printf("Batch: Job %s added to queue", jobID)
-	bp.jobQueue <- job
+	componentLogger("batch").Info("Job queued", "job_id", jobID, "priority", job.Priority)
+	bp.publish(BatchEvent{Type: EventJobQueued, JobID: jobID})
+	bp.queueCond.Signal()
+	bp.sendProgress()
 
 	return jobID
 }
 
+// enqueue inserts job after the last queued job of equal or higher priority.
+// Callers must hold bp.mu.
+func (bp *BatchProcessor) enqueue(job *BatchJob) {
+	pos := len(bp.queue)
+	for pos > 0 && bp.queue[pos-1].Priority < job.Priority {
+		pos--
+	}
+	bp.queue = append(bp.queue, nil)
+	copy(bp.queue[pos+1:], bp.queue[pos:])
+	bp.queue[pos] = job
+}
+
+// dequeue removes job from the queue. Callers must hold bp.mu.
+func (bp *BatchProcessor) dequeue(job *BatchJob) bool {
+	idx := bp.queueIndex(job.ID)
+	if idx < 0 {
+		return false
+	}
+	bp.queue = append(bp.queue[:idx], bp.queue[idx+1:]...)
+	return true
+}
+
+// queueIndex returns the position of a job in the queue, or -1. Callers must hold bp.mu.
+func (bp *BatchProcessor) queueIndex(jobID string) int {
+	for i, job := range bp.queue {
+		if job.ID == jobID {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextJob blocks until a job can be dispatched and removes it from the queue.
+// It returns nil once the processor is shutting down.
+func (bp *BatchProcessor) nextJob() *BatchJob {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	for {
+		if bp.closed {
+			return nil
+		}
+		if !bp.paused && bp.running < bp.maxConcurrent {
+			for _, job := range bp.queue {
+				if job.Status == JobPending {
+					bp.dequeue(job)
+					bp.running++
+					return job
+				}
+			}
+		}
+		bp.queueCond.Wait()
+	}
+}
+
 // worker processes jobs from the queue.
 func (bp *BatchProcessor) worker(workerID int) {
 	defer bp.workers.Done()
 
-	log.Printf("Batch: Worker %d started", workerID)
+	componentLogger("batch").Debug("Worker started", "worker", workerID)
 
 	for {
-		select {
-		case <-bp.ctx.Done():
-			log.Printf("Batch: Worker %d shutting down", workerID)
+		job := bp.nextJob()
+		if job == nil {
+			componentLogger("batch").Debug("Worker shutting down", "worker", workerID)
 			return
-
-		case job := <-bp.jobQueue:
-			bp.processJob(workerID, job)
 		}
+		bp.processJob(workerID, job)
 	}
 }
 
-// processJob processes a single job.
+// processJob processes a single job, retrying failed attempts according to
+// the retry policy for the class of error returned by the engine.
 func (bp *BatchProcessor) processJob(workerID int, job *BatchJob) {
 	defer bp.jobWaitGroup.Done() // Mark job complete for Wait()
-
-	// Create a cancellable context for this job
-	jobCtx, jobCancel := context.WithCancel(bp.ctx)
+	defer bp.releaseSlot()
+
+	// Create a cancellable context for this job that honours processor and job
+	// pauses as well as the shared resource limits. Its logger tags records
+	// with the job ID.
+	logger := componentLogger("batch").With("job_id", job.ID)
+	ctx := WithResourceLimiter(withPauseGates(bp.ctx, bp.gate, job.gate), bp.limiter)
+	jobCtx, jobCancel := context.WithCancel(WithLogger(ctx, logger))
 	defer jobCancel()
+	jobCtx, span := StartSpan(jobCtx, "job", slog.String("job_id", job.ID))
 
 	bp.mu.Lock()
-	job.Status = JobRunning
+	hooks := bp.commandHooks
+	if len(hooks) > 0 {
+		jobCtx = WithCommandHooks(jobCtx, job.ID, hooks)
+	}
+	if bp.prices != nil {
+		jobCtx = WithPriceTable(jobCtx, bp.prices)
+	}
+	if bp.credentials != nil {
+		jobCtx = WithCredentials(jobCtx, bp.credentials)
+	}
+	if bp.providers != nil {
+		jobCtx = WithProviders(jobCtx, bp.providers)
+	}
+	if job.Status == JobCancelled {
+		// Cancelled after nextJob dequeued it but before it got a cancel
+		// function, so CancelJob could only mark it
+		job.EndTime = time.Now()
+		snapshot := *job
+		bp.mu.Unlock()
+
+		span.End(context.Canceled)
+		DefaultMetrics().RecordJob(MetricStatusCancelled, 0)
+		logger.Info("Job cancelled before it started")
+		bp.publish(BatchEvent{Type: EventJobCancelled, JobID: job.ID, Error: context.Canceled})
+		bp.startJobCompleteHooks(hooks, snapshot)
+		bp.sendProgress()
+		return
+	}
+	if job.gate.isPaused() {
+		job.Status = JobPaused
+	} else {
+		job.Status = JobRunning
+	}
 	job.StartTime = time.Now()
 	job.jobCancel = jobCancel // Store cancel function for CancelJob()
 	bp.mu.Unlock()
 
-	log.Printf("Batch: Worker %d processing job %s", workerID, job.ID)
+	logger.Info("Job started", "worker", workerID)
 	bp.sendProgress()
 
-	// Create a progress channel for this job
-	progressChan := make(chan ProgressUpdate, 10)
-
-	// Listen for progress updates
-	done := make(chan bool)
-	go func() {
-		for {
-			select {
-			case update := <-progressChan:
-				bp.mu.Lock()
-				job.Progress = update.Percentage
-				job.StatusMsg = update.Message
-				bp.mu.Unlock()
-				bp.sendProgress()
-
-			case <-done:
-				return
+	for {
+		bp.mu.Lock()
+		job.Attempts++
+		attempt := job.Attempts
+		bp.mu.Unlock()
+
+		bp.publish(BatchEvent{Type: EventJobStarted, JobID: job.ID, Attempt: attempt})
+		result, err := bp.runAttempt(jobCtx, job, attempt)
+
+		if err == nil {
+			bp.mu.Lock()
+			job.EndTime = time.Now()
+			job.Status = JobCompleted
+			job.Result = result
+			job.Error = nil
+			job.Progress = 1.0
+			if result != nil {
+				bp.spent += result.TotalCost()
 			}
+			bp.mu.Unlock()
+			logger.Info("Job completed", "attempt", attempt)
+			bp.publish(BatchEvent{Type: EventJobSucceeded, JobID: job.ID, Attempt: attempt, Progress: 1.0, Result: result})
+			break
 		}
-	}()
-
-	// Process the job
-	result, err := bp.engine.ProcessWithContext(jobCtx, job.Options, progressChan)
 
-	close(done)
-	close(progressChan)
+		class := ClassifyError(err)
+		if errors.Is(jobCtx.Err(), context.Canceled) {
+			class = ErrorClassCancelled
+		}
 
-	bp.mu.Lock()
-	job.EndTime = time.Now()
+		bp.mu.Lock()
+		job.LastError = err
+		job.ErrorClass = class
+		policy := bp.retryPolicy(class)
 
-	if err != nil {
-		if jobCtx.Err() == context.Canceled {
+		if class == ErrorClassCancelled {
+			job.EndTime = time.Now()
 			job.Status = JobCancelled
-			log.Printf("Batch: Job %s cancelled", job.ID)
-		} else {
+			bp.mu.Unlock()
+			logger.Info("Job cancelled", "attempt", attempt)
+			bp.publish(BatchEvent{Type: EventJobCancelled, JobID: job.ID, Attempt: attempt, Error: err})
+			break
+		}
+
+		if attempt >= policy.attempts() {
+			job.EndTime = time.Now()
 			job.Status = JobFailed
 			job.Error = err
-			log.Printf("Batch: Job %s failed: %v", job.ID, err)
+			bp.mu.Unlock()
+			logger.Error("Job failed", "attempts", attempt, "error_class", class.String(), "error", err)
+			bp.publish(BatchEvent{Type: EventJobFailed, JobID: job.ID, Attempt: attempt, Error: err})
+			break
 		}
-	} else {
-		job.Status = JobCompleted
-		job.Result = result
-		job.Progress = 1.0
-		log.Printf("Batch: Job %s completed successfully", job.ID)
+
+		delay := policy.Backoff(attempt)
+		job.Progress = 0
+		job.StatusMsg = fmt.Sprintf("Retrying in %s after %s error (attempt %d of %d)",
+			delay.Round(time.Millisecond), class, attempt, policy.attempts())
+		retryMsg := job.StatusMsg
+		bp.mu.Unlock()
+
+		logger.Warn("Job attempt failed; retrying", "attempt", attempt, "error_class", class.String(), "error", err, "delay", delay)
+		bp.publish(BatchEvent{Type: EventJobProgress, JobID: job.ID, Attempt: attempt, Message: retryMsg, Error: err})
+		bp.sendProgress()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+			continue
+		case <-jobCtx.Done():
+			timer.Stop()
+		}
+
+		bp.mu.Lock()
+		job.EndTime = time.Now()
+		job.Status = JobCancelled
+		bp.mu.Unlock()
+		logger.Info("Job cancelled while waiting to retry", "attempt", attempt)
+		bp.publish(BatchEvent{Type: EventJobCancelled, JobID: job.ID, Attempt: attempt, Error: jobCtx.Err()})
+		break
 	}
 
-	bp.mu.Unlock()
+	snapshot, ok := bp.JobSnapshot(job.ID)
+	if ok {
+		span.SetAttributes(slog.String("status", snapshot.Status.String()), slog.Int("attempts", snapshot.Attempts))
+		jobErr := snapshot.Error
+		if snapshot.Status == JobCancelled {
+			jobErr = snapshot.LastError
+		}
+		span.End(jobErr)
+		DefaultMetrics().RecordJob(strings.ToLower(snapshot.Status.String()), snapshot.EndTime.Sub(snapshot.StartTime))
+	}
+
+	if ok {
+		bp.startJobCompleteHooks(hooks, snapshot)
+	}
 	bp.sendProgress()
 }
 
-// sendProgress sends batch progress updates to the progress channel.
+// startJobCompleteHooks runs the job_complete hooks for a finished job in the
+// background, so slow hooks hold up neither a worker nor bp.mu. stop waits
+// for them; callers must start them before the job is marked done for Wait.
+func (bp *BatchProcessor) startJobCompleteHooks(hooks []CommandHook, job BatchJob) {
+	if len(hooks) == 0 {
+		return
+	}
+	bp.hookRuns.Add(1)
+	go func() {
+		defer bp.hookRuns.Done()
+		RunJobCompleteHooks(hooks, job)
+	}()
+}
+
+// releaseSlot frees the concurrency slot held by a finished job.
+func (bp *BatchProcessor) releaseSlot() {
+	bp.mu.Lock()
+	bp.running--
+	bp.mu.Unlock()
+
+	bp.queueCond.Broadcast()
+}
+
+// runAttempt runs the engine once for job, forwarding engine progress into the
+// job state and the event stream.
+func (bp *BatchProcessor) runAttempt(jobCtx context.Context, job *BatchJob, attempt int) (*ScribeResult, error) {
+	// Respect pauses before each attempt, even if the engine has no checkpoints
+	if err := StageCheckpoint(jobCtx); err != nil {
+		return nil, err
+	}
+
+	// Jobs that have not started yet are halted once the budget is spent
+	if attempt == 1 {
+		if err := bp.checkBudget(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create a progress channel for this attempt
+	progressChan := make(chan ProgressUpdate, 10)
+
+	// Listen for progress updates until the engine returns
+	listenerDone := make(chan struct{})
+	go func() {
+		defer close(listenerDone)
+		stage := ""
+		for update := range progressChan {
+			bp.mu.Lock()
+			job.Progress = update.Percentage
+			job.StatusMsg = update.Message
+			bp.mu.Unlock()
+
+			// Engines that don't report stages are assumed to change stage with each new message
+			current := update.Stage
+			if current == "" {
+				current = update.Message
+			}
+			event := BatchEvent{
+				JobID:         job.ID,
+				Attempt:       attempt,
+				Stage:         current,
+				Progress:      update.Percentage,
+				Message:       update.Message,
+				StageProgress: update.StageProgress,
+				ETA:           update.ETA,
+			}
+			if current != stage {
+				stage = current
+				event.Type = EventStageChanged
+			} else {
+				event.Type = EventJobProgress
+			}
+			bp.publish(event)
+			bp.sendProgress()
+		}
+	}()
+
+	// Process the job
+	result, err := bp.engine.ProcessWithContext(jobCtx, job.Options, progressChan)
+
+	close(progressChan)
+	<-listenerDone
+
+	return result, err
+}
+
+// sendProgress sends batch progress snapshots to the legacy progress channel.
+// Snapshots are dropped when the channel is full; use Subscribe for a
+// lossless stream of job events.
 func (bp *BatchProcessor) sendProgress() {
 	bp.mu.RLock()
 	defer bp.mu.RUnlock()
@@ -231,8 +643,7 @@ func (bp *BatchProcessor) sendProgress() {
 	}
 
 	var totalProgress float64
-	var currentJobID string
-	var currentJobMsg string
+	var currentJob *BatchJob
 
 	for _, job := range bp.jobs {
 		switch job.Status {
@@ -245,12 +656,17 @@ func (bp *BatchProcessor) sendProgress() {
 		case JobRunning:
 			progress.RunningJobs++
 			totalProgress += job.Progress
-			if currentJobID == "" {
-				currentJobID = job.ID
-				currentJobMsg = job.StatusMsg
+			// Report the longest-running job so the value doesn't jump between updates
+			if currentJob == nil || job.StartTime.Before(currentJob.StartTime) {
+				currentJob = job
 			}
 		case JobPending:
 			progress.PendingJobs++
+		case JobPaused:
+			progress.PausedJobs++
+			if !job.StartTime.IsZero() {
+				totalProgress += job.Progress
+			}
 		}
 	}
 
@@ -258,18 +674,22 @@ func (bp *BatchProcessor) sendProgress() {
 		progress.OverallPercent = totalProgress / float64(progress.TotalJobs)
 	}
 
-	progress.CurrentJobID = currentJobID
-	progress.CurrentJobMsg = currentJobMsg
+	if currentJob != nil {
+		progress.CurrentJobID = currentJob.ID
+		progress.CurrentJobMsg = currentJob.StatusMsg
+	}
 
 	// Non-blocking send with logging when dropped
 	select {
 	case bp.progressChan <- progress:
 	default:
-		log.Printf("Warning: Progress update dropped (channel full) [OverallPercent=%.2f%%]", progress.OverallPercent*100)
+		componentLogger("batch").Warn("Progress update dropped (channel full)", "overall_percent", progress.OverallPercent*100)
 	}
 }
 
-// GetProgress returns the progress channel for batch updates.
+// GetProgress returns the progress channel for batch snapshots.
+//
+// Deprecated: snapshots can be dropped. Use Subscribe instead.
 func (bp *BatchProcessor) GetProgress() <-chan BatchProgress {
 	return bp.progressChan
 }
@@ -283,6 +703,34 @@ func (bp *BatchProcessor) GetJob(jobID string) (*BatchJob, bool) {
 	return job, exists
 }
 
+// JobSnapshot returns a copy of a job that is safe to read while the
+// processor keeps updating the original.
+func (bp *BatchProcessor) JobSnapshot(jobID string) (BatchJob, bool) {
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+
+	job, exists := bp.jobs[jobID]
+	if !exists {
+		return BatchJob{}, false
+	}
+	return *job, true
+}
+
+// JobSnapshots returns copies of all jobs, ordered by the time they were queued.
+func (bp *BatchProcessor) JobSnapshots() []BatchJob {
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+
+	jobs := make([]BatchJob, 0, len(bp.jobs))
+	for _, job := range bp.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].QueuedAt.Before(jobs[j].QueuedAt)
+	})
+	return jobs
+}
+
 // GetAllJobs returns all jobs in the batch.
 func (bp *BatchProcessor) GetAllJobs() []*BatchJob {
 	bp.mu.RLock()
@@ -295,7 +743,7 @@ func (bp *BatchProcessor) GetAllJobs() []*BatchJob {
 	return jobs
 }
 
-// CancelJob cancels a specific job if it's pending or running.
+// CancelJob cancels a specific job if it's pending, paused or running.
 func (bp *BatchProcessor) CancelJob(jobID string) error {
 	bp.mu.Lock()
 	defer bp.mu.Unlock()
@@ -305,52 +753,238 @@ func (bp *BatchProcessor) CancelJob(jobID string) error {
 		return fmt.Errorf("job %s not found", jobID)
 	}
 
-	if job.Status == JobCompleted || job.Status == JobFailed || job.Status == JobCancelled {
+	if isFinished(job.Status) {
 		return fmt.Errorf("job %s already finished with status: %s", jobID, job.Status)
 	}
 
-	// Actually cancel the running job by calling its cancel function
-	if job.jobCancel != nil {
+	bp.cancelJobLocked(job)
+	componentLogger("batch").Info("Cancelling job", "job_id", jobID)
+	return nil
+}
+
+// CancelAll cancels all pending, paused and running jobs.
+func (bp *BatchProcessor) CancelAll() {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	for _, job := range bp.jobs {
+		if !isFinished(job.Status) {
+			bp.cancelJobLocked(job)
+		}
+	}
+}
+
+// cancelJobLocked cancels an unfinished job. Queued jobs are removed from the
+// queue; running jobs are cancelled through their context. Callers must hold bp.mu.
+func (bp *BatchProcessor) cancelJobLocked(job *BatchJob) {
+	if bp.dequeue(job) {
+		// The job never reached a worker, so finish it and release it from
+		// Wait() here
+		job.EndTime = time.Now()
+		job.Status = JobCancelled
+		bp.startJobCompleteHooks(bp.commandHooks, *job)
+		bp.jobWaitGroup.Done()
+		DefaultMetrics().RecordJob(MetricStatusCancelled, 0)
+		bp.publish(BatchEvent{Type: EventJobCancelled, JobID: job.ID, Error: context.Canceled})
+	} else if job.jobCancel != nil {
+		// Actually cancel the running job by calling its cancel function
 		job.jobCancel()
-		log.Printf("Batch: Cancelling job %s", jobID)
 	}
 
 	job.Status = JobCancelled
+}
+
+// isFinished reports whether status is terminal.
+func isFinished(status JobStatus) bool {
+	return status == JobCompleted || status == JobFailed || status == JobCancelled
+}
+
+// Pause stops dispatching new jobs. Running jobs continue until their next
+// stage boundary and then wait there until Resume is called.
+// Note that Wait will not return while the processor is paused with jobs left.
+func (bp *BatchProcessor) Pause() {
+	bp.mu.Lock()
+	bp.paused = true
+	bp.mu.Unlock()
+
+	bp.gate.pause()
+	componentLogger("batch").Info("Processor paused")
+	bp.sendProgress()
+}
+
+// Resume restarts job dispatching and releases running jobs held at a stage boundary.
+func (bp *BatchProcessor) Resume() {
+	bp.mu.Lock()
+	bp.paused = false
+	bp.mu.Unlock()
+
+	bp.gate.unpause()
+	bp.queueCond.Broadcast()
+	componentLogger("batch").Info("Processor resumed")
+	bp.sendProgress()
+}
+
+// IsPaused reports whether the processor as a whole is paused.
+func (bp *BatchProcessor) IsPaused() bool {
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+
+	return bp.paused
+}
+
+// PauseJob pauses a single job. A pending job is held in the queue; a running
+// job stops at its next stage boundary.
+func (bp *BatchProcessor) PauseJob(jobID string) error {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	job, exists := bp.jobs[jobID]
+	if !exists {
+		return fmt.Errorf("job %s not found", jobID)
+	}
+
+	switch job.Status {
+	case JobPending, JobRunning:
+		job.gate.pause()
+		job.Status = JobPaused
+		componentLogger("batch").Info("Job paused", "job_id", jobID)
+		return nil
+	case JobPaused:
+		return nil
+	default:
+		return fmt.Errorf("job %s cannot be paused with status: %s", jobID, job.Status)
+	}
+}
+
+// ResumeJob resumes a paused job.
+func (bp *BatchProcessor) ResumeJob(jobID string) error {
+	bp.mu.Lock()
+
+	job, exists := bp.jobs[jobID]
+	if !exists {
+		bp.mu.Unlock()
+		return fmt.Errorf("job %s not found", jobID)
+	}
+
+	if job.Status != JobPaused {
+		bp.mu.Unlock()
+		return fmt.Errorf("job %s is not paused (status: %s)", jobID, job.Status)
+	}
+
+	job.gate.unpause()
+	if job.StartTime.IsZero() {
+		job.Status = JobPending
+	} else {
+		job.Status = JobRunning
+	}
+	bp.mu.Unlock()
+
+	componentLogger("batch").Info("Job resumed", "job_id", jobID)
+	bp.queueCond.Broadcast()
 	return nil
 }
 
-// CancelAll cancels all pending and running jobs.
-func (bp *BatchProcessor) CancelAll() {
+// SetJobPriority changes the priority of a queued job and repositions it accordingly.
+func (bp *BatchProcessor) SetJobPriority(jobID string, priority JobPriority) error {
 	bp.mu.Lock()
 	defer bp.mu.Unlock()
 
-	for _, job := range bp.jobs {
-		if job.Status == JobPending || job.Status == JobRunning {
-			if job.jobCancel != nil {
-				job.jobCancel()
-			}
-			job.Status = JobCancelled
+	job, exists := bp.jobs[jobID]
+	if !exists {
+		return fmt.Errorf("job %s not found", jobID)
+	}
+
+	job.Priority = priority
+	if bp.dequeue(job) {
+		bp.enqueue(job)
+	}
+	return nil
+}
+
+// MoveJob moves a queued job by offset positions (negative moves it towards
+// the front of the queue). The offset is clamped to the queue bounds.
+func (bp *BatchProcessor) MoveJob(jobID string, offset int) error {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	return bp.moveJobLocked(jobID, offset)
+}
+
+// MoveJobToFront moves a queued job to the front of the queue.
+func (bp *BatchProcessor) MoveJobToFront(jobID string) error {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	return bp.moveJobLocked(jobID, -len(bp.queue))
+}
+
+// moveJobLocked implements MoveJob. Callers must hold bp.mu.
+func (bp *BatchProcessor) moveJobLocked(jobID string, offset int) error {
+	idx := bp.queueIndex(jobID)
+	if idx < 0 {
+		if _, exists := bp.jobs[jobID]; !exists {
+			return fmt.Errorf("job %s not found", jobID)
 		}
+		return fmt.Errorf("job %s is not queued", jobID)
+	}
+
+	target := idx + offset
+	if target < 0 {
+		target = 0
+	}
+	if target > len(bp.queue)-1 {
+		target = len(bp.queue) - 1
 	}
+
+	job := bp.queue[idx]
+	if target < idx {
+		copy(bp.queue[target+1:idx+1], bp.queue[target:idx])
+	} else {
+		copy(bp.queue[idx:target], bp.queue[idx+1:target+1])
+	}
+	bp.queue[target] = job
+	return nil
+}
+
+// QueuedJobs returns the pending and paused jobs that have not started, in dispatch order.
+func (bp *BatchProcessor) QueuedJobs() []*BatchJob {
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+
+	jobs := make([]*BatchJob, len(bp.queue))
+	copy(jobs, bp.queue)
+	return jobs
 }
 
 // Wait waits for all jobs to complete and shuts down the processor.
+// Pending webhook deliveries, including their retries, finish before it returns.
 func (bp *BatchProcessor) Wait() {
 	// Efficiently wait for all jobs using WaitGroup instead of polling
 	bp.jobWaitGroup.Wait()
 
-	// Shutdown workers
-	bp.cancel()
-	bp.workers.Wait()
-	close(bp.progressChan)
+	bp.stop()
 }
 
 // Shutdown immediately cancels all jobs and shuts down the processor.
+// Pending webhooks get one final delivery attempt without retries.
+// It is safe to call after Wait.
 func (bp *BatchProcessor) Shutdown() {
 	bp.CancelAll()
-	bp.cancel()
-	bp.workers.Wait()
-	close(bp.progressChan)
+	bp.webhooks.abort()
+	bp.stop()
+}
+
+// stop shuts down the workers, waits for running job_complete hooks and closes
+// all progress channels exactly once.
+func (bp *BatchProcessor) stop() {
+	bp.stopOnce.Do(func() {
+		bp.cancel()
+		bp.workers.Wait()
+		bp.hookRuns.Wait()
+		close(bp.progressChan)
+		bp.closeSubscriptions()
+		bp.webhooks.wait()
+	})
 }
 
 // GetSummary returns a summary of the batch processing results.
@@ -358,7 +992,7 @@ func (bp *BatchProcessor) GetSummary() string {
 	bp.mu.RLock()
 	defer bp.mu.RUnlock()
 
-	var completed, failed, cancelled, pending, running int
+	var completed, failed, cancelled, pending, running, paused int
 
 	for _, job := range bp.jobs {
 		switch job.Status {
@@ -372,9 +1006,11 @@ func (bp *BatchProcessor) GetSummary() string {
 			pending++
 		case JobRunning:
 			running++
+		case JobPaused:
+			paused++
 		}
 	}
 
-	return fmt.Sprintf("Batch Summary: Total=%d, Completed=%d, Failed=%d, Cancelled=%d, Running=%d, Pending=%d",
-		len(bp.jobs), completed, failed, cancelled, running, pending)
+	return fmt.Sprintf("Batch Summary: Total=%d, Completed=%d, Failed=%d, Cancelled=%d, Running=%d, Pending=%d, Paused=%d",
+		len(bp.jobs), completed, failed, cancelled, running, pending, paused)
 }
@@ -0,0 +1,328 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedEngine is a ScribeEngine whose ProcessWithContext behaviour is supplied by the test.
+type scriptedEngine struct {
+	*MockScribeEngine
+	calls   atomic.Int32
+	process func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error)
+}
+
+func newScriptedEngine(process func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error)) *scriptedEngine {
+	return &scriptedEngine{MockScribeEngine: NewMockScribeEngine(), process: process}
+}
+
+func (e *scriptedEngine) ProcessWithContext(ctx context.Context, options ScribeOptions, progress chan<- ProgressUpdate) (*ScribeResult, error) {
+	call := int(e.calls.Add(1))
+	return e.process(ctx, call, options)
+}
+
+// fastRetryPolicy retries quickly so tests don't wait on real backoff delays.
+func fastRetryPolicy(attempts int) RetryPolicy {
+	return RetryPolicy{MaxAttempts: attempts, InitialBackoff: time.Millisecond, Multiplier: 2.0}
+}
+
+func TestBatchProcessor_RetriesTransientErrors(t *testing.T) {
+	engine := newScriptedEngine(func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error) {
+		if call < 3 {
+			return nil, NewScribeError(ErrorClassTransient, "tts", errors.New("status 503"))
+		}
+		return &ScribeResult{OutputDir: "/out"}, nil
+	})
+
+	bp := NewBatchProcessor(engine, 1)
+	bp.SetRetryPolicy(ErrorClassTransient, fastRetryPolicy(5))
+
+	jobID := bp.AddJob(ScribeOptions{InputFile: "flaky.mp4"})
+	bp.Wait()
+
+	job, ok := bp.GetJob(jobID)
+	require.True(t, ok)
+	assert.Equal(t, JobCompleted, job.Status)
+	assert.Equal(t, 3, job.Attempts)
+	assert.NoError(t, job.Error)
+	assert.Error(t, job.LastError, "The last failed attempt should be recorded")
+	assert.Equal(t, ErrorClassTransient, job.ErrorClass)
+}
+
+func TestBatchProcessor_GivesUpAfterMaxAttempts(t *testing.T) {
+	engine := newScriptedEngine(func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error) {
+		return nil, NewScribeError(ErrorClassTransient, "download", errors.New("connection reset"))
+	})
+
+	bp := NewBatchProcessor(engine, 1)
+	bp.SetRetryPolicy(ErrorClassTransient, fastRetryPolicy(3))
+
+	jobID := bp.AddJob(ScribeOptions{InputFile: "broken.mp4"})
+	bp.Wait()
+
+	job, _ := bp.GetJob(jobID)
+	assert.Equal(t, JobFailed, job.Status)
+	assert.Equal(t, 3, job.Attempts)
+	assert.EqualError(t, job.Error, "connection reset")
+}
+
+func TestBatchProcessor_DoesNotRetryInvalidInput(t *testing.T) {
+	engine := newScriptedEngine(func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error) {
+		return nil, NewScribeError(ErrorClassInvalidInput, "input", errors.New("input file not found"))
+	})
+
+	bp := NewBatchProcessor(engine, 1)
+	bp.SetRetryPolicy(ErrorClassTransient, fastRetryPolicy(5))
+
+	jobID := bp.AddJob(ScribeOptions{InputFile: "missing.mp4"})
+	bp.Wait()
+
+	job, _ := bp.GetJob(jobID)
+	assert.Equal(t, JobFailed, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, ErrorClassInvalidInput, job.ErrorClass)
+	assert.Equal(t, int32(1), engine.calls.Load())
+}
+
+func TestBatchProcessor_CancelDuringBackoff(t *testing.T) {
+	engine := newScriptedEngine(func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error) {
+		return nil, NewScribeError(ErrorClassTransient, "tts", errors.New("status 429"))
+	})
+
+	bp := NewBatchProcessor(engine, 1)
+	bp.SetRetryPolicy(ErrorClassTransient, RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour})
+
+	jobID := bp.AddJob(ScribeOptions{InputFile: "slow.mp4"})
+
+	require.Eventually(t, func() bool {
+		return engine.calls.Load() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, bp.CancelJob(jobID))
+	bp.Wait()
+
+	job, _ := bp.GetJob(jobID)
+	assert.Equal(t, JobCancelled, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+}
+
+// recordingEngine returns a scripted engine that records the InputFile of each job in call order.
+func recordingEngine(order *[]string, mu *sync.Mutex) *scriptedEngine {
+	return newScriptedEngine(func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error) {
+		mu.Lock()
+		*order = append(*order, options.InputFile)
+		mu.Unlock()
+		return &ScribeResult{}, nil
+	})
+}
+
+func TestBatchProcessor_PriorityOrdering(t *testing.T) {
+	var order []string
+	var mu sync.Mutex
+	bp := NewBatchProcessor(recordingEngine(&order, &mu), 1)
+
+	// Queue everything while paused so dispatch order depends only on the queue
+	bp.Pause()
+	bp.AddJob(ScribeOptions{InputFile: "normal-1"})
+	bp.AddJob(ScribeOptions{InputFile: "low"}, WithPriority(PriorityLow))
+	bp.AddJob(ScribeOptions{InputFile: "normal-2"})
+	bp.AddJob(ScribeOptions{InputFile: "urgent"}, WithPriority(PriorityUrgent))
+	bp.AddJob(ScribeOptions{InputFile: "high"}, WithPriority(PriorityHigh))
+	bp.Resume()
+	bp.Wait()
+
+	assert.Equal(t, []string{"urgent", "high", "normal-1", "normal-2", "low"}, order)
+}
+
+func TestBatchProcessor_MoveJob(t *testing.T) {
+	var order []string
+	var mu sync.Mutex
+	bp := NewBatchProcessor(recordingEngine(&order, &mu), 1)
+
+	bp.Pause()
+	bp.AddJob(ScribeOptions{InputFile: "a"})
+	b := bp.AddJob(ScribeOptions{InputFile: "b"})
+	c := bp.AddJob(ScribeOptions{InputFile: "c"})
+
+	require.NoError(t, bp.MoveJobToFront(c))
+	require.NoError(t, bp.MoveJob(b, 5), "Offsets beyond the queue should be clamped")
+
+	queued := bp.QueuedJobs()
+	require.Len(t, queued, 3)
+	assert.Equal(t, "c", queued[0].Options.InputFile)
+	assert.Equal(t, "b", queued[2].Options.InputFile)
+
+	bp.Resume()
+	bp.Wait()
+
+	assert.Equal(t, []string{"c", "a", "b"}, order)
+	assert.Error(t, bp.MoveJob(c, 1), "Finished jobs can no longer be moved")
+	assert.Error(t, bp.MoveJob("job_missing", 1))
+}
+
+func TestBatchProcessor_SetJobPriority(t *testing.T) {
+	var order []string
+	var mu sync.Mutex
+	bp := NewBatchProcessor(recordingEngine(&order, &mu), 1)
+
+	bp.Pause()
+	bp.AddJob(ScribeOptions{InputFile: "first"})
+	late := bp.AddJob(ScribeOptions{InputFile: "late"})
+	require.NoError(t, bp.SetJobPriority(late, PriorityUrgent))
+	bp.Resume()
+	bp.Wait()
+
+	assert.Equal(t, []string{"late", "first"}, order)
+}
+
+func TestBatchProcessor_PauseAndResumeJob(t *testing.T) {
+	var order []string
+	var mu sync.Mutex
+	bp := NewBatchProcessor(recordingEngine(&order, &mu), 1)
+
+	bp.Pause()
+	held := bp.AddJob(ScribeOptions{InputFile: "held"})
+	bp.AddJob(ScribeOptions{InputFile: "free"})
+	require.NoError(t, bp.PauseJob(held))
+	bp.Resume()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(order) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	job, _ := bp.GetJob(held)
+	assert.Equal(t, JobPaused, job.Status)
+
+	require.NoError(t, bp.ResumeJob(held))
+	bp.Wait()
+
+	assert.Equal(t, []string{"free", "held"}, order)
+	assert.Equal(t, JobCompleted, job.Status)
+	assert.Error(t, bp.ResumeJob(held), "Only paused jobs can be resumed")
+}
+
+func TestBatchProcessor_PauseHoldsRunningJobAtStageBoundary(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var stagesDone atomic.Int32
+
+	engine := newScriptedEngine(func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error) {
+		close(started)
+		<-release // Simulate a stage that is in progress when Pause is called
+		stagesDone.Add(1)
+
+		if err := StageCheckpoint(ctx); err != nil {
+			return nil, err
+		}
+		stagesDone.Add(1)
+		return &ScribeResult{}, nil
+	})
+
+	bp := NewBatchProcessor(engine, 1)
+	jobID := bp.AddJob(ScribeOptions{InputFile: "long.mp4"})
+
+	<-started
+	bp.Pause()
+	close(release)
+
+	require.Eventually(t, func() bool { return stagesDone.Load() == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(1), stagesDone.Load(), "The job should wait at the stage boundary while paused")
+
+	bp.Resume()
+	bp.Wait()
+
+	job, _ := bp.GetJob(jobID)
+	assert.Equal(t, JobCompleted, job.Status)
+	assert.Equal(t, int32(2), stagesDone.Load())
+}
+
+func TestBatchProcessor_CancelQueuedJob(t *testing.T) {
+	var order []string
+	var mu sync.Mutex
+	bp := NewBatchProcessor(recordingEngine(&order, &mu), 1)
+
+	bp.Pause()
+	cancelled := bp.AddJob(ScribeOptions{InputFile: "cancelled"})
+	bp.AddJob(ScribeOptions{InputFile: "kept"})
+	require.NoError(t, bp.CancelJob(cancelled))
+	bp.Resume()
+	bp.Wait()
+
+	assert.Equal(t, []string{"kept"}, order, "Cancelled queued jobs must never reach the engine")
+	job, _ := bp.GetJob(cancelled)
+	assert.Equal(t, JobCancelled, job.Status)
+}
+
+func TestBatchProcessor_SetMaxConcurrent(t *testing.T) {
+	var current, peak atomic.Int32
+	engine := newScriptedEngine(func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error) {
+		n := current.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		current.Add(-1)
+		return &ScribeResult{}, nil
+	})
+
+	bp := NewBatchProcessor(engine, 1)
+	bp.SetMaxConcurrent(3)
+	assert.Equal(t, 3, bp.MaxConcurrent())
+
+	for i := 0; i < 9; i++ {
+		bp.AddJob(ScribeOptions{InputFile: "video.mp4"})
+	}
+	bp.Wait()
+
+	assert.Equal(t, int32(3), peak.Load())
+}
+
+func TestNewBatchProcessorFromConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxConcurrentJobs = 4
+	config.MaxConcurrentDownloads = 1
+	config.MaxConcurrentFFmpeg = 3
+
+	bp := NewBatchProcessorFromConfig(NewMockScribeEngine(), config)
+	defer bp.Shutdown()
+
+	assert.Equal(t, 4, bp.MaxConcurrent())
+	assert.Equal(t, 1, bp.Limiter().Limit(ResourceDownload))
+	assert.Equal(t, 3, bp.Limiter().Limit(ResourceFFmpeg))
+}
+
+func TestBatchProcessor_ShutdownAfterWait(t *testing.T) {
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	jobID := bp.AddJob(ScribeOptions{InputFile: "video.mp4", TargetLanguage: "Spanish"})
+	bp.Wait()
+
+	assert.NotPanics(t, bp.Shutdown, "Shutdown after Wait should be a no-op")
+
+	job, ok := bp.JobSnapshot(jobID)
+	require.True(t, ok)
+	assert.Equal(t, JobCompleted, job.Status)
+}
+
+func TestParseJobPriority(t *testing.T) {
+	for name, want := range map[string]JobPriority{"": PriorityNormal, "low": PriorityLow, "High": PriorityHigh, " urgent ": PriorityUrgent} {
+		got, err := ParseJobPriority(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, err := ParseJobPriority("asap")
+	assert.Error(t, err)
+}
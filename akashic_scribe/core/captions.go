@@ -0,0 +1,308 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transcript sources recorded in ScribeResult.TranscriptSource.
+const (
+	TranscriptSourceASR          = "asr"           // Transcribed from the audio
+	TranscriptSourceCaptions     = "captions"      // Uploader-provided platform captions
+	TranscriptSourceAutoCaptions = "auto_captions" // Platform-generated automatic captions
+)
+
+// ErrNoCaptions is returned when a video has no captions in the requested language.
+var ErrNoCaptions = errors.New("no captions available")
+
+// CaptionTranscript is a timestamped transcript taken from platform captions.
+type CaptionTranscript struct {
+	Segments []SubtitleSegment // Caption cues; Text holds the caption text
+	Language string            // Language code of the captions, as reported by the platform
+	Source   string            // TranscriptSourceCaptions or TranscriptSourceAutoCaptions
+}
+
+// Text returns the caption text as a single transcript.
+func (c *CaptionTranscript) Text() string {
+	lines := make([]string, 0, len(c.Segments))
+	for _, segment := range c.Segments {
+		lines = append(lines, segment.Text)
+	}
+	return strings.Join(lines, " ")
+}
+
+// captionLanguageNames maps language names, as shown in the GUI, to ISO 639-1 codes.
+var captionLanguageNames = map[string]string{
+	"english": "en", "japanese": "ja", "chinese": "zh", "simplified chinese": "zh",
+	"traditional chinese": "zh", "russian": "ru", "german": "de", "french": "fr",
+	"spanish": "es", "italian": "it", "portuguese": "pt", "korean": "ko",
+}
+
+// captionLanguageCode converts a language setting such as "en-US", "English" or
+// "日本語 (Japanese)" into the base language code used by caption tracks.
+// It returns "" if the language is not recognised.
+func captionLanguageCode(language string) string {
+	language = strings.TrimSpace(language)
+	if open := strings.LastIndex(language, "("); open >= 0 && strings.HasSuffix(language, ")") {
+		language = language[open+1 : len(language)-1]
+	}
+	lower := strings.ToLower(strings.TrimSpace(language))
+
+	if code, ok := captionLanguageNames[lower]; ok {
+		return code
+	}
+
+	base, _, _ := strings.Cut(strings.ReplaceAll(lower, "_", "-"), "-")
+	if len(base) >= 2 && len(base) <= 3 && strings.Trim(base, "abcdefghijklmnopqrstuvwxyz") == "" {
+		return base
+	}
+	return ""
+}
+
+// vttTimingPattern matches a WebVTT cue timing line; hours are optional.
+var vttTimingPattern = regexp.MustCompile(`^((?:\d+:)?\d{2}:\d{2}\.\d{3})\s+-->\s+((?:\d+:)?\d{2}:\d{2}\.\d{3})`)
+
+// vttTagPattern matches inline cue tags such as <c>, <i> and <00:00:01.000>.
+var vttTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// ParseVTT parses WebVTT captions into subtitle segments. Inline tags are
+// stripped and the rolling duplicate lines of YouTube automatic captions are
+// removed, so each spoken line appears once.
+func ParseVTT(data []byte) ([]SubtitleSegment, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.TrimPrefix(text, "\ufeff")
+	if !strings.HasPrefix(text, "WEBVTT") {
+		return nil, errors.New("not a WebVTT file")
+	}
+
+	var segments []SubtitleSegment
+	var previousLines []string
+
+	for _, block := range strings.Split(text, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+
+		// The timing line is the first or, after a cue identifier, the second line;
+		// header, NOTE, STYLE and REGION blocks have none
+		timing := -1
+		for i := 0; i < len(lines) && i < 2; i++ {
+			if vttTimingPattern.MatchString(lines[i]) {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 {
+			continue
+		}
+
+		match := vttTimingPattern.FindStringSubmatch(lines[timing])
+		start, err := parseVTTTimestamp(match[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseVTTTimestamp(match[2])
+		if err != nil {
+			return nil, err
+		}
+
+		var cueLines []string
+		for _, line := range lines[timing+1:] {
+			line = strings.TrimSpace(html.UnescapeString(vttTagPattern.ReplaceAllString(line, "")))
+			if line != "" {
+				cueLines = append(cueLines, line)
+			}
+		}
+
+		// Automatic captions repeat the previous cue's line before adding a new one
+		newLines := cueLines
+		for len(newLines) > 0 && containsString(previousLines, newLines[0]) {
+			newLines = newLines[1:]
+		}
+		if len(cueLines) > 0 {
+			previousLines = cueLines
+		}
+		if len(newLines) == 0 {
+			continue
+		}
+
+		segments = append(segments, SubtitleSegment{
+			Index:     len(segments) + 1,
+			StartTime: start,
+			EndTime:   end,
+			Text:      strings.Join(newLines, " "),
+		})
+	}
+
+	return segments, nil
+}
+
+// parseVTTTimestamp parses "HH:MM:SS.mmm" or "MM:SS.mmm".
+func parseVTTTimestamp(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid VTT timestamp %q: %w", s, err)
+	}
+
+	var minutes int
+	for _, part := range parts[:len(parts)-1] {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid VTT timestamp %q: %w", s, err)
+		}
+		minutes = minutes*60 + n
+	}
+
+	d := time.Duration(minutes)*time.Minute + time.Duration(seconds*float64(time.Second))
+	return d.Round(time.Millisecond), nil
+}
+
+// containsString reports whether values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchPlatformCaptions downloads captions for opts.InputURL in the origin
+// language with yt-dlp. Uploaded captions are preferred; automatic captions
+// are tried next if opts.AllowAutoCaptions is set. It returns ErrNoCaptions
+// if neither is available.
+func (e *realScribeEngine) fetchPlatformCaptions(ctx context.Context, opts ScribeOptions) (*CaptionTranscript, error) {
+	language := captionLanguageCode(opts.OriginLanguage)
+	if language == "" {
+		return nil, fmt.Errorf("%w: origin language %q is not set or not recognised", ErrNoCaptions, opts.OriginLanguage)
+	}
+
+	tempDir, err := os.MkdirTemp("", "akashic_scribe_captions_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			LoggerFrom(ctx).Warn("Failed to clean up temp directory", "dir", tempDir, "error", err)
+		}
+	}()
+
+	sources := []string{TranscriptSourceCaptions}
+	if opts.AllowAutoCaptions {
+		sources = append(sources, TranscriptSourceAutoCaptions)
+	}
+
+	for _, source := range sources {
+		flag := "--write-subs"
+		if source == TranscriptSourceAutoCaptions {
+			flag = "--write-auto-subs"
+		}
+
+		path, trackLanguage, err := e.downloadCaptions(ctx, opts.InputURL, language, flag, filepath.Join(tempDir, source))
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read captions: %w", err)
+		}
+		segments, err := ParseVTT(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse captions: %w", err)
+		}
+		if len(segments) == 0 {
+			continue
+		}
+
+		return &CaptionTranscript{Segments: segments, Language: trackLanguage, Source: source}, nil
+	}
+
+	return nil, fmt.Errorf("%w for language %q", ErrNoCaptions, language)
+}
+
+// downloadCaptions runs yt-dlp with the given subtitle flag and returns the
+// path of the best matching VTT track, or "" if none was written.
+func (e *realScribeEngine) downloadCaptions(ctx context.Context, url, language, flag, outputBase string) (string, string, error) {
+	release, err := AcquireResource(ctx, ResourceDownload)
+	if err != nil {
+		return "", "", err
+	}
+	defer release()
+
+	// "en.*" also matches regional tracks such as en-US and en-GB
+	cmd := newCommand(ctx, "yt-dlp", "--no-playlist", "--skip-download", flag,
+		"--sub-langs", language+".*", "--sub-format", "vtt/best", "--convert-subs", "vtt",
+		"-o", outputBase+".%(ext)s", url)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	logCommand(ctx, cmd)
+	err = cmd.Run()
+	logToolOutput(ctx, cmd, stderr.Bytes())
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", "", fmt.Errorf("operation cancelled: %w", ctx.Err())
+		}
+		return "", "", NewScribeError(ErrorClassTransient, "captions",
+			fmt.Errorf("failed to fetch captions: %w: %s", err, strings.TrimSpace(stderr.String())))
+	}
+
+	files, err := filepath.Glob(outputBase + ".*.vtt")
+	if err != nil || len(files) == 0 {
+		return "", "", nil
+	}
+
+	// Prefer the plain language track over regional variants
+	sort.Strings(files)
+	best := files[0]
+	for _, file := range files {
+		if strings.HasSuffix(file, "."+language+".vtt") {
+			best = file
+			break
+		}
+	}
+	trackLanguage := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(best), filepath.Base(outputBase)+"."), ".vtt")
+	return best, trackLanguage, nil
+}
+
+// platformCaptions returns the captions to use in place of transcription, or
+// nil if captions are not requested, the input is not a URL or none are
+// available. Only cancellation is returned as an error; other failures fall
+// back to transcription.
+func (e *realScribeEngine) platformCaptions(ctx context.Context, opts ScribeOptions) (*CaptionTranscript, error) {
+	if !opts.UsePlatformCaptions || opts.InputFile != "" || !strings.HasPrefix(opts.InputURL, "http") {
+		return nil, nil
+	}
+
+	captions, err := e.fetchPlatformCaptions(ctx, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
+		}
+		LoggerFrom(ctx).Info("Falling back to transcription", "url", opts.InputURL, "reason", err)
+		return nil, nil
+	}
+
+	captions = clipCaptions(captions, opts)
+	if len(captions.Segments) == 0 {
+		LoggerFrom(ctx).Info("Falling back to transcription", "url", opts.InputURL, "reason", "no captions in the requested time range")
+		return nil, nil
+	}
+
+	LoggerFrom(ctx).Info("Using platform captions", "url", opts.InputURL, "source", captions.Source,
+		"language", captions.Language, "cues", len(captions.Segments))
+	return captions, nil
+}
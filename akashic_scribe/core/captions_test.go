@@ -0,0 +1,186 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleVTT = `WEBVTT
+Kind: captions
+Language: en
+
+NOTE This is a comment
+spanning two lines
+
+STYLE
+::cue { color: white; }
+
+intro
+00:00:01.000 --> 00:00:03.500 align:start position:0%
+<v Speaker>Hello &amp; welcome</v>
+to the show.
+
+00:04.000 --> 00:06.250
+<i>Today</i> we talk about Go.
+`
+
+// Automatic captions repeat the previous line and use word-level timestamps.
+const sampleAutoVTT = `WEBVTT
+Kind: captions
+Language: en
+
+00:00:00.000 --> 00:00:02.000 align:start position:0%
+hello<00:00:00.500><c> everyone</c>
+
+00:00:02.000 --> 00:00:02.010 align:start position:0%
+hello everyone
+
+00:00:02.010 --> 00:00:04.000 align:start position:0%
+hello everyone
+and<00:00:02.500><c> welcome</c>
+
+00:00:04.000 --> 00:00:04.010 align:start position:0%
+and welcome
+`
+
+func TestParseVTT(t *testing.T) {
+	segments, err := ParseVTT([]byte(sampleVTT))
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+
+	assert.Equal(t, 1, segments[0].Index)
+	assert.Equal(t, time.Second, segments[0].StartTime)
+	assert.Equal(t, 3500*time.Millisecond, segments[0].EndTime)
+	assert.Equal(t, "Hello & welcome to the show.", segments[0].Text)
+
+	assert.Equal(t, 2, segments[1].Index)
+	assert.Equal(t, 4*time.Second, segments[1].StartTime)
+	assert.Equal(t, 6250*time.Millisecond, segments[1].EndTime)
+	assert.Equal(t, "Today we talk about Go.", segments[1].Text)
+}
+
+func TestParseVTT_AutoCaptionsDeduplicated(t *testing.T) {
+	segments, err := ParseVTT([]byte(sampleAutoVTT))
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+
+	assert.Equal(t, "hello everyone", segments[0].Text)
+	assert.Equal(t, "and welcome", segments[1].Text)
+	assert.Equal(t, 2010*time.Millisecond, segments[1].StartTime)
+
+	transcript := &CaptionTranscript{Segments: segments}
+	assert.Equal(t, "hello everyone and welcome", transcript.Text())
+}
+
+func TestParseVTT_Invalid(t *testing.T) {
+	_, err := ParseVTT([]byte("1\n00:00:01,000 --> 00:00:02,000\nHello\n"))
+	assert.Error(t, err)
+}
+
+func TestParseVTTTimestamp(t *testing.T) {
+	d, err := parseVTTTimestamp("01:02:03.456")
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour+2*time.Minute+3456*time.Millisecond, d)
+
+	d, err = parseVTTTimestamp("02:03.004")
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute+3004*time.Millisecond, d)
+}
+
+func TestCaptionLanguageCode(t *testing.T) {
+	tests := map[string]string{
+		"en-US":                     "en",
+		"ja_JP":                     "ja",
+		"English":                   "en",
+		"日本語 (Japanese)":            "ja",
+		"简体中文 (Simplified Chinese)": "zh",
+		"Português (Portuguese)":    "pt",
+		"":                          "",
+		"Klingon":                   "",
+	}
+	for input, want := range tests {
+		assert.Equal(t, want, captionLanguageCode(input), input)
+	}
+}
+
+func TestSubtitleGenerator_CreateTimedSegments(t *testing.T) {
+	cues := []SubtitleSegment{
+		{StartTime: time.Second, EndTime: 3 * time.Second, Text: "Hello everyone"},
+		{StartTime: 4 * time.Second, EndTime: 8 * time.Second, Text: "and welcome to the show tonight"},
+	}
+
+	sg := NewSubtitleGenerator()
+	sg.CreateTimedSegments(cues, "Hola a todos y bienvenidos al programa de esta noche")
+	require.Len(t, sg.segments, 2)
+
+	assert.Equal(t, time.Second, sg.segments[0].StartTime)
+	assert.Equal(t, 3*time.Second, sg.segments[0].EndTime)
+	assert.Equal(t, "Hello everyone", sg.segments[0].Original)
+	assert.Equal(t, 4*time.Second, sg.segments[1].StartTime)
+	assert.Equal(t, "and welcome to the show tonight", sg.segments[1].Original)
+
+	// All translated words are kept, in order, with more on the longer cue
+	joined := sg.segments[0].Text + " " + sg.segments[1].Text
+	assert.Equal(t, "Hola a todos y bienvenidos al programa de esta noche", joined)
+	assert.Less(t, len(strings.Fields(sg.segments[0].Text)), len(strings.Fields(sg.segments[1].Text)))
+}
+
+func TestSubtitleGenerator_CreateTimedSegmentsWithoutSpaces(t *testing.T) {
+	cues := []SubtitleSegment{
+		{StartTime: 0, EndTime: time.Second, Text: "Hello"},
+		{StartTime: time.Second, EndTime: 2 * time.Second, Text: "world"},
+	}
+
+	sg := NewSubtitleGenerator()
+	sg.CreateTimedSegments(cues, "こんにちは世界")
+	require.Len(t, sg.segments, 2)
+	assert.Equal(t, "こんにちは世界", sg.segments[0].Text+sg.segments[1].Text)
+	assert.NotEmpty(t, sg.segments[0].Text)
+	assert.NotEmpty(t, sg.segments[1].Text)
+}
+
+func TestPlatformCaptions_NotRequested(t *testing.T) {
+	engine := &realScribeEngine{}
+
+	captions, err := engine.platformCaptions(context.Background(), ScribeOptions{InputURL: "https://example.com/video"})
+	require.NoError(t, err)
+	assert.Nil(t, captions)
+
+	// Local files never use platform captions
+	captions, err = engine.platformCaptions(context.Background(), ScribeOptions{
+		InputFile:           "video.mp4",
+		UsePlatformCaptions: true,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, captions)
+}
+
+func TestPlatformCaptions_UnknownLanguageFallsBack(t *testing.T) {
+	engine := &realScribeEngine{}
+
+	// An unrecognised origin language falls back to transcription without running yt-dlp
+	captions, err := engine.platformCaptions(context.Background(), ScribeOptions{
+		InputURL:            "https://example.com/video",
+		UsePlatformCaptions: true,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, captions)
+}
+
+func TestScribeOptions_StringListsCaptionsWithInput(t *testing.T) {
+	out := ScribeOptions{
+		InputURL:            "https://example.com/watch?v=talk",
+		UsePlatformCaptions: true,
+		StartTime:           time.Minute,
+	}.String()
+
+	input, rest, found := strings.Cut(out, "Language Configuration:")
+	require.True(t, found)
+	assert.Contains(t, input, "  Platform Captions: Enabled\n")
+	assert.NotContains(t, rest, "Platform Captions", "Captions are not listed under the time range")
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// pauseGate blocks stage checkpoints while paused.
+type pauseGate struct {
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{} // Closed when the gate is unpaused
+}
+
+// newPauseGate creates an open (unpaused) gate.
+func newPauseGate() *pauseGate {
+	return &pauseGate{}
+}
+
+// pause closes the gate. Checkpoints reached afterwards block until unpause.
+func (g *pauseGate) pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.paused {
+		g.paused = true
+		g.resume = make(chan struct{})
+	}
+}
+
+// unpause opens the gate and releases any blocked checkpoints.
+func (g *pauseGate) unpause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.paused {
+		g.paused = false
+		close(g.resume)
+	}
+}
+
+// isPaused reports whether the gate is currently closed.
+func (g *pauseGate) isPaused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.paused
+}
+
+// wait blocks while the gate is paused or until ctx is done.
+func (g *pauseGate) wait(ctx context.Context) error {
+	for {
+		g.mu.Lock()
+		if !g.paused {
+			g.mu.Unlock()
+			return nil
+		}
+		resume := g.resume
+		g.mu.Unlock()
+
+		select {
+		case <-resume:
+			// Re-check in case the gate was paused again
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// pauseGatesKey is the context key for the pause gates attached to a job.
+type pauseGatesKey struct{}
+
+// withPauseGates returns a context carrying the given gates in addition to any
+// gates already attached to ctx.
+func withPauseGates(ctx context.Context, gates ...*pauseGate) context.Context {
+	existing, _ := ctx.Value(pauseGatesKey{}).([]*pauseGate)
+	combined := make([]*pauseGate, 0, len(existing)+len(gates))
+	combined = append(combined, existing...)
+	combined = append(combined, gates...)
+	return context.WithValue(ctx, pauseGatesKey{}, combined)
+}
+
+// StageCheckpoint marks a stage boundary in an engine pipeline.
+// It returns an error if ctx has been cancelled, and blocks while the job
+// (or the BatchProcessor running it) is paused. Engines should call it
+// between pipeline stages so pausing never interrupts a stage midway.
+func StageCheckpoint(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("operation cancelled: %w", err)
+	}
+
+	gates, _ := ctx.Value(pauseGatesKey{}).([]*pauseGate)
+	for _, gate := range gates {
+		if err := gate.wait(ctx); err != nil {
+			return fmt.Errorf("operation cancelled: %w", err)
+		}
+	}
+
+	return nil
+}
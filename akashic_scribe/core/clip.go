@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hasTimeRange reports whether only part of the input should be processed.
+func (s ScribeOptions) hasTimeRange() bool {
+	return s.StartTime > 0 || s.EndTime > 0
+}
+
+// validateTimeRange checks StartTime and EndTime.
+func validateTimeRange(opts ScribeOptions) error {
+	if opts.StartTime < 0 || opts.EndTime < 0 {
+		return NewScribeError(ErrorClassInvalidInput, "input", fmt.Errorf("start and end time must not be negative"))
+	}
+	if opts.EndTime > 0 && opts.EndTime <= opts.StartTime {
+		return NewScribeError(ErrorClassInvalidInput, "input",
+			fmt.Errorf("end time %s must be after start time %s", opts.EndTime, opts.StartTime))
+	}
+	return nil
+}
+
+// ytDlpDownloadArgs returns the yt-dlp arguments that select the format and,
+// with a time range, download only that section. No output uses the video
+// stream, so only audio is fetched.
+func ytDlpDownloadArgs(opts ScribeOptions) []string {
+	// Fall back to the best muxed format for sites without separate audio
+	args := []string{"-f", "bestaudio/best"}
+	if opts.hasTimeRange() {
+		end := "inf"
+		if opts.EndTime > 0 {
+			end = commandSeconds(opts.EndTime)
+		}
+		args = append(args, "--download-sections", "*"+commandSeconds(opts.StartTime)+"-"+end)
+	}
+	return args
+}
+
+// ffmpegRangeArgs returns the ffmpeg input options that seek to StartTime and
+// stop at EndTime. They must precede "-i".
+func ffmpegRangeArgs(opts ScribeOptions) []string {
+	var args []string
+	if opts.StartTime > 0 {
+		args = append(args, "-ss", commandSeconds(opts.StartTime))
+	}
+	if opts.EndTime > 0 {
+		args = append(args, "-to", commandSeconds(opts.EndTime))
+	}
+	return args
+}
+
+// commandSeconds formats d as seconds with millisecond precision, e.g. "90.5".
+func commandSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Round(time.Millisecond).Seconds(), 'f', -1, 64)
+}
+
+// extractClip cuts the requested time range out of the state's media into
+// its scratch directory and returns the clip's path. Only the audio is kept.
+func (e *realScribeEngine) extractClip(ctx context.Context, state *PipelineState) (string, error) {
+	opts := state.Options
+	dir, err := state.WorkDir()
+	if err != nil {
+		return "", err
+	}
+
+	release, err := AcquireResource(ctx, ResourceFFmpeg)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	// The clip length is only known up front when the range has an end
+	var total time.Duration
+	if opts.EndTime > 0 {
+		total = opts.EndTime - opts.StartTime
+	}
+
+	clipPath := filepath.Join(dir, "clip.wav")
+	args := append(ffmpegRangeArgs(opts), "-i", state.MediaPath, "-vn", "-acodec", "pcm_s16le",
+		"-progress", "pipe:1", "-nostats", "-y", clipPath)
+	cmd := newCommand(ctx, "ffmpeg", args...)
+
+	parse := func(line string) (ProgressUpdate, bool) {
+		done, ok := parseFfmpegOutTime(line)
+		if !ok {
+			return ProgressUpdate{}, false
+		}
+		update := ProgressUpdate{Message: "Extracting time range...", MediaDone: done, MediaTotal: total}
+		if total > 0 {
+			update.StageProgress = float64(done) / float64(total)
+		}
+		return state.stageUpdate(update), true
+	}
+	if err := e.runCommandWithProgress(ctx, cmd, state.progress, parse); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("operation cancelled: %w", ctx.Err())
+		}
+		return "", fmt.Errorf("failed to extract time range: %w", err)
+	}
+	return clipPath, nil
+}
+
+// ffmpegOutTimePattern matches the "out_time=00:01:23.456789" lines of ffmpeg -progress output.
+var ffmpegOutTimePattern = regexp.MustCompile(`^out_time=(\d+):(\d+):(\d+(?:\.\d+)?)$`)
+
+// parseFfmpegOutTime parses the media time processed from a line of
+// ffmpeg -progress output.
+func parseFfmpegOutTime(line string) (time.Duration, bool) {
+	matches := ffmpegOutTimePattern.FindStringSubmatch(strings.TrimSpace(line))
+	if len(matches) < 4 {
+		return 0, false
+	}
+	hours, _ := strconv.Atoi(matches[1])
+	minutes, _ := strconv.Atoi(matches[2])
+	seconds, _ := strconv.ParseFloat(matches[3], 64)
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)), true
+}
+
+// lastLines returns the last n lines of command output.
+func lastLines(output string, n int) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// clipCaptions keeps the caption cues that overlap the requested time range.
+// Cue times stay relative to the start of the full video.
+func clipCaptions(captions *CaptionTranscript, opts ScribeOptions) *CaptionTranscript {
+	if !opts.hasTimeRange() {
+		return captions
+	}
+
+	clipped := &CaptionTranscript{Language: captions.Language, Source: captions.Source}
+	for _, cue := range captions.Segments {
+		if cue.EndTime <= opts.StartTime || (opts.EndTime > 0 && cue.StartTime >= opts.EndTime) {
+			continue
+		}
+		cue.Index = len(clipped.Segments) + 1
+		clipped.Segments = append(clipped.Segments, cue)
+	}
+	return clipped
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTimeRange(t *testing.T) {
+	assert.NoError(t, validateTimeRange(ScribeOptions{}))
+	assert.NoError(t, validateTimeRange(ScribeOptions{StartTime: time.Minute}))
+	assert.NoError(t, validateTimeRange(ScribeOptions{StartTime: time.Minute, EndTime: 2 * time.Minute}))
+
+	err := validateTimeRange(ScribeOptions{StartTime: 2 * time.Minute, EndTime: time.Minute})
+	require.Error(t, err)
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+
+	assert.Error(t, validateTimeRange(ScribeOptions{StartTime: -time.Second}))
+}
+
+func TestYtDlpDownloadArgs(t *testing.T) {
+	assert.Equal(t, []string{"-f", "bestaudio/best"}, ytDlpDownloadArgs(ScribeOptions{}))
+
+	args := ytDlpDownloadArgs(ScribeOptions{StartTime: 90 * time.Second, EndTime: 150500 * time.Millisecond})
+	assert.Equal(t, []string{"-f", "bestaudio/best", "--download-sections", "*90-150.5"}, args)
+
+	args = ytDlpDownloadArgs(ScribeOptions{StartTime: 10 * time.Second})
+	assert.Contains(t, args, "*10-inf")
+
+	args = ytDlpDownloadArgs(ScribeOptions{EndTime: 30 * time.Second})
+	assert.Contains(t, args, "*0-30")
+}
+
+func TestFfmpegRangeArgs(t *testing.T) {
+	assert.Empty(t, ffmpegRangeArgs(ScribeOptions{}))
+	assert.Equal(t, []string{"-ss", "1.25", "-to", "60"},
+		ffmpegRangeArgs(ScribeOptions{StartTime: 1250 * time.Millisecond, EndTime: time.Minute}))
+	assert.Equal(t, []string{"-to", "60"}, ffmpegRangeArgs(ScribeOptions{EndTime: time.Minute}))
+}
+
+func TestClipCaptions(t *testing.T) {
+	captions := &CaptionTranscript{
+		Language: "en",
+		Source:   TranscriptSourceCaptions,
+		Segments: []SubtitleSegment{
+			{Index: 1, StartTime: 0, EndTime: 5 * time.Second, Text: "before"},
+			{Index: 2, StartTime: 8 * time.Second, EndTime: 12 * time.Second, Text: "overlapping start"},
+			{Index: 3, StartTime: 12 * time.Second, EndTime: 15 * time.Second, Text: "inside"},
+			{Index: 4, StartTime: 20 * time.Second, EndTime: 25 * time.Second, Text: "after"},
+		},
+	}
+
+	assert.Same(t, captions, clipCaptions(captions, ScribeOptions{}))
+
+	clipped := clipCaptions(captions, ScribeOptions{StartTime: 10 * time.Second, EndTime: 20 * time.Second})
+	require.Len(t, clipped.Segments, 2)
+	assert.Equal(t, "overlapping start", clipped.Segments[0].Text)
+	assert.Equal(t, 1, clipped.Segments[0].Index)
+	assert.Equal(t, 8*time.Second, clipped.Segments[0].StartTime, "cue times stay absolute")
+	assert.Equal(t, "inside", clipped.Segments[1].Text)
+	assert.Equal(t, 2, clipped.Segments[1].Index)
+	assert.Equal(t, "en", clipped.Language)
+	assert.Len(t, captions.Segments, 4, "original captions are unchanged")
+}
+
+func TestSubtitleGenerator_Offset(t *testing.T) {
+	sg := NewSubtitleGenerator()
+	sg.AddSegment(0, 3*time.Second, "Hello", "")
+	sg.AddSegment(3*time.Second, 6*time.Second, "World", "")
+
+	sg.Offset(90 * time.Second)
+
+	assert.Equal(t, 90*time.Second, sg.segments[0].StartTime)
+	assert.Equal(t, 96*time.Second, sg.segments[1].EndTime)
+	assert.Contains(t, sg.GenerateSRT(false, "bottom"), "00:01:30,000 --> 00:01:33,000")
+}
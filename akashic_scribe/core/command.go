@@ -0,0 +1,25 @@
+package core
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// commandWaitDelay bounds how long a cancelled command may keep its output
+// pipes open, e.g. through a child that ignored the kill, before Wait gives up.
+const commandWaitDelay = 5 * time.Second
+
+// newCommand returns a command for an external tool bound to ctx. The tool
+// runs in its own process group, and cancelling ctx kills the whole group, so
+// helpers it started (ffmpeg under yt-dlp, children of hook scripts) do not
+// outlive the job.
+func newCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+	cmd.WaitDelay = commandWaitDelay
+	return cmd
+}
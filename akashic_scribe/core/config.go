@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // Config represents the application configuration.
@@ -28,6 +30,33 @@ type Config struct {
 	MaxConcurrentJobs int  `json:"max_concurrent_jobs"`
 	EnableCaching     bool `json:"enable_caching"`
 
+	// Resource limits (0 = unlimited)
+	MaxConcurrentDownloads    int            `json:"max_concurrent_downloads"`
+	MaxConcurrentFFmpeg       int            `json:"max_concurrent_ffmpeg"`
+	ProviderRequestsPerMinute map[string]int `json:"provider_requests_per_minute,omitempty"` // Deprecated: use Providers[name].RequestsPerMinute
+
+	// Webhook settings
+	WebhookURLs   []string `json:"webhook_urls,omitempty"`   // Notified when any job finishes
+	WebhookSecret string   `json:"webhook_secret,omitempty"` // HMAC-SHA256 signing key
+
+	// Watch folder settings
+	WatchFolders       []WatchFolder `json:"watch_folders,omitempty"`
+	WatchStableSeconds int           `json:"watch_stable_seconds,omitempty"` // Seconds a file's size must stay unchanged (0 = 5)
+
+	// Command hooks run at pipeline events, e.g. to publish results
+	CommandHooks []CommandHook `json:"command_hooks,omitempty"`
+
+	// Cost accounting
+	Prices      PriceTable `json:"prices,omitempty"`       // Overrides DefaultPriceTable, keyed by provider name
+	BatchBudget float64    `json:"batch_budget,omitempty"` // Most a batch may spend in USD (0 = unlimited)
+
+	// HTTP-based providers, keyed by provider name; override DefaultProviderConfigs
+	Providers map[string]ProviderConfig `json:"providers,omitempty"`
+	Fallbacks map[string][]string       `json:"fallbacks,omitempty"` // Ordered provider names, keyed by capability; override DefaultProviderChains
+
+	// Logging settings
+	LogLevel string `json:"log_level,omitempty"` // debug, info, warn or error (empty = info)
+
 	// Output settings
 	DefaultOutputDir string `json:"default_output_dir"`
 }
@@ -53,6 +82,10 @@ func DefaultConfig() *Config {
 		MaxConcurrentJobs: 2,
 		EnableCaching:     true,
 
+		// Resource limit defaults
+		MaxConcurrentDownloads: 2,
+		MaxConcurrentFFmpeg:    2,
+
 		// Output defaults
 		DefaultOutputDir: "",
 	}
@@ -121,9 +154,145 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max concurrent jobs must be between 1 and 10, got %d", c.MaxConcurrentJobs)
 	}
 
+	// Validate resource limits
+	if c.MaxConcurrentDownloads < 0 {
+		return fmt.Errorf("max concurrent downloads cannot be negative, got %d", c.MaxConcurrentDownloads)
+	}
+	if c.MaxConcurrentFFmpeg < 0 {
+		return fmt.Errorf("max concurrent ffmpeg processes cannot be negative, got %d", c.MaxConcurrentFFmpeg)
+	}
+	for provider, rpm := range c.ProviderRequestsPerMinute {
+		if rpm < 0 {
+			return fmt.Errorf("requests per minute for provider %s cannot be negative, got %d", provider, rpm)
+		}
+	}
+
+	// Validate webhook URLs
+	for _, target := range c.WebhookURLs {
+		if err := ValidateWebhookURL(target); err != nil {
+			return err
+		}
+	}
+
+	// Validate watch folders
+	for i, folder := range c.WatchFolders {
+		if folder.Path == "" {
+			return fmt.Errorf("watch folder %d has no path", i+1)
+		}
+	}
+	if c.WatchStableSeconds < 0 {
+		return fmt.Errorf("watch stable seconds cannot be negative, got %d", c.WatchStableSeconds)
+	}
+
+	// Validate command hooks
+	for i, hook := range c.CommandHooks {
+		if err := hook.Validate(); err != nil {
+			return fmt.Errorf("command hook %d: %w", i+1, err)
+		}
+	}
+
+	// Validate cost accounting
+	for provider, price := range c.Prices {
+		if err := price.validate(); err != nil {
+			return fmt.Errorf("price of %s: %w", provider, err)
+		}
+	}
+	if c.BatchBudget < 0 {
+		return fmt.Errorf("batch budget cannot be negative, got %.2f", c.BatchBudget)
+	}
+
+	// Validate provider settings
+	for provider, providerConfig := range c.Providers {
+		if err := providerConfig.Validate(); err != nil {
+			return fmt.Errorf("provider %s: %w", provider, err)
+		}
+	}
+	if err := c.validateFallbacks(); err != nil {
+		return err
+	}
+
+	// Validate log level
+	if _, err := ParseLogLevel(c.LogLevel); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// validateFallbacks checks that every fallback chain is for a known
+// capability, and that every provider of the chains in effect, including the
+// defaults that Fallbacks does not override, offers its capability.
+func (c *Config) validateFallbacks() error {
+	for capability, chain := range c.Fallbacks {
+		if capability != CapabilityTranslation && capability != CapabilityTTS {
+			return fmt.Errorf("fallbacks: unknown capability %q", capability)
+		}
+		if len(chain) == 0 {
+			return fmt.Errorf("fallbacks: %s chain is empty", capability)
+		}
+	}
+
+	providers := c.NewProviders()
+	for capability, chain := range providers.chains {
+		for _, provider := range chain {
+			config, ok := providers.configs[provider]
+			if !ok {
+				return fmt.Errorf("fallbacks: %s chain names unknown provider %q", capability, provider)
+			}
+			if !config.Supports(capability) {
+				return fmt.Errorf("fallbacks: provider %s (type %q) does not offer %s", provider, config.Type, capability)
+			}
+		}
+	}
+	return nil
+}
+
+// ResourceLimits returns the per-resource limits described by the configuration.
+// Provider request rates are process-wide; see ProviderLimits.
+func (c *Config) ResourceLimits() ResourceLimits {
+	return ResourceLimits{
+		MaxConcurrentDownloads: c.MaxConcurrentDownloads,
+		MaxConcurrentFFmpeg:    c.MaxConcurrentFFmpeg,
+	}
+}
+
+// ProviderLimits returns the process-wide limits of each configured provider,
+// keyed by provider name. Rates from the deprecated ProviderRequestsPerMinute
+// apply to providers whose config sets none.
+func (c *Config) ProviderLimits() map[string]ProviderLimits {
+	limits := make(map[string]ProviderLimits)
+	for provider, rpm := range c.ProviderRequestsPerMinute {
+		limits[provider] = ProviderLimits{RequestsPerMinute: rpm}
+	}
+	for provider, providerConfig := range c.Providers {
+		l := providerConfig.Limits()
+		if l.RequestsPerMinute == 0 {
+			l.RequestsPerMinute = c.ProviderRequestsPerMinute[provider]
+		}
+		limits[provider] = l
+	}
+	return limits
+}
+
+// PriceTable returns DefaultPriceTable with the configured prices applied.
+func (c *Config) PriceTable() PriceTable {
+	return DefaultPriceTable().Merge(c.Prices)
+}
+
+// SlogLevel returns the configured log level; invalid levels select info.
+func (c *Config) SlogLevel() slog.Level {
+	level, _ := ParseLogLevel(c.LogLevel)
+	return level
+}
+
+// WatchStableDuration returns how long watched files must stay unchanged before processing.
+func (c *Config) WatchStableDuration() time.Duration {
+	if c.WatchStableSeconds <= 0 {
+		return DefaultWatchStableDuration
+	}
+	return time.Duration(c.WatchStableSeconds) * time.Second
+}
+
 // LoadConfig loads configuration from a JSON file.
 // If the file doesn't exist, it returns the default configuration.
 func LoadConfig(path string) (*Config, error) {
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,6 +28,8 @@ func TestDefaultConfig(t *testing.T) {
 	assert.True(config.DefaultBilingualSubtitles)
 	assert.Equal(2, config.MaxConcurrentJobs)
 	assert.True(config.EnableCaching)
+	assert.Equal(2, config.MaxConcurrentDownloads)
+	assert.Equal(2, config.MaxConcurrentFFmpeg)
 
 	// Validate default config
 	err := config.Validate()
@@ -141,6 +144,22 @@ func TestConfigValidation(t *testing.T) {
 			shouldErr: true,
 			errMsg:    "max concurrent jobs must be between",
 		},
+		{
+			name: "Negative download limit",
+			modify: func(c *Config) {
+				c.MaxConcurrentDownloads = -1
+			},
+			shouldErr: true,
+			errMsg:    "max concurrent downloads cannot be negative",
+		},
+		{
+			name: "Negative provider rate",
+			modify: func(c *Config) {
+				c.ProviderRequestsPerMinute = map[string]int{"openai": -5}
+			},
+			shouldErr: true,
+			errMsg:    "requests per minute for provider openai cannot be negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -346,3 +365,34 @@ func TestConfigEdgeCases(t *testing.T) {
 	config.DefaultChannels = 2
 	assert.NoError(config.Validate())
 }
+
+func TestConfig_WebhookValidation(t *testing.T) {
+	config := DefaultConfig()
+	config.WebhookURLs = []string{"https://hooks.example.com/scribe"}
+	config.WebhookSecret = "secret"
+	assert.NoError(t, config.Validate())
+
+	webhooks := config.WebhookConfig()
+	assert.Equal(t, config.WebhookURLs, webhooks.URLs)
+	assert.Equal(t, "secret", webhooks.Secret)
+
+	config.WebhookURLs = append(config.WebhookURLs, "not a url")
+	assert.Error(t, config.Validate())
+}
+
+func TestConfig_WatchSettings(t *testing.T) {
+	config := DefaultConfig()
+	assert.Equal(t, DefaultWatchStableDuration, config.WatchStableDuration())
+
+	config.WatchStableSeconds = 10
+	config.WatchFolders = []WatchFolder{{Path: "/srv/inbox", Template: "Podcast"}}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, 10*time.Second, config.WatchStableDuration())
+
+	config.WatchFolders = append(config.WatchFolders, WatchFolder{Template: "Podcast"})
+	assert.Error(t, config.Validate(), "Watch folders need a path")
+
+	config.WatchFolders = nil
+	config.WatchStableSeconds = -1
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,511 @@
+package core
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Credential file names, relative to the config directory.
+const (
+	CredentialsFileName    = "credentials.enc" // Provider API keys, encrypted with AES-256-GCM
+	CredentialsKeyFileName = "credentials.key" // Random key that encrypts the credentials file
+)
+
+// KeyringService is the service name under which API keys are stored in the OS keyring.
+const KeyringService = "akashic-scribe"
+
+// Redacted replaces secrets in logs and descriptions.
+const Redacted = "[REDACTED]"
+
+// Credential store errors.
+var (
+	ErrCredentialNotFound      = errors.New("credential not found")
+	ErrCredentialStoreReadOnly = errors.New("credential store is read-only")
+)
+
+// Secret is an API key or other credential. It prints, logs and marshals as
+// Redacted; Reveal returns the actual value.
+type Secret string
+
+// Reveal returns the secret's value.
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String returns Redacted, or "" for an empty secret.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// GoString keeps the secret out of %#v output.
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// LogValue keeps the secret out of slog output.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// MarshalJSON keeps the secret out of JSON output.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// CredentialProviders returns the names of the providers that need an API
+// key, in order: all but builtin providers and those configured with
+// no_api_key.
+func (p *Providers) CredentialProviders() []string {
+	var providers []string
+	for name, config := range p.configs {
+		if config.Type != ProviderTypeBuiltin && !config.NoAPIKey {
+			providers = append(providers, name)
+		}
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// CredentialStore holds API keys, keyed by provider name.
+type CredentialStore interface {
+	// Name describes the store, e.g. in error messages.
+	Name() string
+	// Get returns the key of provider, or ErrCredentialNotFound.
+	Get(provider string) (Secret, error)
+	// Set stores the key of provider.
+	Set(provider string, key Secret) error
+	// Delete removes the key of provider. Deleting a missing key is not an error.
+	Delete(provider string) error
+}
+
+// CredentialEnvVar returns the environment variable holding the key of
+// provider, e.g. OPENAI_API_KEY. Characters that cannot appear in a variable
+// name are replaced by underscores, so "my-llm" reads MY_LLM_API_KEY.
+func CredentialEnvVar(provider string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.ToUpper(provider))
+	return name + "_API_KEY"
+}
+
+// EnvCredentialStore reads API keys from environment variables named by
+// CredentialEnvVar. It is read-only.
+type EnvCredentialStore struct{}
+
+// Name implements CredentialStore.
+func (EnvCredentialStore) Name() string {
+	return "environment"
+}
+
+// Get implements CredentialStore.
+func (EnvCredentialStore) Get(provider string) (Secret, error) {
+	if key := os.Getenv(CredentialEnvVar(provider)); key != "" {
+		return Secret(key), nil
+	}
+	return "", ErrCredentialNotFound
+}
+
+// Set implements CredentialStore.
+func (EnvCredentialStore) Set(string, Secret) error {
+	return ErrCredentialStoreReadOnly
+}
+
+// Delete implements CredentialStore.
+func (EnvCredentialStore) Delete(string) error {
+	return ErrCredentialStoreReadOnly
+}
+
+// FileCredentialStore keeps API keys in CredentialsFileName under a
+// directory, encrypted with a random key kept next to it in
+// CredentialsKeyFileName. Both files are only readable by the user. The
+// encryption keeps keys out of backups and synced copies of the credentials
+// file; it does not protect them from someone who can read the key file.
+type FileCredentialStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileCredentialStore returns a store that keeps its files in dir.
+func NewFileCredentialStore(dir string) *FileCredentialStore {
+	return &FileCredentialStore{dir: dir}
+}
+
+// Name implements CredentialStore.
+func (s *FileCredentialStore) Name() string {
+	return filepath.Join(s.dir, CredentialsFileName)
+}
+
+// Get implements CredentialStore.
+func (s *FileCredentialStore) Get(provider string) (Secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	key, ok := keys[provider]
+	if !ok || key == "" {
+		return "", ErrCredentialNotFound
+	}
+	return Secret(key), nil
+}
+
+// Set implements CredentialStore.
+func (s *FileCredentialStore) Set(provider string, key Secret) error {
+	if key == "" {
+		return s.Delete(provider)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.load()
+	if err != nil {
+		return err
+	}
+	keys[provider] = key.Reveal()
+	return s.save(keys)
+}
+
+// Delete implements CredentialStore.
+func (s *FileCredentialStore) Delete(provider string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := keys[provider]; !ok {
+		return nil
+	}
+	delete(keys, provider)
+	return s.save(keys)
+}
+
+// load decrypts the credentials file. A missing file holds no keys.
+func (s *FileCredentialStore) load() (map[string]string, error) {
+	keys := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(s.dir, CredentialsFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	gcm, err := s.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("credentials file is corrupt")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials: %w", err)
+	}
+	if err := json.Unmarshal(plain, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials: %w", err)
+	}
+	return keys, nil
+}
+
+// save encrypts keys and replaces the credentials file.
+func (s *FileCredentialStore) save(keys map[string]string) error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	gcm, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	path := filepath.Join(s.dir, CredentialsFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, gcm.Seal(nonce, nonce, plain, nil), 0o600); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	return nil
+}
+
+// cipher returns the AES-GCM cipher for the credentials file, creating the
+// key file first if create is set.
+func (s *FileCredentialStore) cipher(create bool) (cipher.AEAD, error) {
+	path := filepath.Join(s.dir, CredentialsKeyFileName)
+	key, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && create {
+		key = make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, fmt.Errorf("failed to generate credentials key: %w", err)
+		}
+		err = os.WriteFile(path, key, 0o600)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, errors.New("credentials key file is corrupt")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Keyring is the interface of an OS keyring, such as the macOS Keychain,
+// Windows Credential Manager or the Secret Service on Linux. It matches the
+// API of common Go keyring libraries; adapters return ErrCredentialNotFound
+// for missing entries.
+type Keyring interface {
+	Get(service, user string) (string, error)
+	Set(service, user, password string) error
+	Delete(service, user string) error
+}
+
+// KeyringCredentialStore keeps API keys in an OS keyring under
+// KeyringService, with the provider name as the user. When the keyring is nil
+// or fails, for example on a headless Linux system without a Secret Service,
+// the fallback store is used instead.
+type KeyringCredentialStore struct {
+	ring     Keyring
+	fallback CredentialStore
+}
+
+// NewKeyringCredentialStore returns a store backed by ring, or by fallback
+// when ring is nil or unavailable.
+func NewKeyringCredentialStore(ring Keyring, fallback CredentialStore) *KeyringCredentialStore {
+	return &KeyringCredentialStore{ring: ring, fallback: fallback}
+}
+
+// Name implements CredentialStore.
+func (s *KeyringCredentialStore) Name() string {
+	if s.ring == nil {
+		return s.fallback.Name()
+	}
+	return "keyring"
+}
+
+// Get implements CredentialStore. Keys missing from the keyring are looked
+// up in the fallback store, so keys saved while the keyring was unavailable
+// are still found.
+func (s *KeyringCredentialStore) Get(provider string) (Secret, error) {
+	if s.ring != nil {
+		key, err := s.ring.Get(KeyringService, provider)
+		if err == nil && key != "" {
+			return Secret(key), nil
+		}
+		if err != nil && !errors.Is(err, ErrCredentialNotFound) {
+			componentLogger("credentials").Warn("Keyring unavailable; using fallback store", "error", err)
+		}
+	}
+	return s.fallback.Get(provider)
+}
+
+// Set implements CredentialStore.
+func (s *KeyringCredentialStore) Set(provider string, key Secret) error {
+	if s.ring != nil {
+		err := s.ring.Set(KeyringService, provider, key.Reveal())
+		if err == nil {
+			// Don't leave an older copy behind in the fallback store
+			if err := s.fallback.Delete(provider); err != nil && !errors.Is(err, ErrCredentialStoreReadOnly) {
+				return err
+			}
+			return nil
+		}
+		componentLogger("credentials").Warn("Keyring unavailable; using fallback store", "error", err)
+	}
+	return s.fallback.Set(provider, key)
+}
+
+// Delete implements CredentialStore. The key is removed from both the
+// keyring and the fallback store.
+func (s *KeyringCredentialStore) Delete(provider string) error {
+	if s.ring != nil {
+		if err := s.ring.Delete(KeyringService, provider); err != nil && !errors.Is(err, ErrCredentialNotFound) {
+			componentLogger("credentials").Warn("Failed to delete key from keyring", "provider", provider, "error", err)
+		}
+	}
+	return s.fallback.Delete(provider)
+}
+
+// CredentialChain looks keys up in several stores in order. Keys are saved
+// to and deleted from the first writable store.
+type CredentialChain []CredentialStore
+
+// NewCredentialStore returns the store used by the CLI and GUI: environment
+// variables take precedence over keys saved in ring, which falls back to an
+// encrypted file in configDir. ring may be nil.
+func NewCredentialStore(configDir string, ring Keyring) CredentialChain {
+	return CredentialChain{
+		EnvCredentialStore{},
+		NewKeyringCredentialStore(ring, NewFileCredentialStore(configDir)),
+	}
+}
+
+// Name implements CredentialStore.
+func (c CredentialChain) Name() string {
+	names := make([]string, len(c))
+	for i, store := range c {
+		names[i] = store.Name()
+	}
+	return strings.Join(names, ", ")
+}
+
+// Get implements CredentialStore.
+func (c CredentialChain) Get(provider string) (Secret, error) {
+	key, _, err := c.Lookup(provider)
+	return key, err
+}
+
+// Lookup returns the key of provider and the store it was found in.
+func (c CredentialChain) Lookup(provider string) (Secret, CredentialStore, error) {
+	for _, store := range c {
+		key, err := store.Get(provider)
+		if err == nil {
+			return key, store, nil
+		}
+		if !errors.Is(err, ErrCredentialNotFound) {
+			return "", nil, fmt.Errorf("%s: %w", store.Name(), err)
+		}
+	}
+	return "", nil, ErrCredentialNotFound
+}
+
+// Set implements CredentialStore.
+func (c CredentialChain) Set(provider string, key Secret) error {
+	for _, store := range c {
+		err := store.Set(provider, key)
+		if !errors.Is(err, ErrCredentialStoreReadOnly) {
+			return err
+		}
+	}
+	return ErrCredentialStoreReadOnly
+}
+
+// Delete implements CredentialStore.
+func (c CredentialChain) Delete(provider string) error {
+	for _, store := range c {
+		err := store.Delete(provider)
+		if !errors.Is(err, ErrCredentialStoreReadOnly) {
+			return err
+		}
+	}
+	return ErrCredentialStoreReadOnly
+}
+
+// credentialsKey is the context key for the credential store of a job.
+type credentialsKey struct{}
+
+// WithCredentials returns a context under which engines read API keys from
+// store. BatchProcessor attaches its store this way.
+func WithCredentials(ctx context.Context, store CredentialStore) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, store)
+}
+
+// CredentialsFrom returns the credential store attached to ctx, or an
+// EnvCredentialStore.
+func CredentialsFrom(ctx context.Context) CredentialStore {
+	if store, ok := ctx.Value(credentialsKey{}).(CredentialStore); ok {
+		return store
+	}
+	return EnvCredentialStore{}
+}
+
+// SetCredentials sets the store that jobs started afterwards read API keys
+// from. nil selects environment variables.
+func (bp *BatchProcessor) SetCredentials(store CredentialStore) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	bp.credentials = store
+}
+
+// apiKey returns the API key for provider: the job's own key from
+// ScribeOptions.APIKeys, or the one in the context's credential store.
+func apiKey(ctx context.Context, opts ScribeOptions, provider string) (Secret, error) {
+	if key := opts.APIKeys[provider]; key != "" {
+		return key, nil
+	}
+
+	store := CredentialsFrom(ctx)
+	key, err := store.Get(provider)
+	if errors.Is(err, ErrCredentialNotFound) {
+		return "", NewScribeError(ErrorClassInvalidInput, "credentials",
+			fmt.Errorf("no API key for %s: set %s or save a key in Settings", provider, CredentialEnvVar(provider)))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key for %s: %w", provider, err)
+	}
+	return key, nil
+}
+
+// providerKey returns the API key to send to provider: none if its config
+// sets NoAPIKey, otherwise the key found by apiKey.
+func providerKey(ctx context.Context, opts ScribeOptions, provider string) (Secret, error) {
+	if ProvidersFrom(ctx).Config(provider).NoAPIKey {
+		return "", nil
+	}
+	return apiKey(ctx, opts, provider)
+}
+
+// sortedProviders returns the provider names of keys in order.
+func sortedProviders(keys map[string]Secret) []string {
+	providers := make([]string, 0, len(keys))
+	for provider := range keys {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// sensitiveLogKeys are attribute key suffixes whose values are redacted from logs.
+var sensitiveLogKeys = []string{"api_key", "apikey", "authorization", "password", "secret"}
+
+// redactAttr is a slog ReplaceAttr function that redacts attributes whose
+// key names a credential.
+func redactAttr(_ []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	for _, suffix := range sensitiveLogKeys {
+		if strings.HasSuffix(key, suffix) {
+			return slog.String(attr.Key, Redacted)
+		}
+	}
+	return attr
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKeyring is an in-memory Keyring that fails every call when broken is set.
+type fakeKeyring struct {
+	entries map[string]string
+	broken  bool
+}
+
+func (k *fakeKeyring) Get(service, user string) (string, error) {
+	if k.broken {
+		return "", errors.New("no keyring daemon")
+	}
+	if key, ok := k.entries[service+"/"+user]; ok {
+		return key, nil
+	}
+	return "", ErrCredentialNotFound
+}
+
+func (k *fakeKeyring) Set(service, user, password string) error {
+	if k.broken {
+		return errors.New("no keyring daemon")
+	}
+	k.entries[service+"/"+user] = password
+	return nil
+}
+
+func (k *fakeKeyring) Delete(service, user string) error {
+	if k.broken {
+		return errors.New("no keyring daemon")
+	}
+	delete(k.entries, service+"/"+user)
+	return nil
+}
+
+func TestFileCredentialStore_EncryptsKeys(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileCredentialStore(dir)
+
+	_, err := store.Get(ProviderOpenAI)
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+
+	require.NoError(t, store.Set(ProviderOpenAI, "sk-test-123"))
+	require.NoError(t, store.Set("elevenlabs", "el-456"))
+
+	data, err := os.ReadFile(filepath.Join(dir, CredentialsFileName))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "sk-test-123")
+	if runtime.GOOS != "windows" {
+		for _, name := range []string{CredentialsFileName, CredentialsKeyFileName} {
+			info, err := os.Stat(filepath.Join(dir, name))
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), name)
+		}
+	}
+
+	// A new store reads the same file
+	key, err := NewFileCredentialStore(dir).Get(ProviderOpenAI)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-test-123", key.Reveal())
+
+	require.NoError(t, store.Delete(ProviderOpenAI))
+	require.NoError(t, store.Delete(ProviderOpenAI), "Deleting a missing key is not an error")
+	_, err = store.Get(ProviderOpenAI)
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+	key, err = store.Get("elevenlabs")
+	require.NoError(t, err)
+	assert.Equal(t, Secret("el-456"), key)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, CredentialsKeyFileName), bytes.Repeat([]byte{1}, 32), 0o600))
+	_, err = store.Get("elevenlabs")
+	assert.Error(t, err, "A different key cannot decrypt the file")
+}
+
+func TestKeyringCredentialStore_FallsBackToFile(t *testing.T) {
+	dir := t.TempDir()
+	ring := &fakeKeyring{entries: map[string]string{}}
+	store := NewKeyringCredentialStore(ring, NewFileCredentialStore(dir))
+
+	require.NoError(t, store.Set(ProviderOpenAI, "from-keyring"))
+	assert.Equal(t, "from-keyring", ring.entries[KeyringService+"/"+ProviderOpenAI])
+	assert.NoFileExists(t, filepath.Join(dir, CredentialsFileName))
+
+	ring.broken = true
+	require.NoError(t, store.Set(ProviderOpenAI, "from-file"))
+	key, err := store.Get(ProviderOpenAI)
+	require.NoError(t, err)
+	assert.Equal(t, Secret("from-file"), key)
+
+	ring.broken = false
+	require.NoError(t, store.Delete(ProviderOpenAI))
+	_, err = store.Get(ProviderOpenAI)
+	assert.ErrorIs(t, err, ErrCredentialNotFound, "Delete removes the key from both stores")
+}
+
+func TestCredentialEnvVar(t *testing.T) {
+	assert.Equal(t, "OPENAI_API_KEY", CredentialEnvVar(ProviderOpenAI))
+	assert.Equal(t, "MY_LLM_API_KEY", CredentialEnvVar("my-llm"))
+	assert.Equal(t, "LOCAL_LLM_V2_API_KEY", CredentialEnvVar("local.llm v2"))
+	assert.Equal(t, "_BER_API_KEY", CredentialEnvVar("über"), "Non-ASCII letters are replaced")
+}
+
+func TestCredentialChain_EnvironmentTakesPrecedence(t *testing.T) {
+	t.Setenv(CredentialEnvVar(ProviderOpenAI), "")
+	store := NewCredentialStore(t.TempDir(), nil)
+
+	require.NoError(t, store.Set(ProviderOpenAI, "saved"), "Set skips the read-only environment")
+	key, source, err := store.Lookup(ProviderOpenAI)
+	require.NoError(t, err)
+	assert.Equal(t, Secret("saved"), key)
+	assert.IsType(t, &KeyringCredentialStore{}, source)
+
+	t.Setenv("OPENAI_API_KEY", "from-env")
+	key, source, err = store.Lookup(ProviderOpenAI)
+	require.NoError(t, err)
+	assert.Equal(t, Secret("from-env"), key)
+	assert.Equal(t, EnvCredentialStore{}, source)
+
+	assert.ErrorIs(t, CredentialChain{EnvCredentialStore{}}.Set(ProviderOpenAI, "x"), ErrCredentialStoreReadOnly)
+}
+
+func TestSecret_IsRedacted(t *testing.T) {
+	key := Secret("sk-live-secret")
+	opts := ScribeOptions{InputFile: "a.mp4", APIKeys: map[string]Secret{ProviderOpenAI: key}}
+
+	assert.NotContains(t, opts.String(), "sk-live-secret")
+	assert.Contains(t, opts.String(), "openai: "+Redacted)
+	assert.NotContains(t, fmt.Sprintf("%v %+v %#v %s", key, opts, opts, key), "sk-live-secret")
+
+	data, err := json.Marshal(struct{ Key Secret }{key})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "sk-live-secret")
+	data, err = json.Marshal(opts)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "APIKeys", "Per-job keys are never serialized")
+
+	var logs bytes.Buffer
+	logger := NewLogger(&logs, slog.LevelDebug)
+	logger.Info("Calling provider", "key", key, "api_key", "sk-plain-string", "authorization", "Bearer sk-header")
+	assert.NotContains(t, logs.String(), "sk-")
+	assert.Contains(t, logs.String(), "api_key="+Redacted)
+}
+
+func TestAPIKey_Lookup(t *testing.T) {
+	t.Setenv(CredentialEnvVar(ProviderOpenAI), "")
+
+	_, err := apiKey(context.Background(), ScribeOptions{}, ProviderOpenAI)
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+	assert.Contains(t, err.Error(), "OPENAI_API_KEY")
+
+	store := NewFileCredentialStore(t.TempDir())
+	require.NoError(t, store.Set(ProviderOpenAI, "stored"))
+	ctx := WithCredentials(context.Background(), store)
+	key, err := apiKey(ctx, ScribeOptions{}, ProviderOpenAI)
+	require.NoError(t, err)
+	assert.Equal(t, Secret("stored"), key)
+
+	key, err = apiKey(ctx, ScribeOptions{APIKeys: map[string]Secret{ProviderOpenAI: "per-job"}}, ProviderOpenAI)
+	require.NoError(t, err)
+	assert.Equal(t, Secret("per-job"), key, "The job's own key wins")
+}
+
+func TestProviders_CredentialProviders(t *testing.T) {
+	assert.Equal(t, []string{ProviderOpenAI}, DefaultConfig().NewProviders().CredentialProviders())
+
+	config := DefaultConfig()
+	config.Providers = map[string]ProviderConfig{
+		"deepl-proxy": {Type: ProviderTypeLibreTranslate, BaseURL: "https://translate.example.com"},
+		"local-llm":   {Type: ProviderTypeOpenAI, BaseURL: "http://localhost:11434/v1", NoAPIKey: true},
+	}
+	require.NoError(t, config.Validate())
+	assert.Equal(t, []string{"deepl-proxy", ProviderOpenAI}, config.NewProviders().CredentialProviders(),
+		"Builtin providers and providers without keys are not listed")
+}
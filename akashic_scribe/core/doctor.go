@@ -0,0 +1,408 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CheckStatus is the outcome of a diagnostic check.
+type CheckStatus string
+
+// Diagnostic check statuses.
+const (
+	CheckOK      CheckStatus = "ok"      // Nothing to do
+	CheckWarning CheckStatus = "warning" // Some features will not work, or may stop working
+	CheckFailed  CheckStatus = "failed"  // Jobs will fail until this is fixed
+)
+
+// Requirements checked by Diagnose.
+const (
+	MinFFmpegMajorVersion = 4                    // Oldest ffmpeg and ffprobe release supported
+	MaxYtDlpAge           = 180 * 24 * time.Hour // yt-dlp releases older than this often fail to download
+	diagnoseProbeTimeout  = 10 * time.Second     // Limit on each tool run and provider request
+)
+
+// Check is the result of one diagnostic check.
+type Check struct {
+	Name   string      `json:"name"`             // What was checked, e.g. "ffmpeg" or "ffmpeg encoder libmp3lame"
+	Status CheckStatus `json:"status"`           // CheckOK, CheckWarning or CheckFailed
+	Detail string      `json:"detail,omitempty"` // What was found, e.g. the version
+	Fix    string      `json:"fix,omitempty"`    // How to resolve a warning or failure on this OS
+}
+
+// DiagnosticsReport lists the results of the diagnostic checks in the order
+// they ran.
+type DiagnosticsReport struct {
+	OS     string  `json:"os"` // GOOS the fixes are written for
+	Checks []Check `json:"checks"`
+}
+
+// OK reports whether no check failed. Warnings do not count.
+func (r *DiagnosticsReport) OK() bool {
+	for _, check := range r.Checks {
+		if check.Status == CheckFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// Problems returns the checks that warned or failed.
+func (r *DiagnosticsReport) Problems() []Check {
+	var problems []Check
+	for _, check := range r.Checks {
+		if check.Status != CheckOK {
+			problems = append(problems, check)
+		}
+	}
+	return problems
+}
+
+// String formats the report with one line per check and the fix below each
+// problem.
+func (r *DiagnosticsReport) String() string {
+	var b strings.Builder
+	for _, check := range r.Checks {
+		marker := map[CheckStatus]string{CheckOK: "OK  ", CheckWarning: "WARN", CheckFailed: "FAIL"}[check.Status]
+		fmt.Fprintf(&b, "%s %s", marker, check.Name)
+		if check.Detail != "" {
+			fmt.Fprintf(&b, ": %s", check.Detail)
+		}
+		b.WriteString("\n")
+		if check.Fix != "" {
+			fmt.Fprintf(&b, "     Fix: %s\n", check.Fix)
+		}
+	}
+	return b.String()
+}
+
+// Diagnose checks the external tools (see DiagnoseTools) and the providers of
+// the fallback chains attached to ctx: that each has an API key in the
+// context's credential store, and that its server answers and accepts the key.
+func Diagnose(ctx context.Context) *DiagnosticsReport {
+	report := DiagnoseTools(ctx)
+	report.Checks = append(report.Checks, diagnoseProviders(ctx)...)
+	return report
+}
+
+// DiagnoseTools checks that ffmpeg, ffprobe and yt-dlp are installed and
+// recent enough, and that ffmpeg has the encoders and filters dubbing uses.
+// Fixes are given for the current OS.
+func DiagnoseTools(ctx context.Context) *DiagnosticsReport {
+	return diagnoseTools(ctx, runtime.GOOS, time.Now())
+}
+
+// diagnoseTools implements DiagnoseTools for goos at time now.
+func diagnoseTools(ctx context.Context, goos string, now time.Time) *DiagnosticsReport {
+	report := &DiagnosticsReport{OS: goos}
+	ffmpeg := checkFFmpegTool(ctx, "ffmpeg", goos)
+	report.Checks = append(report.Checks, ffmpeg, checkFFmpegTool(ctx, "ffprobe", goos), checkYtDlp(ctx, goos, now))
+	if ffmpeg.Status != CheckFailed {
+		report.Checks = append(report.Checks, checkFFmpegComponents(ctx, goos)...)
+	}
+	return report
+}
+
+// ffmpegVersionPattern matches the release number in "ffmpeg version 6.1.1-3ubuntu5"
+// or "ffprobe version n7.0".
+var ffmpegVersionPattern = regexp.MustCompile(`version n?(\d+)\.(\d+)(\S*)`)
+
+// checkFFmpegTool checks that tool, ffmpeg or ffprobe, runs and is at least
+// MinFFmpegMajorVersion. Git builds without a release number are accepted.
+func checkFFmpegTool(ctx context.Context, tool, goos string) Check {
+	output, check, ok := runDiagnosticTool(ctx, tool, goos, "-version")
+	if !ok {
+		return check
+	}
+
+	firstLine, _, _ := strings.Cut(output, "\n")
+	matches := ffmpegVersionPattern.FindStringSubmatch(firstLine)
+	if matches == nil {
+		check.Detail = "unrecognised version: " + strings.TrimSpace(firstLine)
+		return check
+	}
+	version := matches[1] + "." + matches[2] + matches[3]
+	if major, _ := strconv.Atoi(matches[1]); major < MinFFmpegMajorVersion {
+		check.Status = CheckWarning
+		check.Detail = fmt.Sprintf("version %s is older than %d.0", version, MinFFmpegMajorVersion)
+		check.Fix = toolFix(upgradeFixes, tool, goos)
+		return check
+	}
+	check.Detail = "version " + version
+	return check
+}
+
+// checkYtDlp checks that yt-dlp runs and is no older than MaxYtDlpAge at now.
+// Its versions are release dates, e.g. "2024.08.06".
+func checkYtDlp(ctx context.Context, goos string, now time.Time) Check {
+	output, check, ok := runDiagnosticTool(ctx, "yt-dlp", goos, "--version")
+	if !ok {
+		return check
+	}
+
+	version := strings.TrimSpace(output)
+	check.Detail = "version " + version
+	if len(version) < 10 {
+		return check
+	}
+	released, err := time.Parse("2006.01.02", version[:10])
+	if err != nil {
+		return check
+	}
+	if now.Sub(released) > MaxYtDlpAge {
+		check.Status = CheckWarning
+		check.Detail = fmt.Sprintf("version %s is more than %d days old; sites change often and old releases fail to download",
+			version, int(MaxYtDlpAge.Hours()/24))
+		check.Fix = toolFix(upgradeFixes, "yt-dlp", goos)
+	}
+	return check
+}
+
+// runDiagnosticTool runs tool with args and returns its output. If the tool
+// is missing or fails, ok is false and check describes the failure.
+func runDiagnosticTool(ctx context.Context, tool, goos string, args ...string) (output string, check Check, ok bool) {
+	check = Check{Name: tool, Status: CheckOK}
+	if _, err := exec.LookPath(tool); err != nil {
+		check.Status = CheckFailed
+		check.Detail = "not found in PATH"
+		check.Fix = toolFix(installFixes, tool, goos)
+		return "", check, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, diagnoseProbeTimeout)
+	defer cancel()
+	out, err := newCommand(ctx, tool, args...).Output()
+	if err != nil {
+		check.Status = CheckFailed
+		check.Detail = fmt.Sprintf("failed to run: %v", err)
+		check.Fix = toolFix(installFixes, tool, goos)
+		return "", check, false
+	}
+	return string(out), check, true
+}
+
+// ffmpegComponents are the ffmpeg encoders and filters the engine can use.
+var ffmpegComponents = []struct {
+	kind     string // "encoder" or "filter"
+	name     string
+	purpose  string
+	required bool // Needed with default options
+}{
+	{"encoder", "libmp3lame", "MP3 dubbed audio, the default format", true},
+	{"encoder", "libvorbis", "OGG dubbed audio", false},
+	{"filter", "loudnorm", "audio normalization", false},
+	{"filter", "rubberband", "pitch shifting without changing tempo", false},
+}
+
+// checkFFmpegComponents checks that ffmpeg was built with ffmpegComponents.
+// Missing required components fail; the others warn.
+func checkFFmpegComponents(ctx context.Context, goos string) []Check {
+	available := make(map[string]map[string]bool)
+	for _, kind := range []string{"encoder", "filter"} {
+		output, check, ok := runDiagnosticTool(ctx, "ffmpeg", goos, "-hide_banner", "-"+kind+"s")
+		if !ok {
+			check.Name = "ffmpeg " + kind + "s"
+			return []Check{check}
+		}
+		available[kind] = ffmpegListNames(output)
+	}
+
+	var checks []Check
+	for _, component := range ffmpegComponents {
+		check := Check{Name: "ffmpeg " + component.kind + " " + component.name, Status: CheckOK, Detail: "for " + component.purpose}
+		if !available[component.kind][component.name] {
+			check.Status = CheckWarning
+			if component.required {
+				check.Status = CheckFailed
+			}
+			check.Detail = "missing; needed for " + component.purpose
+			check.Fix = toolFix(ffmpegBuildFixes, "ffmpeg", goos)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// ffmpegListNames returns the names in the output of "ffmpeg -encoders" or
+// "ffmpeg -filters", where each entry is a flags column followed by the name.
+func ffmpegListNames(output string) map[string]bool {
+	names := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			names[fields[1]] = true
+		}
+	}
+	return names
+}
+
+// Fixes by tool and GOOS; "default" covers other systems.
+var (
+	installFixes = map[string]map[string]string{
+		"ffmpeg": {
+			"windows": "winget install ffmpeg",
+			"darwin":  "brew install ffmpeg",
+			"linux":   "sudo apt install ffmpeg (or your distribution's package manager, e.g. sudo dnf install ffmpeg)",
+			"default": "Install ffmpeg from https://ffmpeg.org/download.html",
+		},
+		"ffprobe": {
+			"windows": "ffprobe ships with ffmpeg: winget install ffmpeg",
+			"darwin":  "ffprobe ships with ffmpeg: brew install ffmpeg",
+			"linux":   "ffprobe ships with ffmpeg: sudo apt install ffmpeg",
+			"default": "ffprobe ships with ffmpeg: install it from https://ffmpeg.org/download.html",
+		},
+		"yt-dlp": {
+			"windows": "winget install yt-dlp",
+			"darwin":  "brew install yt-dlp",
+			"linux":   "python3 -m pip install -U yt-dlp (distribution packages are often outdated)",
+			"default": "Install yt-dlp from https://github.com/yt-dlp/yt-dlp#installation",
+		},
+	}
+	upgradeFixes = map[string]map[string]string{
+		"ffmpeg": {
+			"windows": "winget upgrade ffmpeg",
+			"darwin":  "brew upgrade ffmpeg",
+			"linux":   "Upgrade ffmpeg with your package manager, or install a static build from https://ffmpeg.org/download.html",
+			"default": "Install a current release from https://ffmpeg.org/download.html",
+		},
+		"ffprobe": {
+			"windows": "ffprobe ships with ffmpeg: winget upgrade ffmpeg",
+			"darwin":  "ffprobe ships with ffmpeg: brew upgrade ffmpeg",
+			"linux":   "ffprobe ships with ffmpeg: upgrade it with your package manager",
+			"default": "Install a current ffmpeg release from https://ffmpeg.org/download.html",
+		},
+		"yt-dlp": {
+			"windows": "winget upgrade yt-dlp",
+			"darwin":  "brew upgrade yt-dlp",
+			"linux":   "python3 -m pip install -U yt-dlp, or yt-dlp -U for the standalone binary",
+			"default": "yt-dlp -U",
+		},
+	}
+	ffmpegBuildFixes = map[string]map[string]string{
+		"ffmpeg": {
+			"windows": "Install a full build: winget install Gyan.FFmpeg",
+			"darwin":  "brew reinstall ffmpeg; the Homebrew build includes it",
+			"linux":   "Install a full build, e.g. sudo apt install ffmpeg on Debian or Ubuntu, or a static build from https://ffmpeg.org/download.html",
+			"default": "Install a full build from https://ffmpeg.org/download.html",
+		},
+	}
+)
+
+// toolFix returns the fix for tool on goos from fixes.
+func toolFix(fixes map[string]map[string]string, tool, goos string) string {
+	if fix, ok := fixes[tool][goos]; ok {
+		return fix
+	}
+	return fixes[tool]["default"]
+}
+
+// diagnoseProviders checks the credentials and reachability of every
+// HTTP-based provider in the fallback chains attached to ctx.
+func diagnoseProviders(ctx context.Context) []Check {
+	providers := ProvidersFrom(ctx)
+	seen := make(map[string]bool)
+	var checks []Check
+	for _, capability := range []string{CapabilityTranslation, CapabilityTTS} {
+		for _, provider := range providers.Chain(capability) {
+			if seen[provider] || providers.Config(provider).Type == ProviderTypeBuiltin {
+				continue
+			}
+			seen[provider] = true
+			checks = append(checks, checkProvider(ctx, providers, provider)...)
+		}
+	}
+	return checks
+}
+
+// checkProvider checks that provider has an API key, unless it needs none,
+// and that its server answers and accepts the key.
+func checkProvider(ctx context.Context, providers *Providers, provider string) []Check {
+	config := providers.Config(provider)
+	var checks []Check
+
+	var key Secret
+	if !config.NoAPIKey {
+		check := Check{Name: "provider " + provider + " API key", Status: CheckOK}
+		var err error
+		key, err = CredentialsFrom(ctx).Get(provider)
+		switch {
+		case errors.Is(err, ErrCredentialNotFound):
+			check.Status = CheckWarning
+			check.Detail = "not set; " + provider + " requests will fail"
+			check.Fix = fmt.Sprintf("Set %s, run 'scribe credentials set %s', or save a key under Settings > API Keys",
+				CredentialEnvVar(provider), provider)
+		case err != nil:
+			check.Status = CheckFailed
+			check.Detail = fmt.Sprintf("failed to read: %v", err)
+			check.Fix = "Check that the credentials file in the config directory is readable, or set " + CredentialEnvVar(provider)
+		default:
+			check.Detail = "found"
+		}
+		checks = append(checks, check)
+	}
+
+	return append(checks, checkProviderReachable(ctx, providers, provider, key))
+}
+
+// checkProviderReachable requests a cheap listing from provider, with key
+// if set, to check that its server answers and accepts the key.
+func checkProviderReachable(ctx context.Context, providers *Providers, provider string, key Secret) Check {
+	config := providers.Config(provider)
+	check := Check{Name: "provider " + provider, Status: CheckOK}
+	configFix := fmt.Sprintf("Check base_url and proxy_url of %s under providers in the config, and your network connection", provider)
+
+	path := "/models"
+	if config.Type == ProviderTypeLibreTranslate {
+		path = "/languages"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, diagnoseProbeTimeout)
+	defer cancel()
+	req, err := providers.NewRequest(ctx, provider, "GET", path, nil)
+	if err != nil {
+		check.Status = CheckFailed
+		check.Detail = err.Error()
+		check.Fix = configFix
+		return check
+	}
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key.Reveal())
+	}
+	client, err := providers.Client(provider)
+	if err != nil {
+		check.Status = CheckFailed
+		check.Detail = err.Error()
+		check.Fix = "Check ca_bundle and proxy_url of " + provider + " under providers in the config"
+		return check
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		check.Status = CheckFailed
+		check.Detail = fmt.Sprintf("unreachable: %v", err)
+		check.Fix = configFix
+		return check
+	}
+	resp.Body.Close()
+
+	switch {
+	case (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) && key != "":
+		check.Status = CheckFailed
+		check.Detail = fmt.Sprintf("API key rejected (status %d)", resp.StatusCode)
+		check.Fix = fmt.Sprintf("Replace the key: run 'scribe credentials set %s' or save a new one under Settings > API Keys", provider)
+	case resp.StatusCode >= 500:
+		check.Status = CheckWarning
+		check.Detail = fmt.Sprintf("server error (status %d) from %s", resp.StatusCode, config.BaseURL)
+		check.Fix = "Try again later; configure fallbacks to keep jobs running meanwhile"
+	default:
+		check.Detail = "reachable at " + config.BaseURL
+	}
+	return check
+}
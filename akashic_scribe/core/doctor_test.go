@@ -0,0 +1,134 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// doctorTools puts fake ffmpeg, ffprobe and yt-dlp on PATH that report the
+// given version output. The fake ffmpeg lists libmp3lame and loudnorm only.
+func doctorTools(t *testing.T, ffmpegVersion, ffprobeVersion, ytDlpVersion string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools are shell scripts")
+	}
+
+	dir := t.TempDir()
+	scripts := map[string]string{
+		"ffmpeg": `#!/bin/sh
+case "$1 $2" in
+  "-version "*) echo "` + ffmpegVersion + `" ;;
+  "-hide_banner -encoders") printf ' A....D libmp3lame           libmp3lame MP3\n A....D aac                  AAC\n' ;;
+  "-hide_banner -filters") printf ' ... loudnorm          A->A       EBU R128 loudness normalization\n' ;;
+esac
+`,
+		"ffprobe": "#!/bin/sh\necho \"" + ffprobeVersion + "\"\n",
+		"yt-dlp":  "#!/bin/sh\necho \"" + ytDlpVersion + "\"\n",
+	}
+	for name, script := range scripts {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755))
+	}
+	t.Setenv("PATH", dir)
+}
+
+// checksByName indexes the checks of report by name.
+func checksByName(checks []Check) map[string]Check {
+	byName := make(map[string]Check)
+	for _, check := range checks {
+		byName[check.Name] = check
+	}
+	return byName
+}
+
+func TestDiagnoseTools_VersionsAndComponents(t *testing.T) {
+	doctorTools(t, "ffmpeg version 6.1.1-3ubuntu5 Copyright (c) 2000-2023", "ffprobe version 3.4.8 Copyright", "2023.01.06")
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	report := diagnoseTools(context.Background(), "darwin", now)
+	checks := checksByName(report.Checks)
+
+	assert.Equal(t, Check{Name: "ffmpeg", Status: CheckOK, Detail: "version 6.1.1-3ubuntu5"}, checks["ffmpeg"])
+	assert.Equal(t, CheckWarning, checks["ffprobe"].Status, "ffprobe 3.4 is too old")
+	assert.Equal(t, "ffprobe ships with ffmpeg: brew upgrade ffmpeg", checks["ffprobe"].Fix)
+	assert.Equal(t, CheckWarning, checks["yt-dlp"].Status, "yt-dlp is more than six months old")
+	assert.Equal(t, "brew upgrade yt-dlp", checks["yt-dlp"].Fix)
+
+	assert.Equal(t, CheckOK, checks["ffmpeg encoder libmp3lame"].Status)
+	assert.Equal(t, CheckOK, checks["ffmpeg filter loudnorm"].Status)
+	assert.Equal(t, CheckWarning, checks["ffmpeg encoder libvorbis"].Status)
+	assert.Equal(t, CheckWarning, checks["ffmpeg filter rubberband"].Status)
+	assert.Contains(t, checks["ffmpeg filter rubberband"].Fix, "brew reinstall ffmpeg")
+
+	assert.True(t, report.OK(), "Warnings do not fail the report")
+	assert.Len(t, report.Problems(), 4)
+	assert.Contains(t, report.String(), "WARN yt-dlp: version 2023.01.06 is more than 180 days old")
+}
+
+func TestDiagnoseTools_MissingTools(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	report := diagnoseTools(context.Background(), "linux", time.Now())
+	require.Len(t, report.Checks, 3, "Components are not checked without ffmpeg")
+	for _, check := range report.Checks {
+		assert.Equal(t, CheckFailed, check.Status, check.Name)
+	}
+	assert.False(t, report.OK())
+	assert.Equal(t, "sudo apt install ffmpeg (or your distribution's package manager, e.g. sudo dnf install ffmpeg)", report.Checks[0].Fix)
+
+	report = diagnoseTools(context.Background(), "windows", time.Now())
+	assert.Equal(t, "winget install yt-dlp", report.Checks[2].Fix)
+	report = diagnoseTools(context.Background(), "freebsd", time.Now())
+	assert.Contains(t, report.Checks[2].Fix, "https://github.com/yt-dlp/yt-dlp")
+}
+
+func TestDiagnoseProviders_CredentialsAndReachability(t *testing.T) {
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer sk-good" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer llm.Close()
+	libre := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/languages", r.URL.Path)
+		w.Write([]byte("[]"))
+	}))
+	defer libre.Close()
+
+	config := DefaultConfig()
+	config.Providers = map[string]ProviderConfig{
+		"llm":   {Type: ProviderTypeOpenAI, BaseURL: llm.URL + "/v1"},
+		"libre": {Type: ProviderTypeLibreTranslate, BaseURL: libre.URL, NoAPIKey: true},
+	}
+	config.Fallbacks = map[string][]string{
+		CapabilityTranslation: {"llm", "libre", ProviderBuiltin},
+		CapabilityTTS:         {"llm"},
+	}
+	require.NoError(t, config.Validate())
+	ctx := WithCredentials(WithProviders(context.Background(), config.NewProviders()), CredentialChain{EnvCredentialStore{}})
+
+	t.Setenv("LLM_API_KEY", "sk-bad")
+	checks := diagnoseProviders(ctx)
+	require.Len(t, checks, 3, "Each provider is checked once; builtin and keyless providers need no key check")
+	assert.Equal(t, Check{Name: "provider llm API key", Status: CheckOK, Detail: "found"}, checks[0])
+	assert.Equal(t, CheckFailed, checks[1].Status)
+	assert.Equal(t, "API key rejected (status 401)", checks[1].Detail)
+	assert.Equal(t, Check{Name: "provider libre", Status: CheckOK, Detail: "reachable at " + libre.URL}, checks[2])
+
+	t.Setenv("LLM_API_KEY", "sk-good")
+	assert.Equal(t, CheckOK, diagnoseProviders(ctx)[1].Status)
+
+	os.Unsetenv("LLM_API_KEY")
+	checks = diagnoseProviders(ctx)
+	assert.Equal(t, CheckWarning, checks[0].Status)
+	assert.Contains(t, checks[0].Fix, "LLM_API_KEY")
+}
@@ -1,6 +1,9 @@
 package core
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // ScribeEngine defines the interface for the core transcription and translation engine.
 //
@@ -15,6 +18,14 @@ type ScribeEngine interface {
 	// Translate takes a text and a target language, and returns the translation.
 	Translate(text string, targetLanguage string) (string, error)
 
+	// TranscribeContext is Transcribe bound to ctx: cancelling ctx kills the
+	// tools it runs and aborts its requests.
+	TranscribeContext(ctx context.Context, videoSource string) (string, error)
+
+	// TranslateContext is Translate bound to ctx: cancelling ctx aborts the
+	// provider requests it makes.
+	TranslateContext(ctx context.Context, text string, targetLanguage string) (string, error)
+
 	// StartProcessing runs the full pipeline and reports progress.
 	// The context can be used to cancel the operation at any time.
 	StartProcessing(ctx context.Context, options ScribeOptions, progress chan<- ProgressUpdate) error
@@ -25,9 +36,23 @@ type ScribeEngine interface {
 }
 
 // ProgressUpdate is sent over the progress channel to report backend status.
+// Percentage and Message are always set; the other fields are set when the
+// engine knows them, so consumers never need to parse Message.
 type ProgressUpdate struct {
-	Percentage float64 // 0.0 to 1.0
-	Message    string  // Status message
+	Percentage float64 // Overall progress, 0.0 to 1.0
+	Message    string  // Human-readable status
+
+	Stage         string  // Pipeline stage the update belongs to, e.g. StageDownload (empty outside a stage)
+	StageProgress float64 // Progress within Stage, 0.0 to 1.0
+
+	BytesDone  int64         // Bytes processed so far in the stage, e.g. downloaded (0 = unknown)
+	BytesTotal int64         // Bytes the stage will process in total (0 = unknown)
+	MediaDone  time.Duration // Media time processed so far in the stage (0 = unknown)
+	MediaTotal time.Duration // Media time the stage will process in total (0 = unknown)
+	ETA        time.Duration // Estimated time until the job finishes (0 = unknown)
+
+	Payload any           // Optional stage-specific data, e.g. the *CaptionTranscript used as the transcript
+	Result  *ScribeResult // Final result; set only on the last update sent by StartProcessing
 }
 
 // ScribeResult contains the results of a completed transcription/translation job.
@@ -37,4 +62,61 @@ type ScribeResult struct {
 	DubbedAudio   string `json:"dubbed_audio,omitempty"`
 	SubtitlesFile string `json:"subtitles_file,omitempty"`
 	OutputDir     string `json:"output_dir"`
+
+	StageTimings     []StageTiming      `json:"stage_timings,omitempty"`     // Duration of each pipeline stage, in execution order
+	DetectedLanguage string             `json:"detected_language,omitempty"` // Source language reported by the transcription step
+	ProviderCosts    map[string]float64 `json:"provider_costs,omitempty"`    // Estimated cost in USD, keyed by provider name
+	Usage            map[string]Usage   `json:"usage,omitempty"`             // Billable usage, keyed by provider name
+	ServedBy         map[string]string  `json:"served_by,omitempty"`         // Provider that served each stage, e.g. "translate": "openai"
+	Artifacts        map[string]string  `json:"artifacts,omitempty"`         // Output files by kind, including those written by custom stages
+	TranscriptSource string             `json:"transcript_source,omitempty"` // TranscriptSourceASR, TranscriptSourceCaptions or TranscriptSourceAutoCaptions
+}
+
+// StageTiming records how long a pipeline stage took.
+type StageTiming struct {
+	Stage    string        `json:"stage"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration_ns"`
 }
+
+// stageTimer collects StageTimings for consecutive pipeline stages.
+type stageTimer struct {
+	timings []StageTiming
+	stage   string
+	start   time.Time
+}
+
+// begin ends the current stage, if any, and starts timing a new one.
+func (t *stageTimer) begin(stage string) {
+	t.end()
+	t.stage = stage
+	t.start = time.Now()
+}
+
+// end stops timing the current stage.
+func (t *stageTimer) end() {
+	if t.stage == "" {
+		return
+	}
+	t.timings = append(t.timings, StageTiming{Stage: t.stage, Start: t.start, Duration: time.Since(t.start)})
+	t.stage = ""
+}
+
+// finish ends the current stage and returns all recorded timings.
+func (t *stageTimer) finish() []StageTiming {
+	t.end()
+	return t.timings
+}
+
+// Names of the built-in pipeline stages, as recorded in StageTimings and used
+// to position custom stages and hooks.
+const (
+	StageCaptions   = "captions"
+	StageDownload   = "download"
+	StageExtract    = "extract"
+	StageTranscribe = "transcribe"
+	StageTranslate  = "translate"
+	StageDubbing    = "dubbing"
+	StageSubtitles  = "subtitles"
+	StageSave       = "save"
+)
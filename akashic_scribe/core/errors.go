@@ -0,0 +1,237 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorClass categorises engine failures so callers can decide whether
+// an operation is worth retrying.
+type ErrorClass int
+
+const (
+	ErrorClassUnknown      ErrorClass = iota // Unclassified failure
+	ErrorClassTransient                      // Network failures, HTTP 429 and 5xx responses
+	ErrorClassDependency                     // Missing external tools such as yt-dlp or ffmpeg
+	ErrorClassInvalidInput                   // Bad options, missing input files, HTTP 4xx responses
+	ErrorClassCancelled                      // The operation's context was cancelled
+)
+
+// String returns the string representation of an ErrorClass.
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassTransient:
+		return "Transient"
+	case ErrorClassDependency:
+		return "Dependency"
+	case ErrorClassInvalidInput:
+		return "InvalidInput"
+	case ErrorClassCancelled:
+		return "Cancelled"
+	default:
+		return "Unknown"
+	}
+}
+
+// ScribeError is an error annotated with the ErrorClass it belongs to.
+type ScribeError struct {
+	Class ErrorClass // Category of the failure
+	Op    string     // Operation that failed, e.g. "download", "tts"
+	Err   error      // Underlying error
+}
+
+// NewScribeError wraps err with a class and operation name.
+func NewScribeError(class ErrorClass, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ScribeError{Class: class, Op: op, Err: err}
+}
+
+// Error implements the error interface. The message is the underlying
+// error's message; Op and Class are metadata for callers.
+func (e *ScribeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ScribeError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError determines the ErrorClass of err.
+// Cancellation always wins, so a cancelled download is never retried.
+// Otherwise the outermost ScribeError decides, falling back to
+// well-known standard library errors.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassUnknown
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCancelled
+	}
+
+	var scribeErr *ScribeError
+	if errors.As(err, &scribeErr) {
+		return scribeErr.Class
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTransient
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorClassTransient
+	}
+
+	if errors.Is(err, exec.ErrNotFound) {
+		return ErrorClassDependency
+	}
+
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrorClassInvalidInput
+	}
+
+	return ErrorClassUnknown
+}
+
+// classifyHTTPStatus maps an HTTP response status to an ErrorClass.
+func classifyHTTPStatus(statusCode int) ErrorClass {
+	switch {
+	case statusCode == http.StatusTooManyRequests, statusCode == http.StatusRequestTimeout:
+		return ErrorClassTransient
+	case statusCode >= 500:
+		return ErrorClassTransient
+	case statusCode >= 400:
+		return ErrorClassInvalidInput
+	default:
+		return ErrorClassUnknown
+	}
+}
+
+// ytDlpHTTPErrorPattern matches HTTP errors reported by yt-dlp, e.g. "HTTP Error 403: Forbidden".
+var ytDlpHTTPErrorPattern = regexp.MustCompile(`HTTP Error (\d{3})`)
+
+// ytDlpPermanentErrors are yt-dlp messages for URLs that no retry will fix:
+// private, removed, geo-blocked or unsupported videos.
+var ytDlpPermanentErrors = []string{
+	"Private video",
+	"Video unavailable",
+	"available in your country",
+	"Unsupported URL",
+}
+
+// classifyYtDlpError classifies a failed yt-dlp run from its error output.
+// HTTP errors are classified by status and known permanent failures as
+// invalid input; anything else, such as a network error, is transient.
+func classifyYtDlpError(output string) ErrorClass {
+	if matches := ytDlpHTTPErrorPattern.FindStringSubmatch(output); matches != nil {
+		status, _ := strconv.Atoi(matches[1])
+		if class := classifyHTTPStatus(status); class != ErrorClassUnknown {
+			return class
+		}
+	}
+	for _, message := range ytDlpPermanentErrors {
+		if strings.Contains(output, message) {
+			return ErrorClassInvalidInput
+		}
+	}
+	return ErrorClassTransient
+}
+
+// downloadError wraps a failed yt-dlp download, classified by classifyYtDlpError.
+// err must include yt-dlp's error output.
+func downloadError(err error) error {
+	return NewScribeError(classifyYtDlpError(err.Error()), "download", fmt.Errorf("failed to download video: %w", err))
+}
+
+// RetryPolicy describes how often and how quickly a failed job is retried.
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first (values < 1 are treated as 1)
+	InitialBackoff time.Duration // Delay before the first retry
+	MaxBackoff     time.Duration // Upper bound for the delay (0 = unbounded)
+	Multiplier     float64       // Growth factor applied per attempt (values < 1 are treated as 1)
+	Jitter         float64       // Random spread as a fraction of the delay (0.0 to 1.0)
+}
+
+// DefaultRetryPolicies returns the retry policies used by a new BatchProcessor.
+// Transient failures are retried with exponential backoff, unknown failures
+// get a single retry, and everything else fails immediately.
+func DefaultRetryPolicies() map[ErrorClass]RetryPolicy {
+	return map[ErrorClass]RetryPolicy{
+		ErrorClassTransient: {
+			MaxAttempts:    4,
+			InitialBackoff: 2 * time.Second,
+			MaxBackoff:     time.Minute,
+			Multiplier:     2.0,
+			Jitter:         0.2,
+		},
+		ErrorClassUnknown: {
+			MaxAttempts:    2,
+			InitialBackoff: 5 * time.Second,
+			MaxBackoff:     time.Minute,
+			Multiplier:     2.0,
+			Jitter:         0.2,
+		},
+		ErrorClassDependency:   {MaxAttempts: 1},
+		ErrorClassInvalidInput: {MaxAttempts: 1},
+		ErrorClassCancelled:    {MaxAttempts: 1},
+	}
+}
+
+// Backoff returns the delay to wait after the given number of failed attempts (1-based).
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		delay *= multiplier
+		if p.MaxBackoff > 0 && delay >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+
+	// Spread retries so a burst of failures doesn't retry in lockstep
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay += delay * jitter * (2*rand.Float64() - 1)
+	}
+
+	if delay < 0 {
+		return 0
+	}
+	return time.Duration(delay)
+}
+
+// attempts returns the effective maximum number of attempts.
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
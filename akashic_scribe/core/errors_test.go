@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ErrorClass
+	}{
+		{"Nil error", nil, ErrorClassUnknown},
+		{"Plain error", errors.New("boom"), ErrorClassUnknown},
+		{"Scribe transient", NewScribeError(ErrorClassTransient, "download", errors.New("reset")), ErrorClassTransient},
+		{"Wrapped scribe error", fmt.Errorf("job failed: %w", NewScribeError(ErrorClassDependency, "check", errors.New("missing"))), ErrorClassDependency},
+		{"Context cancelled", fmt.Errorf("operation cancelled: %w", context.Canceled), ErrorClassCancelled},
+		{"Cancellation beats scribe class", NewScribeError(ErrorClassTransient, "download", context.Canceled), ErrorClassCancelled},
+		{"Deadline exceeded", context.DeadlineExceeded, ErrorClassTransient},
+		{"Executable not found", &exec.Error{Name: "yt-dlp", Err: exec.ErrNotFound}, ErrorClassDependency},
+		{"File not found", fmt.Errorf("stat: %w", os.ErrNotExist), ErrorClassInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClassifyError(tt.err))
+		})
+	}
+}
+
+func TestClassifyHTTPStatus(t *testing.T) {
+	assert.Equal(t, ErrorClassTransient, classifyHTTPStatus(429))
+	assert.Equal(t, ErrorClassTransient, classifyHTTPStatus(500))
+	assert.Equal(t, ErrorClassTransient, classifyHTTPStatus(503))
+	assert.Equal(t, ErrorClassInvalidInput, classifyHTTPStatus(400))
+	assert.Equal(t, ErrorClassInvalidInput, classifyHTTPStatus(401))
+	assert.Equal(t, ErrorClassUnknown, classifyHTTPStatus(302))
+}
+
+func TestClassifyYtDlpError(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected ErrorClass
+	}{
+		{"ERROR: [youtube] abc: Private video. Sign in if you've been granted access", ErrorClassInvalidInput},
+		{"ERROR: [youtube] abc: Video unavailable. This video has been removed by the uploader", ErrorClassInvalidInput},
+		{"ERROR: [youtube] abc: The uploader has not made this video available in your country", ErrorClassInvalidInput},
+		{"ERROR: Unsupported URL: https://example.com/page", ErrorClassInvalidInput},
+		{"ERROR: unable to download video data: HTTP Error 403: Forbidden", ErrorClassInvalidInput},
+		{"ERROR: unable to download video data: HTTP Error 429: Too Many Requests", ErrorClassTransient},
+		{"ERROR: unable to download video data: HTTP Error 503: Service Unavailable", ErrorClassTransient},
+		{"ERROR: Unable to download webpage: <urlopen error [Errno -3] Temporary failure in name resolution>", ErrorClassTransient},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, classifyYtDlpError(tt.output), tt.output)
+	}
+}
+
+func TestScribeErrorUnwrap(t *testing.T) {
+	base := errors.New("underlying")
+	err := NewScribeError(ErrorClassTransient, "tts", base)
+
+	assert.True(t, errors.Is(err, base))
+	assert.Equal(t, "underlying", err.Error())
+	assert.Nil(t, NewScribeError(ErrorClassTransient, "tts", nil))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2.0,
+	}
+
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 400*time.Millisecond, policy.Backoff(3))
+	assert.Equal(t, time.Second, policy.Backoff(10), "Backoff should be capped at MaxBackoff")
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: time.Second,
+		Multiplier:     1.0,
+		Jitter:         0.5,
+	}
+
+	for i := 0; i < 100; i++ {
+		delay := policy.Backoff(1)
+		assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		assert.LessOrEqual(t, delay, 1500*time.Millisecond)
+	}
+}
+
+func TestDefaultRetryPolicies(t *testing.T) {
+	policies := DefaultRetryPolicies()
+
+	assert.Greater(t, policies[ErrorClassTransient].MaxAttempts, 1, "Transient errors should be retried")
+	assert.Equal(t, 1, policies[ErrorClassDependency].attempts())
+	assert.Equal(t, 1, policies[ErrorClassInvalidInput].attempts())
+	assert.Equal(t, 1, policies[ErrorClassCancelled].attempts())
+	assert.Equal(t, 1, RetryPolicy{}.attempts())
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Assumptions behind job estimates. Speech runs at about 150 words, or 900
+// characters, per minute; processing times are per minute of media.
+const (
+	estimateCharactersPerMinute = 900
+	estimateOverhead            = 2 * time.Second
+	estimateDownloadPerMinute   = 3 * time.Second
+	estimateTranscribePerMinute = 6 * time.Second
+	estimateTranslatePerMinute  = 1 * time.Second
+	estimateDubbingPerMinute    = 4 * time.Second
+)
+
+// Estimate is the expected usage, cost and runtime of a job, computed before
+// it runs.
+type Estimate struct {
+	MediaDuration        time.Duration      `json:"media_duration_ns"`        // Media that will be processed, after any time range
+	DurationSource       string             `json:"duration_source"`          // "ffprobe" for files, "yt-dlp" for URLs, "playlist" if known from a playlist listing
+	TranscriptCharacters int64              `json:"transcript_characters"`    // Expected transcript length
+	Usage                map[string]Usage   `json:"usage,omitempty"`          // Expected billable usage, keyed by provider name
+	ProviderCosts        map[string]float64 `json:"provider_costs,omitempty"` // Expected cost in USD, keyed by provider name
+	TotalCost            float64            `json:"total_cost"`
+	Runtime              time.Duration      `json:"runtime_ns"` // Expected processing time
+}
+
+// EstimateJob probes the input's duration and estimates the usage, cost and
+// runtime of processing it with opts. Costs use the price table attached to
+// ctx (see WithPriceTable). Jobs that use platform captions are estimated as
+// if they were transcribed, since whether captions exist is only known when
+// the job runs.
+func EstimateJob(ctx context.Context, opts ScribeOptions) (*Estimate, error) {
+	if err := validateTimeRange(opts); err != nil {
+		return nil, err
+	}
+
+	var est Estimate
+	var duration time.Duration
+	var err error
+	switch {
+	case opts.InputFile != "":
+		if _, statErr := os.Stat(opts.InputFile); statErr != nil {
+			return nil, NewScribeError(ErrorClassInvalidInput, "input", fmt.Errorf("input file not found: %w", statErr))
+		}
+		est.DurationSource = "ffprobe"
+		duration, err = probeDuration(ctx, opts.InputFile)
+	case opts.InputURL != "" && opts.InputDuration > 0:
+		est.DurationSource = "playlist"
+		duration = opts.InputDuration
+	case opts.InputURL != "":
+		est.DurationSource = "yt-dlp"
+		duration, err = probeURLDuration(ctx, opts.InputURL)
+	default:
+		return nil, NewScribeError(ErrorClassInvalidInput, "input", errors.New("no input file or URL provided"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe input duration: %w", err)
+	}
+
+	// Only the requested time range is processed
+	if opts.EndTime > 0 && opts.EndTime < duration {
+		duration = opts.EndTime
+	}
+	if opts.StartTime >= duration {
+		return nil, NewScribeError(ErrorClassInvalidInput, "input",
+			fmt.Errorf("start time %s is past the end of the input (%s)", opts.StartTime, duration))
+	}
+	est.MediaDuration = duration - opts.StartTime
+
+	minutes := est.MediaDuration.Minutes()
+	est.TranscriptCharacters = int64(minutes * estimateCharactersPerMinute)
+
+	// Mirror the usage the pipeline stages record, assuming the first
+	// provider of each fallback chain serves. LLM translation is billed for
+	// the transcript and its translation, at about four characters per token.
+	providers := ProvidersFrom(ctx)
+	state := NewPipelineState(opts, nil)
+	state.RecordUsage(ProviderBuiltin, Usage{AudioMinutes: minutes})
+	if translator := firstProvider(providers, CapabilityTranslation); providers.Config(translator).Type == ProviderTypeLibreTranslate {
+		state.RecordUsage(translator, Usage{Characters: est.TranscriptCharacters})
+	} else {
+		state.RecordUsage(translator, Usage{Tokens: 2 * ((est.TranscriptCharacters + 3) / 4)})
+	}
+	runtime := estimateOverhead + perMinute(estimateTranscribePerMinute, minutes) + perMinute(estimateTranslatePerMinute, minutes)
+	if opts.InputURL != "" && opts.InputFile == "" {
+		runtime += perMinute(estimateDownloadPerMinute, minutes)
+	}
+	if opts.CreateDubbing {
+		if !opts.UseCustomVoice {
+			state.RecordUsage(firstProvider(providers, CapabilityTTS), Usage{Characters: est.TranscriptCharacters})
+		}
+		runtime += perMinute(estimateDubbingPerMinute, minutes)
+	}
+
+	est.Usage = state.Usage
+	est.ProviderCosts = PriceTableFrom(ctx).Costs(est.Usage)
+	for _, cost := range est.ProviderCosts {
+		est.TotalCost += cost
+	}
+	est.Runtime = runtime.Round(time.Second)
+	return &est, nil
+}
+
+// firstProvider returns the provider tried first for capability.
+func firstProvider(providers *Providers, capability string) string {
+	if chain := providers.Chain(capability); len(chain) > 0 {
+		return chain[0]
+	}
+	return DefaultProviderChains()[capability][0]
+}
+
+// perMinute scales a per-minute time by a number of minutes.
+func perMinute(d time.Duration, minutes float64) time.Duration {
+	return time.Duration(float64(d) * minutes)
+}
+
+// Add accumulates other into e, e.g. to total the estimates of a batch.
+// The runtimes are summed as if the jobs ran one after another.
+func (e *Estimate) Add(other *Estimate) {
+	e.MediaDuration += other.MediaDuration
+	e.TranscriptCharacters += other.TranscriptCharacters
+	e.Runtime += other.Runtime
+	e.TotalCost += other.TotalCost
+	if e.Usage == nil {
+		e.Usage = make(map[string]Usage)
+	}
+	for provider, usage := range other.Usage {
+		total := e.Usage[provider]
+		total.Add(usage)
+		e.Usage[provider] = total
+	}
+	if e.ProviderCosts == nil {
+		e.ProviderCosts = make(map[string]float64)
+	}
+	for provider, cost := range other.ProviderCosts {
+		e.ProviderCosts[provider] += cost
+	}
+}
+
+// Summary describes the estimate in one line, e.g. for a confirmation prompt.
+func (e *Estimate) Summary() string {
+	return fmt.Sprintf("%s of media, about %d transcript characters; estimated cost $%.4f, runtime about %s",
+		e.MediaDuration.Round(time.Second), e.TranscriptCharacters, e.TotalCost, e.Runtime)
+}
+
+// probeURLDuration reads a video's duration from `yt-dlp -J` without downloading it.
+func probeURLDuration(ctx context.Context, url string) (time.Duration, error) {
+	cmd := newCommand(ctx, "yt-dlp", "-J", "--no-playlist", "--skip-download", url)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	logCommand(ctx, cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf("operation cancelled: %w", ctx.Err())
+		}
+		return 0, NewScribeError(classifyYtDlpError(stderr.String()), "estimate",
+			fmt.Errorf("yt-dlp failed: %w: %s", err, lastLines(stderr.String(), 5)))
+	}
+
+	var info struct {
+		Duration float64 `json:"duration"`
+	}
+	if err := json.Unmarshal(output, &info); err != nil {
+		return 0, fmt.Errorf("failed to parse yt-dlp output: %w", err)
+	}
+	if info.Duration <= 0 {
+		return 0, fmt.Errorf("yt-dlp did not report a duration for %s", url)
+	}
+	return time.Duration(info.Duration * float64(time.Second)), nil
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateJob_LocalFile(t *testing.T) {
+	fakeTools(t)
+	input := filepath.Join(t.TempDir(), "talk.mp4")
+	require.NoError(t, os.WriteFile(input, []byte("video"), 0o644))
+
+	est, err := EstimateJob(context.Background(), ScribeOptions{
+		InputFile:      input,
+		TargetLanguage: "ja-JP",
+		CreateDubbing:  true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ffprobe", est.DurationSource)
+	assert.Equal(t, 12500*time.Millisecond, est.MediaDuration, "The fake ffprobe reports 12.5 seconds")
+	assert.Equal(t, int64(187), est.TranscriptCharacters)
+	assert.InDelta(t, 12.5/60, est.Usage[ProviderBuiltin].AudioMinutes, 1e-9)
+	assert.Equal(t, int64(94), est.Usage[ProviderBuiltin].Tokens, "Transcript and translation tokens")
+	assert.Equal(t, int64(187), est.Usage[ProviderOpenAI].Characters)
+	assert.InDelta(t, 187*30/1e6, est.TotalCost, 1e-9)
+	assert.Greater(t, est.Runtime, estimateOverhead)
+
+	est, err = EstimateJob(context.Background(), ScribeOptions{
+		InputFile:      input,
+		TargetLanguage: "ja-JP",
+		CreateDubbing:  true,
+		UseCustomVoice: true,
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, est.Usage, ProviderOpenAI, "Custom voices are not billed")
+	assert.Zero(t, est.TotalCost)
+}
+
+func TestEstimateJob_URLAndTimeRange(t *testing.T) {
+	argsLog := fakeTools(t)
+
+	ctx := WithPriceTable(context.Background(), PriceTable{ProviderBuiltin: {PerAudioMinute: 1}})
+	est, err := EstimateJob(ctx, ScribeOptions{
+		InputURL:       "https://example.com/watch?v=talk",
+		TargetLanguage: "ja-JP",
+		StartTime:      30 * time.Second,
+		EndTime:        time.Hour,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "yt-dlp", est.DurationSource)
+	assert.Equal(t, time.Minute, est.MediaDuration, "The 90 second video is clipped from 30 seconds to its end")
+	assert.InDelta(t, 1.0, est.TotalCost, 1e-9)
+
+	args, err := os.ReadFile(argsLog)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "-J --no-playlist --skip-download")
+	assert.NotContains(t, string(args), " -o ", "Nothing was downloaded")
+
+	_, err = EstimateJob(ctx, ScribeOptions{
+		InputURL:  "https://example.com/watch?v=talk",
+		StartTime: 2 * time.Minute,
+	})
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+
+	_, err = EstimateJob(ctx, ScribeOptions{InputFile: filepath.Join(t.TempDir(), "missing.mp4")})
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+}
+
+func TestEstimateJob_KnownURLDuration(t *testing.T) {
+	argsLog := fakeTools(t)
+
+	est, err := EstimateJob(context.Background(), ScribeOptions{
+		InputURL:      "https://example.com/watch?v=talk",
+		InputDuration: 10 * time.Minute,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "playlist", est.DurationSource)
+	assert.Equal(t, 10*time.Minute, est.MediaDuration)
+
+	_, err = os.Stat(argsLog)
+	assert.True(t, os.IsNotExist(err), "yt-dlp is not run for a known duration")
+}
+
+func TestEstimateJob_ClassifiesProbeFailures(t *testing.T) {
+	argsLog := fakeTools(t)
+	ytDlp := filepath.Join(filepath.Dir(argsLog), "yt-dlp")
+
+	require.NoError(t, os.WriteFile(ytDlp, []byte("#!/bin/sh\necho 'ERROR: Video unavailable' >&2\nexit 1\n"), 0o755))
+	_, err := EstimateJob(context.Background(), ScribeOptions{InputURL: "https://example.com/watch?v=gone"})
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+
+	require.NoError(t, os.WriteFile(ytDlp, []byte("#!/bin/sh\necho 'ERROR: Unable to download webpage: timed out' >&2\nexit 1\n"), 0o755))
+	_, err = EstimateJob(context.Background(), ScribeOptions{InputURL: "https://example.com/watch?v=slow"})
+	assert.Equal(t, ErrorClassTransient, ClassifyError(err))
+}
+
+func TestEstimate_Add(t *testing.T) {
+	var total Estimate
+	for range 2 {
+		total.Add(&Estimate{
+			MediaDuration:        time.Minute,
+			TranscriptCharacters: 900,
+			Usage:                map[string]Usage{ProviderOpenAI: {Characters: 900}},
+			ProviderCosts:        map[string]float64{ProviderOpenAI: 0.027},
+			TotalCost:            0.027,
+			Runtime:              10 * time.Second,
+		})
+	}
+
+	assert.Equal(t, 2*time.Minute, total.MediaDuration)
+	assert.Equal(t, int64(1800), total.Usage[ProviderOpenAI].Characters)
+	assert.InDelta(t, 0.054, total.ProviderCosts[ProviderOpenAI], 1e-9)
+	assert.Equal(t, 20*time.Second, total.Runtime)
+	assert.Equal(t, "2m0s of media, about 1800 transcript characters; estimated cost $0.0540, runtime about 20s", total.Summary())
+}
@@ -0,0 +1,280 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// BatchEventType identifies the kind of a BatchEvent.
+type BatchEventType int
+
+const (
+	EventJobQueued    BatchEventType = iota // Job added to the queue
+	EventJobStarted                         // Job picked up by a worker
+	EventStageChanged                       // Job entered a new pipeline stage
+	EventJobProgress                        // Job progress changed within a stage
+	EventJobSucceeded                       // Job completed successfully (terminal)
+	EventJobFailed                          // Job failed after all retries (terminal)
+	EventJobCancelled                       // Job was cancelled (terminal)
+)
+
+// String returns the string representation of a BatchEventType.
+func (t BatchEventType) String() string {
+	switch t {
+	case EventJobQueued:
+		return "JobQueued"
+	case EventJobStarted:
+		return "JobStarted"
+	case EventStageChanged:
+		return "StageChanged"
+	case EventJobProgress:
+		return "JobProgress"
+	case EventJobSucceeded:
+		return "JobSucceeded"
+	case EventJobFailed:
+		return "JobFailed"
+	case EventJobCancelled:
+		return "JobCancelled"
+	default:
+		return "Unknown"
+	}
+}
+
+// IsTerminal reports whether the event ends a job's lifecycle.
+// Terminal events are never dropped by a Subscription.
+func (t BatchEventType) IsTerminal() bool {
+	return t == EventJobSucceeded || t == EventJobFailed || t == EventJobCancelled
+}
+
+// BatchEvent describes a change in the state of a batch job.
+type BatchEvent struct {
+	Type          BatchEventType
+	JobID         string
+	Time          time.Time
+	Stage         string        // Current stage (StageChanged, JobProgress)
+	Progress      float64       // Job progress (0.0 to 1.0)
+	StageProgress float64       // Progress within Stage (0.0 to 1.0)
+	ETA           time.Duration // Estimated time until the job finishes (0 = unknown)
+	Message       string        // Status message from the engine
+	Attempt       int           // Attempt number the event belongs to
+	Error         error         // Error for JobFailed and JobCancelled
+	Result        *ScribeResult // Result for JobSucceeded
+}
+
+// DefaultSubscriptionBuffer is the buffer size used when Subscribe is called with size <= 0.
+const DefaultSubscriptionBuffer = 64
+
+// Subscription delivers BatchEvents to a single consumer.
+//
+// Each subscription has its own bounded buffer so a slow consumer never
+// blocks the processor or other subscribers. When the buffer is full,
+// non-terminal events are dropped (consecutive JobProgress events for the
+// same job are coalesced first); terminal events are always delivered.
+type Subscription struct {
+	events chan BatchEvent
+
+	mu       sync.Mutex
+	pending  []BatchEvent
+	buffer   int           // Maximum number of pending non-terminal events
+	dropped  uint64        // Number of events dropped because the buffer was full
+	draining bool          // Deliver remaining events, then close
+	wake     chan struct{} // Signals the delivery goroutine
+	done     chan struct{} // Closed by Close to abandon delivery
+	once     sync.Once
+}
+
+// newSubscription creates a subscription and starts its delivery goroutine.
+func newSubscription(buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultSubscriptionBuffer
+	}
+
+	s := &Subscription{
+		events: make(chan BatchEvent),
+		buffer: buffer,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go s.deliver()
+	return s
+}
+
+// Events returns the channel on which events are delivered. The channel is
+// closed after the processor shuts down and all pending events were delivered,
+// or when Close is called.
+func (s *Subscription) Events() <-chan BatchEvent {
+	return s.events
+}
+
+// Dropped returns the number of non-terminal events dropped because the buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.dropped
+}
+
+// Close stops delivery immediately and discards pending events.
+func (s *Subscription) Close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// push queues an event for delivery.
+func (s *Subscription) push(event BatchEvent) {
+	select {
+	case <-s.done:
+		return // Closed by the consumer
+	default:
+	}
+
+	s.mu.Lock()
+	if s.draining {
+		s.mu.Unlock()
+		return
+	}
+
+	if !event.Type.IsTerminal() {
+		if event.Type == EventJobProgress {
+			// Replace a queued progress event for the same job rather than growing the queue
+			for i := len(s.pending) - 1; i >= 0; i-- {
+				if s.pending[i].JobID != event.JobID {
+					continue
+				}
+				if s.pending[i].Type == EventJobProgress {
+					s.pending[i] = event
+					s.mu.Unlock()
+					return
+				}
+				break
+			}
+		}
+
+		if s.nonTerminalPendingLocked() >= s.buffer {
+			s.dropped++
+			s.mu.Unlock()
+			return
+		}
+	}
+
+	s.pending = append(s.pending, event)
+	s.mu.Unlock()
+	s.signal()
+}
+
+// drain delivers the remaining events and then closes the events channel.
+func (s *Subscription) drain() {
+	s.mu.Lock()
+	s.draining = true
+	s.mu.Unlock()
+	s.signal()
+}
+
+// signal wakes the delivery goroutine without blocking.
+func (s *Subscription) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// nonTerminalPendingLocked counts queued non-terminal events. Callers must hold s.mu.
+func (s *Subscription) nonTerminalPendingLocked() int {
+	count := 0
+	for _, event := range s.pending {
+		if !event.Type.IsTerminal() {
+			count++
+		}
+	}
+	return count
+}
+
+// deliver forwards queued events to the consumer in order.
+func (s *Subscription) deliver() {
+	defer close(s.events)
+
+	for {
+		s.mu.Lock()
+		if len(s.pending) == 0 {
+			draining := s.draining
+			s.mu.Unlock()
+			if draining {
+				return
+			}
+
+			select {
+			case <-s.wake:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		event := s.pending[0]
+		s.mu.Unlock()
+
+		select {
+		case s.events <- event:
+			s.mu.Lock()
+			s.pending = s.pending[1:]
+			s.mu.Unlock()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Subscribe registers a new event subscriber with the given buffer size
+// (<= 0 uses DefaultSubscriptionBuffer). Subscribers only receive events
+// published after they subscribed.
+func (bp *BatchProcessor) Subscribe(buffer int) *Subscription {
+	sub := newSubscription(buffer)
+
+	bp.subsMu.Lock()
+	defer bp.subsMu.Unlock()
+
+	if bp.subsClosed {
+		sub.drain()
+		return sub
+	}
+	bp.subscribers = append(bp.subscribers, sub)
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its subscription.
+func (bp *BatchProcessor) Unsubscribe(sub *Subscription) {
+	bp.subsMu.Lock()
+	for i, s := range bp.subscribers {
+		if s == sub {
+			bp.subscribers = append(bp.subscribers[:i], bp.subscribers[i+1:]...)
+			break
+		}
+	}
+	bp.subsMu.Unlock()
+
+	sub.Close()
+}
+
+// publish sends an event to every subscriber. It never blocks.
+func (bp *BatchProcessor) publish(event BatchEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	bp.subsMu.Lock()
+	defer bp.subsMu.Unlock()
+
+	for _, sub := range bp.subscribers {
+		sub.push(event)
+	}
+}
+
+// closeSubscriptions delivers outstanding events to all subscribers and then closes them.
+func (bp *BatchProcessor) closeSubscriptions() {
+	bp.subsMu.Lock()
+	defer bp.subsMu.Unlock()
+
+	bp.subsClosed = true
+	for _, sub := range bp.subscribers {
+		sub.drain()
+	}
+	bp.subscribers = nil
+}
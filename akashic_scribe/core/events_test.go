@@ -0,0 +1,198 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectEvents reads events until the subscription closes.
+func collectEvents(t *testing.T, sub *Subscription) []BatchEvent {
+	t.Helper()
+
+	var events []BatchEvent
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		case <-timeout:
+			t.Fatal("Timed out waiting for the subscription to close")
+			return events
+		}
+	}
+}
+
+// eventTypes returns the event types received for a job.
+func eventTypes(events []BatchEvent, jobID string) []BatchEventType {
+	var types []BatchEventType
+	for _, event := range events {
+		if event.JobID == jobID {
+			types = append(types, event.Type)
+		}
+	}
+	return types
+}
+
+func TestSubscribe_JobLifecycle(t *testing.T) {
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	sub := bp.Subscribe(0)
+
+	jobID := bp.AddJob(ScribeOptions{InputFile: "video.mp4", TargetLanguage: "Spanish"})
+	bp.Wait()
+
+	events := collectEvents(t, sub)
+	types := eventTypes(events, jobID)
+
+	require.NotEmpty(t, types)
+	assert.Equal(t, EventJobQueued, types[0])
+	assert.Equal(t, EventJobStarted, types[1])
+	assert.Contains(t, types, EventStageChanged)
+	assert.Equal(t, EventJobSucceeded, types[len(types)-1])
+
+	// Stage changes use the engine's stage names
+	var stages []string
+	for _, event := range events {
+		if event.Type == EventStageChanged {
+			stages = append(stages, event.Stage)
+		}
+	}
+	assert.Contains(t, stages, StageTranscribe)
+	assert.Contains(t, stages, StageTranslate)
+
+	final := events[len(events)-1]
+	require.NotNil(t, final.Result)
+	assert.Equal(t, "/mock/output/dir", final.Result.OutputDir)
+	assert.Equal(t, 1, final.Attempt)
+}
+
+func TestSubscribe_FailedAndCancelledJobs(t *testing.T) {
+	engine := newScriptedEngine(func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error) {
+		return nil, NewScribeError(ErrorClassInvalidInput, "input", errors.New("bad input"))
+	})
+	bp := NewBatchProcessor(engine, 1)
+	sub := bp.Subscribe(0)
+
+	bp.Pause()
+	failing := bp.AddJob(ScribeOptions{InputFile: "bad.mp4"})
+	cancelled := bp.AddJob(ScribeOptions{InputFile: "unwanted.mp4"})
+	require.NoError(t, bp.CancelJob(cancelled))
+	bp.Resume()
+	bp.Wait()
+
+	events := collectEvents(t, sub)
+
+	failTypes := eventTypes(events, failing)
+	assert.Equal(t, EventJobFailed, failTypes[len(failTypes)-1])
+
+	cancelTypes := eventTypes(events, cancelled)
+	assert.Equal(t, []BatchEventType{EventJobQueued, EventJobCancelled}, cancelTypes)
+
+	for _, event := range events {
+		if event.Type == EventJobFailed {
+			assert.EqualError(t, event.Error, "bad input")
+		}
+	}
+}
+
+func TestBatchProcessor_CancelBetweenDequeueAndStart(t *testing.T) {
+	engine := newScriptedEngine(func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error) {
+		return &ScribeResult{}, nil
+	})
+	bp := NewBatchProcessor(engine, 1)
+	sub := bp.Subscribe(0)
+
+	// Take the job off the queue the way nextJob does, without starting it
+	bp.Pause()
+	jobID := bp.AddJob(ScribeOptions{InputFile: "video.mp4"})
+	bp.mu.Lock()
+	job := bp.jobs[jobID]
+	require.True(t, bp.dequeue(job))
+	bp.running++
+	bp.mu.Unlock()
+
+	require.NoError(t, bp.CancelJob(jobID))
+	bp.processJob(0, job)
+	bp.Resume()
+	bp.Wait()
+
+	assert.Zero(t, engine.calls.Load(), "A cancelled job must not run")
+	snapshot, _ := bp.JobSnapshot(jobID)
+	assert.Equal(t, JobCancelled, snapshot.Status)
+	assert.False(t, snapshot.EndTime.IsZero())
+	assert.Equal(t, []BatchEventType{EventJobQueued, EventJobCancelled}, eventTypes(collectEvents(t, sub), jobID))
+}
+
+func TestSubscription_NeverDropsTerminalEvents(t *testing.T) {
+	sub := newSubscription(2)
+
+	// Nobody is reading, so the buffer fills up
+	for i := 0; i < 10; i++ {
+		sub.push(BatchEvent{Type: EventStageChanged, JobID: fmt.Sprintf("job_%d", i)})
+	}
+	for i := 0; i < 5; i++ {
+		sub.push(BatchEvent{Type: EventJobSucceeded, JobID: fmt.Sprintf("job_%d", i)})
+	}
+	sub.drain()
+
+	var terminal, other int
+	for event := range sub.Events() {
+		if event.Type.IsTerminal() {
+			terminal++
+		} else {
+			other++
+		}
+	}
+
+	assert.Equal(t, 5, terminal, "Every terminal event must be delivered")
+	assert.LessOrEqual(t, other, 3, "Non-terminal events beyond the buffer should be dropped")
+	assert.GreaterOrEqual(t, sub.Dropped(), uint64(7))
+}
+
+func TestSubscription_CoalescesProgress(t *testing.T) {
+	sub := newSubscription(4)
+
+	sub.push(BatchEvent{Type: EventStageChanged, JobID: "job_1", Stage: "Transcribing"})
+	for i := 1; i <= 100; i++ {
+		sub.push(BatchEvent{Type: EventJobProgress, JobID: "job_1", Progress: float64(i) / 100})
+	}
+	sub.drain()
+
+	var last BatchEvent
+	count := 0
+	for event := range sub.Events() {
+		last = event
+		count++
+	}
+
+	assert.LessOrEqual(t, count, 3)
+	assert.Equal(t, 1.0, last.Progress, "The most recent progress should survive coalescing")
+	assert.Zero(t, sub.Dropped())
+}
+
+func TestUnsubscribe(t *testing.T) {
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	sub := bp.Subscribe(1)
+	bp.Unsubscribe(sub)
+
+	select {
+	case _, ok := <-sub.Events():
+		assert.False(t, ok, "Events channel should be closed after Unsubscribe")
+	case <-time.After(time.Second):
+		t.Fatal("Events channel was not closed")
+	}
+
+	bp.AddJob(ScribeOptions{InputFile: "video.mp4"})
+	bp.Wait()
+
+	late := bp.Subscribe(1)
+	assert.Empty(t, collectEvents(t, late), "Subscribing after shutdown yields a closed subscription")
+}
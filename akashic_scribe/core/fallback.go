@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// Capabilities served by providers, used as keys of fallback chains.
+const (
+	CapabilityTranslation = "translation"
+	CapabilityTTS         = "tts"
+)
+
+// DefaultProviderChains returns the provider chains used when the
+// configuration declares none: the built-in translator and OpenAI TTS.
+func DefaultProviderChains() map[string][]string {
+	return map[string][]string{
+		CapabilityTranslation: {ProviderBuiltin},
+		CapabilityTTS:         {ProviderOpenAI},
+	}
+}
+
+// Chain returns the providers that serve capability, in the order they are tried.
+func (p *Providers) Chain(capability string) []string {
+	return p.chains[capability]
+}
+
+// served describes the provider that served a request and what it used.
+type served struct {
+	provider string
+	usage    Usage
+}
+
+// withFallback calls attempt with each provider of the capability's chain in
+// turn until one succeeds. Only transient failures, such as network errors or
+// HTTP 429 and 5xx responses, move on to the next provider; any other error,
+// or cancellation, ends the chain.
+func withFallback(ctx context.Context, capability string, attempt func(ctx context.Context, provider string) (Usage, error)) (served, error) {
+	providers := ProvidersFrom(ctx)
+	chain := providers.Chain(capability)
+	if len(chain) == 0 {
+		return served{}, NewScribeError(ErrorClassInvalidInput, capability, fmt.Errorf("no providers configured for %s", capability))
+	}
+
+	var lastErr error
+	for i, provider := range chain {
+		if !providers.Config(provider).Supports(capability) {
+			return served{}, NewScribeError(ErrorClassInvalidInput, capability,
+				fmt.Errorf("provider %s does not offer %s", provider, capability))
+		}
+
+		usage, err := attempt(ctx, provider)
+		if err == nil {
+			if i > 0 {
+				LoggerFrom(ctx).Info("Fallback provider served request", "capability", capability, "provider", provider)
+			}
+			return served{provider: provider, usage: usage}, nil
+		}
+		if ctx.Err() != nil || ClassifyError(err) != ErrorClassTransient {
+			return served{}, err
+		}
+
+		lastErr = err
+		if i < len(chain)-1 {
+			LoggerFrom(ctx).Warn("Provider failed; falling back to the next one",
+				"capability", capability, "provider", provider, "next", chain[i+1], "error", err)
+		}
+	}
+	if len(chain) == 1 {
+		return served{}, lastErr
+	}
+	return served{}, fmt.Errorf("all %s providers failed: %w", capability, lastErr)
+}
+
+// RecordServedBy records that provider served stage, for ScribeResult.ServedBy.
+func (s *PipelineState) RecordServedBy(stage, provider string) {
+	if s.ServedBy == nil {
+		s.ServedBy = make(map[string]string)
+	}
+	s.ServedBy[stage] = provider
+}
+
+// recordServed records the provider that served stage and its usage.
+func (s *PipelineState) recordServed(stage string, by served) {
+	s.RecordServedBy(stage, by.provider)
+	s.RecordUsage(by.provider, by.usage)
+}
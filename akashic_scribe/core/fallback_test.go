@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fallbackProviders configures a local OpenAI-compatible primary answering
+// with primaryStatus, and a LibreTranslate secondary, as the translation chain.
+func fallbackProviders(t *testing.T, primaryStatus int) (ctx context.Context, secondaryCalls *atomic.Int32) {
+	t.Helper()
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(primaryStatus)
+	}))
+	t.Cleanup(primary.Close)
+
+	secondaryCalls = new(atomic.Int32)
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls.Add(1)
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "/translate", r.URL.Path)
+		assert.Equal(t, "en", body["source"])
+		assert.Equal(t, "ja", body["target"])
+		json.NewEncoder(w).Encode(map[string]string{"translatedText": "こんにちは"})
+	}))
+	t.Cleanup(secondary.Close)
+
+	config := DefaultConfig()
+	config.Providers = map[string]ProviderConfig{
+		"local-llm": {Type: ProviderTypeOpenAI, BaseURL: primary.URL, NoAPIKey: true},
+		"libre":     {Type: ProviderTypeLibreTranslate, BaseURL: secondary.URL, NoAPIKey: true},
+	}
+	config.Fallbacks = map[string][]string{CapabilityTranslation: {"local-llm", "libre"}}
+	require.NoError(t, config.Validate())
+	return WithProviders(context.Background(), config.NewProviders()), secondaryCalls
+}
+
+func TestTranslateStage_FailsOverOnTransientErrors(t *testing.T) {
+	ctx, secondaryCalls := fallbackProviders(t, http.StatusServiceUnavailable)
+	state := NewPipelineState(ScribeOptions{OriginLanguage: "en-US", TargetLanguage: "ja-JP"}, nil)
+	state.Transcript = "hello"
+
+	require.NoError(t, (&realScribeEngine{}).translateStage(ctx, state))
+	assert.Equal(t, "こんにちは", state.Translation)
+	assert.Equal(t, int32(1), secondaryCalls.Load())
+	assert.Equal(t, map[string]string{StageTranslate: "libre"}, state.result(nil).ServedBy)
+	assert.Equal(t, map[string]Usage{"libre": {Characters: 5}}, state.Usage, "Only the serving provider is billed")
+}
+
+func TestTranslateStage_DoesNotFailOverOnInvalidInput(t *testing.T) {
+	ctx, secondaryCalls := fallbackProviders(t, http.StatusBadRequest)
+	state := NewPipelineState(ScribeOptions{TargetLanguage: "ja-JP"}, nil)
+	state.Transcript = "hello"
+
+	err := (&realScribeEngine{}).translateStage(ctx, state)
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+	assert.Zero(t, secondaryCalls.Load())
+	assert.Nil(t, state.ServedBy)
+}
+
+func TestGenerateDubbing_FailsOverToSecondTTSProvider(t *testing.T) {
+	fakeTools(t)
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer primary.Close()
+	var auth string
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte("mp3 audio"))
+	}))
+	defer secondary.Close()
+
+	config := DefaultConfig()
+	config.Providers = map[string]ProviderConfig{
+		ProviderOpenAI: {BaseURL: primary.URL},
+		"local-tts":    {Type: ProviderTypeOpenAI, BaseURL: secondary.URL, NoAPIKey: true},
+	}
+	config.Fallbacks = map[string][]string{CapabilityTTS: {ProviderOpenAI, "local-tts"}}
+	require.NoError(t, config.Validate())
+	ctx := WithProviders(context.Background(), config.NewProviders())
+
+	opts := ScribeOptions{VoiceModel: "alloy", APIKeys: map[string]Secret{ProviderOpenAI: "sk-test"}}
+	audioPath, by, err := (&realScribeEngine{}).generateDubbing(ctx, "hello", opts, t.TempDir())
+	require.NoError(t, err)
+	assert.FileExists(t, audioPath)
+	assert.Equal(t, "local-tts", by.provider)
+	assert.Equal(t, Usage{Characters: 5}, by.usage)
+	assert.Empty(t, auth, "No key is sent to providers configured without one")
+}
+
+func TestConfig_ValidatesFallbacks(t *testing.T) {
+	config := DefaultConfig()
+	assert.Equal(t, DefaultProviderChains(), config.NewProviders().chains)
+
+	config.Providers = map[string]ProviderConfig{"libre": {Type: ProviderTypeLibreTranslate, BaseURL: "http://localhost:5000"}}
+	for _, invalid := range []map[string][]string{
+		{"ocr": {ProviderBuiltin}},
+		{CapabilityTranslation: {}},
+		{CapabilityTranslation: {"missing"}},
+		{CapabilityTTS: {"libre"}},
+		{CapabilityTTS: {ProviderBuiltin}},
+	} {
+		config.Fallbacks = invalid
+		assert.Error(t, config.Validate(), "%v", invalid)
+	}
+
+	config.Fallbacks = map[string][]string{CapabilityTranslation: {ProviderOpenAI, "libre", ProviderBuiltin}}
+	require.NoError(t, config.Validate())
+	assert.Equal(t, []string{ProviderOpenAI, "libre", ProviderBuiltin}, config.NewProviders().Chain(CapabilityTranslation))
+
+	config.Providers["bad"] = ProviderConfig{Type: "deepl"}
+	assert.Error(t, config.Validate())
+
+	// Default chains are checked too, e.g. when a custom provider type
+	// replaces a provider they name
+	config = DefaultConfig()
+	config.Providers = map[string]ProviderConfig{ProviderOpenAI: {Type: ProviderTypeLibreTranslate}}
+	assert.ErrorContains(t, config.Validate(), "does not offer "+CapabilityTTS)
+
+	config.Fallbacks = map[string][]string{CapabilityTTS: {"local-tts"}}
+	config.Providers["local-tts"] = ProviderConfig{Type: ProviderTypeOpenAI, BaseURL: "http://localhost:8880"}
+	assert.NoError(t, config.Validate(), "Overriding the default chain fixes it")
+}
@@ -0,0 +1,264 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Command hook events.
+const (
+	HookPostDownload   = "post_download"   // After a URL input has been downloaded
+	HookPostTranscribe = "post_transcribe" // After the transcript is available, from transcription or platform captions
+	HookPostTranslate  = "post_translate"  // After the transcript has been translated
+	HookJobComplete    = "job_complete"    // After a job has succeeded, failed or been cancelled
+)
+
+// Command hook failure policies.
+const (
+	HookFailureContinue = "continue" // Log the failure and carry on (default)
+	HookFailureFail     = "fail"     // Fail the job; not allowed for job_complete
+)
+
+// DefaultHookTimeout is how long a command hook may run when no timeout is configured.
+const DefaultHookTimeout = time.Minute
+
+// CommandHook is an external command run at a pipeline event, e.g. a script
+// that uploads results to a CMS. The command is not run through a shell; use
+// ["sh", "-c", "..."] for shell syntax.
+//
+// The command runs in the job's output directory. It receives the job's
+// context as AKASHIC_* environment variables and as a HookPayload JSON
+// document on stdin.
+type CommandHook struct {
+	Event          string   `json:"event"`                     // One of the Hook* events
+	Command        []string `json:"command"`                   // Program and arguments
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"` // 0 = DefaultHookTimeout
+	OnFailure      string   `json:"on_failure,omitempty"`      // HookFailureContinue or HookFailureFail (empty = continue)
+}
+
+// Validate checks the hook's event, command, timeout and failure policy.
+func (h CommandHook) Validate() error {
+	switch h.Event {
+	case HookPostDownload, HookPostTranscribe, HookPostTranslate, HookJobComplete:
+	default:
+		return fmt.Errorf("unknown hook event %q", h.Event)
+	}
+	if len(h.Command) == 0 || h.Command[0] == "" {
+		return fmt.Errorf("%s hook has no command", h.Event)
+	}
+	if h.TimeoutSeconds < 0 {
+		return fmt.Errorf("%s hook timeout cannot be negative, got %d", h.Event, h.TimeoutSeconds)
+	}
+	switch h.OnFailure {
+	case "", HookFailureContinue:
+	case HookFailureFail:
+		if h.Event == HookJobComplete {
+			return fmt.Errorf("%s hooks cannot fail the job, which has already finished", h.Event)
+		}
+	default:
+		return fmt.Errorf("unknown hook failure policy %q", h.OnFailure)
+	}
+	return nil
+}
+
+// timeout returns the hook's time limit.
+func (h CommandHook) timeout() time.Duration {
+	if h.TimeoutSeconds <= 0 {
+		return DefaultHookTimeout
+	}
+	return time.Duration(h.TimeoutSeconds) * time.Second
+}
+
+// HookPayload is the JSON document written to a command hook's stdin.
+type HookPayload struct {
+	Event            string            `json:"event"`
+	JobID            string            `json:"job_id,omitempty"`
+	Timestamp        time.Time         `json:"timestamp"`
+	Options          ScribeOptions     `json:"options"`
+	OutputDir        string            `json:"output_dir"`
+	MediaPath        string            `json:"media_path,omitempty"`
+	Transcript       string            `json:"transcript,omitempty"`
+	Translation      string            `json:"translation,omitempty"`
+	TranscriptSource string            `json:"transcript_source,omitempty"`
+	DetectedLanguage string            `json:"detected_language,omitempty"`
+	Artifacts        map[string]string `json:"artifacts,omitempty"`
+
+	// Set for job_complete only
+	Status     string        `json:"status,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	ErrorClass string        `json:"error_class,omitempty"`
+	Result     *ScribeResult `json:"result,omitempty"`
+}
+
+// newHookPayload describes a pipeline state for the given event.
+func newHookPayload(event, jobID string, state *PipelineState) HookPayload {
+	return HookPayload{
+		Event:            event,
+		JobID:            jobID,
+		Timestamp:        time.Now(),
+		Options:          state.Options,
+		OutputDir:        state.OutputDir,
+		MediaPath:        state.MediaPath,
+		Transcript:       state.Transcript,
+		Translation:      state.Translation,
+		TranscriptSource: state.TranscriptSource,
+		DetectedLanguage: state.DetectedLanguage,
+		Artifacts:        state.Artifacts,
+	}
+}
+
+// runCommandHook runs hook with payload on stdin and env added to the
+// environment, and applies its failure policy. Only failures of "fail"
+// hooks and cancellation of ctx are returned.
+func runCommandHook(ctx context.Context, hook CommandHook, payload HookPayload, env []string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s hook payload: %w", hook.Event, err)
+	}
+
+	hookCtx, cancel := context.WithTimeout(ctx, hook.timeout())
+	defer cancel()
+
+	cmd := newCommand(hookCtx, hook.Command[0], hook.Command[1:]...)
+	if info, err := os.Stat(payload.OutputDir); err == nil && info.IsDir() {
+		cmd.Dir = payload.OutputDir
+	}
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Env = append(cmd.Env, "AKASHIC_EVENT="+hook.Event, "AKASHIC_JOB_ID="+payload.JobID)
+	cmd.Stdin = bytes.NewReader(body)
+
+	logger := LoggerFrom(ctx).With("hook", hook.Event, "command", hook.Command[0])
+	logCommand(ctx, cmd)
+	output, err := cmd.CombinedOutput()
+	logToolOutput(ctx, cmd, output)
+	switch {
+	case err == nil:
+		logger.Info("Hook finished")
+		return nil
+	case ctx.Err() != nil:
+		return fmt.Errorf("operation cancelled: %w", ctx.Err())
+	case errors.Is(hookCtx.Err(), context.DeadlineExceeded):
+		err = fmt.Errorf("%s hook %s timed out after %s", hook.Event, hook.Command[0], hook.timeout())
+	default:
+		err = fmt.Errorf("%s hook %s failed: %w: %s", hook.Event, hook.Command[0], err, lastLines(string(output), 5))
+	}
+
+	if hook.OnFailure == HookFailureFail {
+		return err
+	}
+	logger.Warn("Hook failed; continuing", "error", err)
+	return nil
+}
+
+// jobHooks are the command hooks attached to a job's context.
+type jobHooks struct {
+	jobID string
+	hooks []CommandHook
+}
+
+// commandHooksKey is the context key for the command hooks attached to a job.
+type commandHooksKey struct{}
+
+// WithCommandHooks returns a context under which pipeline runs execute the
+// post_download, post_transcribe and post_translate hooks among hooks.
+// jobID is passed to the hooks. BatchProcessor attaches its hooks this way;
+// direct callers of ProcessWithContext can do the same, and run the
+// job_complete hooks with RunJobCompleteHooks.
+func WithCommandHooks(ctx context.Context, jobID string, hooks []CommandHook) context.Context {
+	return context.WithValue(ctx, commandHooksKey{}, jobHooks{jobID: jobID, hooks: hooks})
+}
+
+// hookStages maps stage events to the stages they follow.
+var hookStages = map[string][]string{
+	HookPostDownload:   {StageDownload},
+	HookPostTranscribe: {StageCaptions, StageTranscribe},
+	HookPostTranslate:  {StageTranslate},
+}
+
+// attachCommandHooks registers the command hooks attached to ctx as after
+// hooks on p. Events whose stages p does not have are ignored.
+func attachCommandHooks(ctx context.Context, p *Pipeline) {
+	attached, _ := ctx.Value(commandHooksKey{}).(jobHooks)
+	for _, hook := range attached.hooks {
+		hook := hook
+		for _, stage := range hookStages[hook.Event] {
+			if p.index(stage) < 0 {
+				continue
+			}
+			p.After(stage, func(ctx context.Context, stage string, state *PipelineState) error {
+				// The captions stage only produces the transcript when captions were found
+				if stage == StageCaptions && state.Captions == nil {
+					return nil
+				}
+				return runCommandHook(ctx, hook, newHookPayload(hook.Event, attached.jobID, state), stateEnv(state))
+			})
+		}
+	}
+}
+
+// RunJobCompleteHooks runs the job_complete hooks among hooks for a finished
+// job. They get their own timeouts so they still run for cancelled jobs and
+// during shutdown. BatchProcessor runs them for its jobs; callers of
+// ProcessWithContext, such as the GUI, call it after each job.
+func RunJobCompleteHooks(hooks []CommandHook, job BatchJob) {
+	var payload HookPayload
+	var env []string
+	for _, hook := range hooks {
+		if hook.Event != HookJobComplete {
+			continue
+		}
+		if payload.Event == "" {
+			payload, env = newJobCompletePayload(job)
+		}
+		runCommandHook(context.Background(), hook, payload, env)
+	}
+}
+
+// newJobCompletePayload describes a finished job as a hook payload and
+// environment variables.
+func newJobCompletePayload(job BatchJob) (HookPayload, []string) {
+	state := NewPipelineState(job.Options, nil)
+	if result := job.Result; result != nil {
+		state.OutputDir = result.OutputDir
+		state.Transcript = result.Transcription
+		state.Translation = result.Translation
+		state.TranscriptSource = result.TranscriptSource
+		state.DetectedLanguage = result.DetectedLanguage
+		if result.Artifacts != nil {
+			state.Artifacts = result.Artifacts
+		}
+	}
+
+	payload := newHookPayload(HookJobComplete, job.ID, state)
+	payload.Status = job.Status.String()
+	payload.Result = job.Result
+	err := job.Error
+	if err == nil && job.Status == JobCancelled {
+		err = job.LastError
+	}
+	if err != nil {
+		payload.Error = err.Error()
+		payload.ErrorClass = ClassifyError(err).String()
+	}
+	return payload, append(stateEnv(state), "AKASHIC_JOB_STATUS="+payload.Status)
+}
+
+// SetCommandHooks replaces the command hooks run for jobs started afterwards.
+func (bp *BatchProcessor) SetCommandHooks(hooks []CommandHook) error {
+	for i, hook := range hooks {
+		if err := hook.Validate(); err != nil {
+			return fmt.Errorf("command hook %d: %w", i+1, err)
+		}
+	}
+
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	bp.commandHooks = hooks
+	return nil
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hookRecorder writes a hook script that appends $AKASHIC_EVENT to events.log
+// and saves its stdin to <event>.json in dir. It returns the script's path.
+func hookRecorder(t *testing.T, dir string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts are shell scripts")
+	}
+
+	script := filepath.Join(dir, "record-hook")
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+echo "$AKASHIC_EVENT $AKASHIC_JOB_ID $AKASHIC_JOB_STATUS" >> "`+filepath.Join(dir, "events.log")+`"
+cat > "`+dir+`/$AKASHIC_EVENT.json"
+`), 0o755))
+	return script
+}
+
+// readHookPayload reads the payload saved by hookRecorder for event.
+func readHookPayload(t *testing.T, dir, event string) HookPayload {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, event+".json"))
+	require.NoError(t, err)
+
+	var payload HookPayload
+	require.NoError(t, json.Unmarshal(data, &payload))
+	return payload
+}
+
+func TestCommandHook_Validate(t *testing.T) {
+	assert.NoError(t, CommandHook{Event: HookPostTranslate, Command: []string{"publish"}}.Validate())
+	assert.NoError(t, CommandHook{Event: HookPostDownload, Command: []string{"scan"}, OnFailure: HookFailureFail, TimeoutSeconds: 30}.Validate())
+
+	assert.Error(t, CommandHook{Event: "pre_everything", Command: []string{"x"}}.Validate())
+	assert.Error(t, CommandHook{Event: HookPostTranslate}.Validate())
+	assert.Error(t, CommandHook{Event: HookPostTranslate, Command: []string{"x"}, TimeoutSeconds: -1}.Validate())
+	assert.Error(t, CommandHook{Event: HookPostTranslate, Command: []string{"x"}, OnFailure: "retry"}.Validate())
+	assert.Error(t, CommandHook{Event: HookJobComplete, Command: []string{"x"}, OnFailure: HookFailureFail}.Validate())
+
+	config := DefaultConfig()
+	config.CommandHooks = []CommandHook{{Event: HookJobComplete}}
+	assert.Error(t, config.Validate())
+}
+
+func TestCommandHooks_RunAfterStages(t *testing.T) {
+	fakeTools(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.mp4")
+	require.NoError(t, os.WriteFile(input, []byte("video"), 0o644))
+	script := hookRecorder(t, dir)
+
+	ctx := WithCommandHooks(context.Background(), "job-1", []CommandHook{
+		{Event: HookPostDownload, Command: []string{script}},
+		{Event: HookPostTranscribe, Command: []string{script}},
+		{Event: HookPostTranslate, Command: []string{script}},
+		{Event: HookJobComplete, Command: []string{script}},
+	})
+
+	progress, stop := drainProgress()
+	result, err := NewRealScribeEngine().ProcessWithContext(ctx, ScribeOptions{
+		InputFile:      input,
+		TargetLanguage: "ja-JP",
+		OutputDir:      filepath.Join(dir, "out"),
+	}, progress)
+	stop()
+	require.NoError(t, err)
+
+	events, err := os.ReadFile(filepath.Join(dir, "events.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "post_transcribe job-1 \npost_translate job-1 \n", string(events),
+		"Local files are not downloaded, and job_complete is run by BatchProcessor")
+
+	payload := readHookPayload(t, dir, HookPostTranslate)
+	assert.Equal(t, "job-1", payload.JobID)
+	assert.Equal(t, result.Transcription, payload.Transcript)
+	assert.Equal(t, result.Translation, payload.Translation)
+	assert.Equal(t, filepath.Join(dir, "out"), payload.OutputDir)
+	assert.Equal(t, "ja-JP", payload.Options.TargetLanguage)
+}
+
+func TestCommandHooks_FailurePolicies(t *testing.T) {
+	fakeTools(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.mp4")
+	require.NoError(t, os.WriteFile(input, []byte("video"), 0o644))
+
+	process := func(hook CommandHook) error {
+		ctx := WithCommandHooks(context.Background(), "job", []CommandHook{hook})
+		progress, stop := drainProgress()
+		defer stop()
+		_, err := NewRealScribeEngine().ProcessWithContext(ctx, ScribeOptions{
+			InputFile:      input,
+			TargetLanguage: "ja-JP",
+			OutputDir:      dir,
+		}, progress)
+		return err
+	}
+
+	failing := []string{"sh", "-c", "echo cms unavailable >&2; exit 3"}
+	assert.NoError(t, process(CommandHook{Event: HookPostTranscribe, Command: failing}),
+		"Failures are logged by default")
+
+	err := process(CommandHook{Event: HookPostTranscribe, Command: failing, OnFailure: HookFailureFail})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "post_transcribe hook sh failed")
+	assert.Contains(t, err.Error(), "cms unavailable")
+
+	err = process(CommandHook{Event: HookPostTranslate, Command: []string{"sleep", "10"}, TimeoutSeconds: 1, OnFailure: HookFailureFail})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out after 1s")
+}
+
+func TestCommandHooks_JobComplete(t *testing.T) {
+	dir := t.TempDir()
+	script := hookRecorder(t, dir)
+
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	require.NoError(t, bp.SetCommandHooks([]CommandHook{{Event: HookJobComplete, Command: []string{script}}}))
+
+	jobID := bp.AddJob(ScribeOptions{InputFile: "a.mp4", TargetLanguage: "Spanish"})
+	bp.Wait()
+
+	events, err := os.ReadFile(filepath.Join(dir, "events.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "job_complete "+jobID+" Completed\n", string(events))
+
+	payload := readHookPayload(t, dir, HookJobComplete)
+	assert.Equal(t, jobID, payload.JobID)
+	assert.Equal(t, "Completed", payload.Status)
+	require.NotNil(t, payload.Result)
+	assert.Contains(t, payload.Result.Translation, "Spanish")
+
+	assert.Error(t, bp.SetCommandHooks([]CommandHook{{Event: HookJobComplete}}))
+}
+
+func TestCommandHooks_JobCompleteRunsForQueuedCancellations(t *testing.T) {
+	dir := t.TempDir()
+	script := hookRecorder(t, dir)
+
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	require.NoError(t, bp.SetCommandHooks([]CommandHook{{Event: HookJobComplete, Command: []string{script}}}))
+
+	bp.Pause()
+	jobID := bp.AddJob(ScribeOptions{InputFile: "a.mp4"})
+	require.NoError(t, bp.CancelJob(jobID))
+	bp.Resume()
+	bp.Wait()
+
+	events, err := os.ReadFile(filepath.Join(dir, "events.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "job_complete "+jobID+" Cancelled\n", string(events))
+}
+
+func TestCommandHooks_JobCompleteDoesNotHoldSlot(t *testing.T) {
+	dir := t.TempDir()
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts are shell scripts")
+	}
+	release := filepath.Join(dir, "release")
+	script := filepath.Join(dir, "wait-hook")
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+while [ ! -f "`+release+`" ]; do sleep 0.05; done
+`), 0o755))
+
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	require.NoError(t, bp.SetCommandHooks([]CommandHook{{Event: HookJobComplete, Command: []string{script}, TimeoutSeconds: 10}}))
+
+	bp.AddJob(ScribeOptions{InputFile: "a.mp4", TargetLanguage: "Spanish"})
+	second := bp.AddJob(ScribeOptions{InputFile: "b.mp4", TargetLanguage: "Spanish"})
+	assert.Eventually(t, func() bool {
+		job, _ := bp.JobSnapshot(second)
+		return job.Status != JobPending
+	}, 5*time.Second, 10*time.Millisecond, "The second job should start while the first job's hook runs")
+
+	require.NoError(t, os.WriteFile(release, nil, 0o644))
+	bp.Wait()
+}
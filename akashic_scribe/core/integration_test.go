@@ -2,7 +2,6 @@ package core
 
 import (
 	"context"
-	"encoding/json"
 	"testing"
 	"time"
 
@@ -98,7 +97,7 @@ func (suite *IntegrationTestSuite) TestProgressReportingIntegration() {
 
 	// Collect progress updates
 	var updates []ProgressUpdate
-	var finalResult string
+	var finalResult *ScribeResult
 
 	timeout := time.After(10 * time.Second)
 
@@ -115,11 +114,9 @@ func (suite *IntegrationTestSuite) TestProgressReportingIntegration() {
 			updates = append(updates, update)
 			suite.T().Logf("Progress Update: %.1f%% - %s", update.Percentage*100, update.Message)
 
-			// Check for final result JSON
-			if update.Percentage >= 1.0 && len(update.Message) > 0 {
-				if idx := len("Scribing complete.\n"); len(update.Message) > idx {
-					finalResult = update.Message[idx:]
-				}
+			// The final update carries the result
+			if update.Result != nil {
+				finalResult = update.Result
 			}
 
 		case <-timeout:
@@ -140,17 +137,10 @@ ProcessingFinished:
 	assert.Equal(1.0, finalUpdate.Percentage, "Final update should be 100%")
 	assert.Contains(finalUpdate.Message, "Scribing complete", "Final message should indicate completion")
 
-	// Verify result JSON structure if present
-	if finalResult != "" {
-		var result struct {
-			Transcription string `json:"Transcription"`
-			Translation   string `json:"Translation"`
-		}
-
-		err := json.Unmarshal([]byte(finalResult), &result)
-		assert.NoError(err, "Final result should be valid JSON")
-		assert.NotEmpty(result.Transcription, "Result should include transcription")
-		assert.NotEmpty(result.Translation, "Result should include translation")
+	// Verify the result delivered with the final update
+	if assert.NotNil(finalResult, "Final update should carry the result") {
+		assert.NotEmpty(finalResult.Transcription, "Result should include transcription")
+		assert.NotEmpty(finalResult.Translation, "Result should include translation")
 	}
 
 	suite.T().Log("✅ Progress reporting integration test passed")
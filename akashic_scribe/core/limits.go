@@ -0,0 +1,224 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Resource identifies a shared resource whose concurrent use can be limited.
+type Resource string
+
+const (
+	ResourceDownload Resource = "download" // yt-dlp downloads (network-bound)
+	ResourceFFmpeg   Resource = "ffmpeg"   // ffmpeg processes (CPU-bound)
+)
+
+// ResourceLimits configures per-resource concurrency. Zero or negative values
+// mean unlimited. Provider requests are limited by ConfigureProviderLimits.
+type ResourceLimits struct {
+	MaxConcurrentDownloads int // Concurrent yt-dlp downloads
+	MaxConcurrentFFmpeg    int // Concurrent ffmpeg processes
+}
+
+// ResourceLimiter enforces ResourceLimits across all jobs that share it.
+// Limits can be changed at runtime.
+type ResourceLimiter struct {
+	mu         sync.Mutex
+	semaphores map[Resource]*resourceSemaphore
+}
+
+// NewResourceLimiter creates a limiter enforcing the given limits.
+func NewResourceLimiter(limits ResourceLimits) *ResourceLimiter {
+	rl := &ResourceLimiter{semaphores: make(map[Resource]*resourceSemaphore)}
+	rl.SetLimit(ResourceDownload, limits.MaxConcurrentDownloads)
+	rl.SetLimit(ResourceFFmpeg, limits.MaxConcurrentFFmpeg)
+	return rl
+}
+
+// SetLimit changes the concurrency limit for a resource (0 = unlimited).
+// Lowering a limit never interrupts holders; it only delays new acquisitions.
+func (rl *ResourceLimiter) SetLimit(resource Resource, limit int) {
+	rl.semaphore(resource).setLimit(limit)
+}
+
+// Limit returns the current concurrency limit for a resource.
+func (rl *ResourceLimiter) Limit(resource Resource) int {
+	return rl.semaphore(resource).currentLimit()
+}
+
+// Acquire blocks until a slot for resource is free or ctx is done.
+// The returned function releases the slot and must be called exactly once.
+func (rl *ResourceLimiter) Acquire(ctx context.Context, resource Resource) (func(), error) {
+	sem := rl.semaphore(resource)
+	if err := sem.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() { once.Do(sem.release) }, nil
+}
+
+// semaphore returns the semaphore for resource, creating an unlimited one if needed.
+func (rl *ResourceLimiter) semaphore(resource Resource) *resourceSemaphore {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	sem, ok := rl.semaphores[resource]
+	if !ok {
+		sem = newResourceSemaphore(0)
+		rl.semaphores[resource] = sem
+	}
+	return sem
+}
+
+// resourceLimiterKey is the context key for the ResourceLimiter of a job.
+type resourceLimiterKey struct{}
+
+// WithResourceLimiter returns a context whose pipeline stages are subject to rl.
+func WithResourceLimiter(ctx context.Context, rl *ResourceLimiter) context.Context {
+	return context.WithValue(ctx, resourceLimiterKey{}, rl)
+}
+
+// AcquireResource acquires a slot for resource from the limiter attached to ctx.
+// Without a limiter it returns immediately. The returned function releases the slot.
+func AcquireResource(ctx context.Context, resource Resource) (func(), error) {
+	rl, _ := ctx.Value(resourceLimiterKey{}).(*ResourceLimiter)
+	if rl == nil {
+		return func() {}, nil
+	}
+
+	release, err := rl.Acquire(ctx, resource)
+	if err != nil {
+		return nil, fmt.Errorf("operation cancelled while waiting for %s slot: %w", resource, err)
+	}
+	return release, nil
+}
+
+// resourceSemaphore is a counting semaphore whose limit can change at runtime.
+type resourceSemaphore struct {
+	mu      sync.Mutex
+	limit   int           // Maximum holders (<= 0 = unlimited)
+	inUse   int           // Current holders
+	changed chan struct{} // Closed whenever a slot frees up or the limit changes
+}
+
+// newResourceSemaphore creates a semaphore with the given limit.
+func newResourceSemaphore(limit int) *resourceSemaphore {
+	return &resourceSemaphore{limit: limit, changed: make(chan struct{})}
+}
+
+// acquire blocks until a slot is free or ctx is done.
+func (s *resourceSemaphore) acquire(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		if s.limit <= 0 || s.inUse < s.limit {
+			s.inUse++
+			s.mu.Unlock()
+			return nil
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release frees a slot.
+func (s *resourceSemaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inUse--
+	s.notifyLocked()
+}
+
+// setLimit changes the limit and wakes waiters.
+func (s *resourceSemaphore) setLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.limit = limit
+	s.notifyLocked()
+}
+
+// currentLimit returns the current limit.
+func (s *resourceSemaphore) currentLimit() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.limit
+}
+
+// notifyLocked wakes all waiters. Callers must hold s.mu.
+func (s *resourceSemaphore) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// tokenBucket is a token-bucket rate limiter holding up to capacity tokens,
+// refilled continuously at capacity tokens per period.
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	rate     float64 // Tokens per second
+	tokens   float64
+	last     time.Time
+}
+
+// newTokenBucket creates a full bucket allowing capacity tokens per period.
+func newTokenBucket(capacity float64, period time.Duration) *tokenBucket {
+	return &tokenBucket{
+		capacity: capacity,
+		rate:     capacity / period.Seconds(),
+		tokens:   capacity,
+		last:     time.Now(),
+	}
+}
+
+// reserve takes n tokens (going into debt if needed) and returns how long the
+// caller must wait before proceeding.
+func (b *tokenBucket) reserve(n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// wait blocks until n tokens are available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context, n float64) error {
+	delay := b.reserve(n)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reservation back so cancelled callers don't starve others
+		b.mu.Lock()
+		b.tokens += n
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceLimiter_LimitsConcurrency(t *testing.T) {
+	rl := NewResourceLimiter(ResourceLimits{MaxConcurrentFFmpeg: 2})
+	ctx := WithResourceLimiter(context.Background(), rl)
+
+	var current, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := AcquireResource(ctx, ResourceFFmpeg)
+			require.NoError(t, err)
+			defer release()
+
+			n := current.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			current.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestResourceLimiter_SetLimitWakesWaiters(t *testing.T) {
+	rl := NewResourceLimiter(ResourceLimits{MaxConcurrentDownloads: 1})
+
+	release, err := rl.Acquire(context.Background(), ResourceDownload)
+	require.NoError(t, err)
+	defer release()
+
+	acquired := make(chan struct{})
+	go func() {
+		second, err := rl.Acquire(context.Background(), ResourceDownload)
+		if err == nil {
+			defer second()
+			close(acquired)
+		}
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Second download should wait while the limit is 1")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	rl.SetLimit(ResourceDownload, 2)
+	assert.Equal(t, 2, rl.Limit(ResourceDownload))
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Raising the limit should admit the waiting download")
+	}
+}
+
+func TestResourceLimiter_AcquireCancelled(t *testing.T) {
+	rl := NewResourceLimiter(ResourceLimits{MaxConcurrentFFmpeg: 1})
+	release, err := rl.Acquire(context.Background(), ResourceFFmpeg)
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(WithResourceLimiter(context.Background(), rl), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = AcquireResource(ctx, ResourceFFmpeg)
+	assert.Error(t, err)
+	assert.Equal(t, ErrorClassTransient, ClassifyError(err), "Timeouts waiting for a slot are transient")
+}
+
+func TestResourceLimiter_Unlimited(t *testing.T) {
+	// Without a limiter in the context acquisitions never block
+	release, err := AcquireResource(context.Background(), ResourceDownload)
+	require.NoError(t, err)
+	release()
+
+	rl := NewResourceLimiter(ResourceLimits{})
+	for i := 0; i < 100; i++ {
+		_, err := rl.Acquire(context.Background(), ResourceFFmpeg)
+		require.NoError(t, err)
+	}
+}
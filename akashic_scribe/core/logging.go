@@ -0,0 +1,203 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Log levels accepted by Config.LogLevel.
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// JobLogFileName is the name of the log file written to each job's output directory.
+const JobLogFileName = "scribe.log"
+
+// ParseLogLevel converts a Config.LogLevel value into a slog.Level.
+// An empty string selects LogLevelInfo.
+func ParseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case LogLevelDebug:
+		return slog.LevelDebug, nil
+	case "", LogLevelInfo:
+		return slog.LevelInfo, nil
+	case LogLevelWarn, "warning":
+		return slog.LevelWarn, nil
+	case LogLevelError:
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q (must be debug, info, warn or error)", level)
+	}
+}
+
+// NewLogger creates a logger that writes text records at or above level to w.
+// Applications install it with slog.SetDefault; the package logs through
+// slog.Default, so standard library log output is captured as well.
+func NewLogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}))
+}
+
+// loggerKey is the context key for the logger attached to a job.
+type loggerKey struct{}
+
+// WithLogger returns a context carrying logger. Engines log through the
+// logger attached to their context, so attributes such as the job ID appear
+// on every record of the job.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFrom returns the logger attached to ctx, or slog.Default.
+func LoggerFrom(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// componentLogger returns the default logger tagged with a component name.
+func componentLogger(component string) *slog.Logger {
+	return slog.Default().With("component", component)
+}
+
+// teeHandler sends each record to several handlers.
+type teeHandler []slog.Handler
+
+// Enabled reports whether any handler handles records at level.
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes the record to every handler that is enabled for its level.
+func (t teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var firstErr error
+	for _, h := range t {
+		if !h.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, record.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WithAttrs returns a teeHandler whose handlers all have attrs.
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(teeHandler, len(t))
+	for i, h := range t {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+// WithGroup returns a teeHandler whose handlers all use the group.
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make(teeHandler, len(t))
+	for i, h := range t {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+// jobLog is the log file in a job's output directory. It receives the job's
+// log records at every level as well as the output of external tools.
+type jobLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// openJobLog creates or appends to the job log in outputDir and returns a
+// context whose logger also writes to it. Records in the file carry the
+// attributes added from then on, such as the stage, but not those already on
+// the context's logger: the file belongs to a single job.
+func openJobLog(ctx context.Context, outputDir string) (*jobLog, context.Context, error) {
+	file, err := os.OpenFile(filepath.Join(outputDir, JobLogFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, ctx, fmt.Errorf("failed to open job log: %w", err)
+	}
+
+	l := &jobLog{file: file}
+	fileHandler := slog.NewTextHandler(l, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: redactAttr})
+	logger := slog.New(teeHandler{LoggerFrom(ctx).Handler(), fileHandler})
+
+	ctx = WithLogger(ctx, logger)
+	ctx = context.WithValue(ctx, toolOutputKey{}, l)
+	return l, ctx, nil
+}
+
+// Write appends p to the log file. It is safe for concurrent use.
+func (l *jobLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Write(p)
+}
+
+// Close closes the log file.
+func (l *jobLog) Close() error {
+	return l.file.Close()
+}
+
+// toolOutputKey is the context key for the writer that receives external tool output.
+type toolOutputKey struct{}
+
+// toolOutput returns the writer for external tool output attached to ctx, or nil.
+func toolOutput(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(toolOutputKey{}).(io.Writer)
+	return w
+}
+
+// logCommand records that cmd is about to run, in the job log if ctx has
+// one and at debug level.
+func logCommand(ctx context.Context, cmd *exec.Cmd) {
+	LoggerFrom(ctx).Debug("Running command", "command", strings.Join(cmd.Args, " "))
+}
+
+// runLoggedCommand runs cmd to completion and copies its combined output
+// into the job log attached to ctx.
+func runLoggedCommand(ctx context.Context, cmd *exec.Cmd) error {
+	logCommand(ctx, cmd)
+	output, err := cmd.CombinedOutput()
+	logToolOutput(ctx, cmd, output)
+	return err
+}
+
+// logToolOutput copies the output of a finished command into the job log
+// attached to ctx, if any.
+func logToolOutput(ctx context.Context, cmd *exec.Cmd, output []byte) {
+	w := toolOutput(ctx)
+	if w == nil || len(output) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	name := filepath.Base(cmd.Path)
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		b.WriteString(name + "> " + line + "\n")
+	}
+	io.WriteString(w, b.String())
+}
+
+// logToolLine copies one line of a running command's output into the job
+// log attached to ctx, if any.
+func logToolLine(ctx context.Context, cmd *exec.Cmd, line string) {
+	if w := toolOutput(ctx); w != nil {
+		io.WriteString(w, filepath.Base(cmd.Path)+"> "+line+"\n")
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	cases := map[string]slog.Level{
+		"":        slog.LevelInfo,
+		"debug":   slog.LevelDebug,
+		"INFO":    slog.LevelInfo,
+		"warn":    slog.LevelWarn,
+		"warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+	}
+	for input, want := range cases {
+		level, err := ParseLogLevel(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, level, input)
+	}
+
+	_, err := ParseLogLevel("verbose")
+	assert.Error(t, err)
+
+	config := DefaultConfig()
+	config.LogLevel = "verbose"
+	assert.Error(t, config.Validate())
+	config.LogLevel = LogLevelDebug
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, slog.LevelDebug, config.SlogLevel())
+}
+
+func TestTeeHandler(t *testing.T) {
+	var info, debug bytes.Buffer
+	logger := slog.New(teeHandler{
+		slog.NewTextHandler(&info, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		slog.NewTextHandler(&debug, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	}).With("job_id", "job-1")
+
+	logger.Debug("details")
+	logger.Info("started", "stage", StageDownload)
+
+	assert.NotContains(t, info.String(), "details")
+	assert.Contains(t, info.String(), "msg=started job_id=job-1 stage=download")
+	assert.Contains(t, debug.String(), "msg=details job_id=job-1")
+	assert.Contains(t, debug.String(), "msg=started job_id=job-1 stage=download")
+}
+
+func TestJobLog_CapturesStagesAndToolOutput(t *testing.T) {
+	fakeTools(t)
+	dir := t.TempDir()
+
+	var console bytes.Buffer
+	ctx := WithLogger(context.Background(), NewLogger(&console, slog.LevelInfo).With("job_id", "job-7"))
+
+	progress, stop := drainProgress()
+	_, err := NewRealScribeEngine().ProcessWithContext(ctx, ScribeOptions{
+		InputURL:       "https://example.com/watch?v=talk",
+		TargetLanguage: "ja-JP",
+		OutputDir:      dir,
+	}, progress)
+	stop()
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, JobLogFileName))
+	require.NoError(t, err)
+	jobLog := string(data)
+
+	assert.Contains(t, jobLog, `msg="Processing started" pipeline=default`)
+	assert.Contains(t, jobLog, `msg="Stage finished" stage=download`)
+	assert.Contains(t, jobLog, `msg="Running command" stage=download command="yt-dlp`)
+	assert.Contains(t, jobLog, "yt-dlp> [download] 100.0% of 1.00MiB\n")
+	assert.Contains(t, jobLog, `msg="Stage finished" stage=transcribe`)
+	assert.Contains(t, jobLog, `msg="Processing finished"`)
+
+	assert.Contains(t, console.String(), `msg="Processing finished" job_id=job-7`)
+	assert.NotContains(t, console.String(), "yt-dlp>", "tool output only goes to the job log")
+	assert.NotContains(t, console.String(), "Running command", "debug records only go to the job log")
+}
@@ -0,0 +1,267 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric names exported by Metrics.
+const (
+	MetricStageDuration    = "akashic_stage_duration_seconds"            // Histogram by pipeline, stage and status
+	MetricJobs             = "akashic_jobs_total"                        // Counter of finished batch jobs by status
+	MetricJobDuration      = "akashic_job_duration_seconds"              // Histogram of batch job run time by status
+	MetricProviderRequests = "akashic_provider_requests_total"           // Counter of provider API requests by provider and status
+	MetricProviderLatency  = "akashic_provider_request_duration_seconds" // Histogram of provider API latency by provider
+	MetricCacheLookups     = "akashic_cache_lookups_total"               // Counter of cache lookups by cache and result (hit or miss)
+)
+
+// Metric status label values.
+const (
+	MetricStatusOK        = "ok"
+	MetricStatusError     = "error"
+	MetricStatusCancelled = "cancelled"
+)
+
+// metricStatus returns the status label for an operation that ended with err.
+func metricStatus(err error) string {
+	switch {
+	case err == nil:
+		return MetricStatusOK
+	case ClassifyError(err) == ErrorClassCancelled:
+		return MetricStatusCancelled
+	default:
+		return MetricStatusError
+	}
+}
+
+// Histogram bucket upper bounds, in seconds.
+var (
+	stageDurationBuckets   = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600}
+	providerLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+)
+
+// Metrics collects counters and histograms about jobs, stages, provider
+// requests and caches, and writes them in the Prometheus text format. It is
+// safe for concurrent use.
+type Metrics struct {
+	mu       sync.Mutex
+	families map[string]*metricFamily
+}
+
+// metricFamily is a named metric with one series per label combination.
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string    // "counter" or "histogram"
+	labels  []string  // Label names
+	buckets []float64 // Histogram bucket upper bounds
+	series  map[string]*metricSeries
+}
+
+// metricSeries holds the value of one label combination.
+type metricSeries struct {
+	labelValues []string
+	value       float64  // Counter value, or histogram sum
+	count       uint64   // Histogram observation count
+	buckets     []uint64 // Histogram observations per bucket, not cumulative
+}
+
+// NewMetrics creates an empty metrics registry.
+func NewMetrics() *Metrics {
+	m := &Metrics{families: make(map[string]*metricFamily)}
+	m.define(MetricStageDuration, "histogram", "Duration of pipeline stages in seconds.", stageDurationBuckets, "pipeline", "stage", "status")
+	m.define(MetricJobs, "counter", "Finished batch jobs.", nil, "status")
+	m.define(MetricJobDuration, "histogram", "Run time of batch jobs in seconds, including retries.", stageDurationBuckets, "status")
+	m.define(MetricProviderRequests, "counter", "Provider API requests.", nil, "provider", "status")
+	m.define(MetricProviderLatency, "histogram", "Latency of provider API requests in seconds.", providerLatencyBuckets, "provider")
+	m.define(MetricCacheLookups, "counter", "Cache lookups.", nil, "cache", "result")
+	return m
+}
+
+// defaultMetrics receives the metrics recorded by the engine and BatchProcessor.
+var defaultMetrics = NewMetrics()
+
+// DefaultMetrics returns the registry the package records its metrics in.
+func DefaultMetrics() *Metrics {
+	return defaultMetrics
+}
+
+// define registers a metric family.
+func (m *Metrics) define(name, kind, help string, buckets []float64, labels ...string) {
+	m.families[name] = &metricFamily{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*metricSeries),
+	}
+}
+
+// seriesLocked returns the series of a family for the given label values,
+// creating it if needed. The caller must hold m.mu.
+func (m *Metrics) seriesLocked(name string, labelValues ...string) *metricSeries {
+	family := m.families[name]
+	key := strings.Join(labelValues, "\xff")
+	s, ok := family.series[key]
+	if !ok {
+		s = &metricSeries{labelValues: labelValues}
+		if family.kind == "histogram" {
+			s.buckets = make([]uint64, len(family.buckets))
+		}
+		family.series[key] = s
+	}
+	return s
+}
+
+// add increments a counter.
+func (m *Metrics) add(name string, delta float64, labelValues ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.seriesLocked(name, labelValues...).value += delta
+}
+
+// observe records a histogram observation.
+func (m *Metrics) observe(name string, value float64, labelValues ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.seriesLocked(name, labelValues...)
+	s.value += value
+	s.count++
+	for i, bound := range m.families[name].buckets {
+		if value <= bound {
+			s.buckets[i]++
+			break
+		}
+	}
+}
+
+// ObserveStage records the duration of a pipeline stage. status is one of
+// the MetricStatus* values.
+func (m *Metrics) ObserveStage(pipeline, stage, status string, d time.Duration) {
+	m.observe(MetricStageDuration, d.Seconds(), pipeline, stage, status)
+}
+
+// RecordJob records a finished batch job with its status, e.g. "completed",
+// and its run time.
+func (m *Metrics) RecordJob(status string, d time.Duration) {
+	m.add(MetricJobs, 1, status)
+	m.observe(MetricJobDuration, d.Seconds(), status)
+}
+
+// ObserveProviderRequest records the latency and outcome of a provider API request.
+func (m *Metrics) ObserveProviderRequest(provider string, d time.Duration, err error) {
+	m.add(MetricProviderRequests, 1, provider, metricStatus(err))
+	m.observe(MetricProviderLatency, d.Seconds(), provider)
+}
+
+// RecordCacheLookup records a cache hit or miss.
+func (m *Metrics) RecordCacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.add(MetricCacheLookups, 1, cache, result)
+}
+
+// value returns a counter's value, or a histogram's observation count.
+func (m *Metrics) value(name string, labelValues ...string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.families[name].series[strings.Join(labelValues, "\xff")]
+	switch {
+	case !ok:
+		return 0
+	case m.families[name].kind == "histogram":
+		return float64(s.count)
+	default:
+		return s.value
+	}
+}
+
+// WritePrometheus writes all metrics in the Prometheus text exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		family := m.families[name]
+		fmt.Fprintf(bw, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", family.name, family.kind)
+
+		keys := make([]string, 0, len(family.series))
+		for key := range family.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := family.series[key]
+			labels := formatLabels(family.labels, s.labelValues)
+			if family.kind == "counter" {
+				fmt.Fprintf(bw, "%s%s %s\n", family.name, labels, formatFloat(s.value))
+				continue
+			}
+
+			bucketLabels := append(append([]string(nil), family.labels...), "le")
+			bucketValues := append(append([]string(nil), s.labelValues...), "")
+			var cumulative uint64
+			for i, bound := range family.buckets {
+				cumulative += s.buckets[i]
+				bucketValues[len(bucketValues)-1] = formatFloat(bound)
+				fmt.Fprintf(bw, "%s_bucket%s %d\n", family.name, formatLabels(bucketLabels, bucketValues), cumulative)
+			}
+			bucketValues[len(bucketValues)-1] = "+Inf"
+			fmt.Fprintf(bw, "%s_bucket%s %d\n", family.name, formatLabels(bucketLabels, bucketValues), s.count)
+			fmt.Fprintf(bw, "%s_sum%s %s\n", family.name, labels, formatFloat(s.value))
+			fmt.Fprintf(bw, "%s_count%s %d\n", family.name, labels, s.count)
+		}
+	}
+	return bw.Flush()
+}
+
+// formatLabels formats label pairs as {name="value",...}, or "" if there are none.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name + `="` + labelEscaper.Replace(values[i]) + `"`)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// labelEscaper escapes label values for the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatFloat formats a sample value the way Prometheus expects.
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
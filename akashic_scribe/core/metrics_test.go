@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_WritePrometheus(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveStage("default", StageDownload, MetricStatusOK, 300*time.Millisecond)
+	m.ObserveStage("default", StageDownload, MetricStatusOK, 7*time.Second)
+	m.RecordJob("completed", time.Minute)
+	m.ObserveProviderRequest(ProviderOpenAI, 200*time.Millisecond, nil)
+	m.ObserveProviderRequest(ProviderOpenAI, time.Second, errors.New("boom"))
+	m.RecordCacheLookup("templates", true)
+	m.RecordCacheLookup("templates", false)
+	m.RecordCacheLookup("templates", true)
+
+	var b strings.Builder
+	require.NoError(t, m.WritePrometheus(&b))
+	out := b.String()
+
+	for _, line := range []string{
+		"# TYPE akashic_stage_duration_seconds histogram",
+		`akashic_stage_duration_seconds_bucket{pipeline="default",stage="download",status="ok",le="0.1"} 0`,
+		`akashic_stage_duration_seconds_bucket{pipeline="default",stage="download",status="ok",le="0.5"} 1`,
+		`akashic_stage_duration_seconds_bucket{pipeline="default",stage="download",status="ok",le="10"} 2`,
+		`akashic_stage_duration_seconds_bucket{pipeline="default",stage="download",status="ok",le="+Inf"} 2`,
+		`akashic_stage_duration_seconds_sum{pipeline="default",stage="download",status="ok"} 7.3`,
+		`akashic_stage_duration_seconds_count{pipeline="default",stage="download",status="ok"} 2`,
+		"# TYPE akashic_jobs_total counter",
+		`akashic_jobs_total{status="completed"} 1`,
+		`akashic_provider_requests_total{provider="openai",status="error"} 1`,
+		`akashic_provider_requests_total{provider="openai",status="ok"} 1`,
+		`akashic_provider_request_duration_seconds_count{provider="openai"} 2`,
+		`akashic_cache_lookups_total{cache="templates",result="hit"} 2`,
+		`akashic_cache_lookups_total{cache="templates",result="miss"} 1`,
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+}
+
+func TestMetrics_LabelEscaping(t *testing.T) {
+	m := NewMetrics()
+	m.RecordCacheLookup("a \"quoted\"\\path\n", true)
+
+	var b strings.Builder
+	require.NoError(t, m.WritePrometheus(&b))
+	assert.Contains(t, b.String(), `akashic_cache_lookups_total{cache="a \"quoted\"\\path\n",result="hit"} 1`)
+}
+
+func TestMetrics_RecordedByPipelineAndBatch(t *testing.T) {
+	p := NewPipeline("metrics-test")
+	require.NoError(t, p.Append(recordStage("ok")))
+	require.NoError(t, p.Append(Stage{
+		Name: "broken",
+		Run:  func(context.Context, *PipelineState) error { return errors.New("broken") },
+	}))
+
+	before := DefaultMetrics().value(MetricStageDuration, "metrics-test", "broken", MetricStatusError)
+	_, err := p.Run(context.Background(), NewPipelineState(ScribeOptions{}, nil))
+	require.Error(t, err)
+	assert.Equal(t, 1.0, DefaultMetrics().value(MetricStageDuration, "metrics-test", "ok", MetricStatusOK))
+	assert.Equal(t, before+1, DefaultMetrics().value(MetricStageDuration, "metrics-test", "broken", MetricStatusError))
+
+	completed := DefaultMetrics().value(MetricJobs, "completed")
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	defer bp.Shutdown()
+	bp.AddJob(ScribeOptions{InputFile: "a.mp4", TargetLanguage: "Spanish"})
+	bp.Wait()
+	assert.Equal(t, completed+1, DefaultMetrics().value(MetricJobs, "completed"))
+}
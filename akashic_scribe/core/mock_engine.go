@@ -16,26 +16,46 @@ func NewMockScribeEngine() *MockScribeEngine {
 
 // Transcribe simulates the transcription process.
 func (m *MockScribeEngine) Transcribe(videoSource string) (string, error) {
-	fmt.Printf("Mock Transcribe called with: %s\n", videoSource)
-	time.Sleep(200 * time.Millisecond) // Simulate work
+	return m.TranscribeContext(context.Background(), videoSource)
+}
+
+// TranscribeContext simulates the transcription process, stopping early if
+// ctx is cancelled.
+func (m *MockScribeEngine) TranscribeContext(ctx context.Context, videoSource string) (string, error) {
+	LoggerFrom(ctx).Debug("Mock Transcribe called", "input", videoSource)
+	select {
+	case <-time.After(200 * time.Millisecond): // Simulate work
+	case <-ctx.Done():
+		return "", fmt.Errorf("operation cancelled: %w", ctx.Err())
+	}
 	transcription := "This is a mock transcription of the video."
-	fmt.Println("Mock transcription finished.")
+	LoggerFrom(ctx).Debug("Mock transcription finished")
 	return transcription, nil
 }
 
 // Translate simulates the translation process.
 func (m *MockScribeEngine) Translate(text string, targetLanguage string) (string, error) {
-	fmt.Printf("Mock Translate called for language '%s' with text: %s\n", targetLanguage, text)
-	time.Sleep(100 * time.Millisecond) // Simulate work
+	return m.TranslateContext(context.Background(), text, targetLanguage)
+}
+
+// TranslateContext simulates the translation process, stopping early if ctx
+// is cancelled.
+func (m *MockScribeEngine) TranslateContext(ctx context.Context, text string, targetLanguage string) (string, error) {
+	LoggerFrom(ctx).Debug("Mock Translate called", "target_language", targetLanguage, "text", text)
+	select {
+	case <-time.After(100 * time.Millisecond): // Simulate work
+	case <-ctx.Done():
+		return "", fmt.Errorf("operation cancelled: %w", ctx.Err())
+	}
 	translation := fmt.Sprintf("This is a mock translation to '%s'.", targetLanguage)
-	fmt.Println("Mock translation finished.")
+	LoggerFrom(ctx).Debug("Mock translation finished")
 	return translation, nil
 }
 
 // StartProcessing simulates the full processing pipeline with progress reporting.
 // The operation can be cancelled via the provided context.
 func (m *MockScribeEngine) StartProcessing(ctx context.Context, options ScribeOptions, progress chan<- ProgressUpdate) error {
-	fmt.Printf("Mock StartProcessing called with options: %+v\n", options)
+	LoggerFrom(ctx).Debug("Mock StartProcessing called", "options", options)
 
 	// Check for cancellation before starting
 	select {
@@ -45,7 +65,7 @@ func (m *MockScribeEngine) StartProcessing(ctx context.Context, options ScribeOp
 	}
 
 	// Simulate the full processing pipeline with progress updates
-	progress <- ProgressUpdate{0.0, "Starting processing..."}
+	progress <- ProgressUpdate{Percentage: 0.0, Message: "Starting processing..."}
 
 	// Simulate work with cancellation check
 	select {
@@ -54,7 +74,7 @@ func (m *MockScribeEngine) StartProcessing(ctx context.Context, options ScribeOp
 		return fmt.Errorf("operation cancelled: %w", ctx.Err())
 	}
 
-	progress <- ProgressUpdate{0.2, "Transcribing audio..."}
+	progress <- ProgressUpdate{Percentage: 0.2, Message: "Transcribing audio...", Stage: StageTranscribe}
 
 	select {
 	case <-time.After(100 * time.Millisecond):
@@ -62,19 +82,17 @@ func (m *MockScribeEngine) StartProcessing(ctx context.Context, options ScribeOp
 		return fmt.Errorf("operation cancelled: %w", ctx.Err())
 	}
 
-	transcription, err := m.Transcribe(options.InputFile)
+	transcription, err := m.TranscribeContext(ctx, options.InputFile)
 	if err != nil {
 		return err
 	}
 
 	// Check for cancellation before translation
-	select {
-	case <-ctx.Done():
-		return fmt.Errorf("operation cancelled: %w", ctx.Err())
-	default:
+	if err := StageCheckpoint(ctx); err != nil {
+		return err
 	}
 
-	progress <- ProgressUpdate{0.6, "Translating text..."}
+	progress <- ProgressUpdate{Percentage: 0.6, Message: "Translating text...", Stage: StageTranslate}
 
 	select {
 	case <-time.After(100 * time.Millisecond):
@@ -82,20 +100,18 @@ func (m *MockScribeEngine) StartProcessing(ctx context.Context, options ScribeOp
 		return fmt.Errorf("operation cancelled: %w", ctx.Err())
 	}
 
-	translation, err := m.Translate(transcription, options.TargetLanguage)
+	translation, err := m.TranslateContext(ctx, transcription, options.TargetLanguage)
 	if err != nil {
 		return err
 	}
 
 	if options.CreateDubbing {
 		// Check for cancellation before dubbing
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("operation cancelled: %w", ctx.Err())
-		default:
+		if err := StageCheckpoint(ctx); err != nil {
+			return err
 		}
 
-		progress <- ProgressUpdate{0.8, "Creating dubbed audio..."}
+		progress <- ProgressUpdate{Percentage: 0.8, Message: "Creating dubbed audio...", Stage: StageDubbing}
 
 		select {
 		case <-time.After(50 * time.Millisecond):
@@ -106,13 +122,11 @@ func (m *MockScribeEngine) StartProcessing(ctx context.Context, options ScribeOp
 
 	if options.CreateSubtitles {
 		// Check for cancellation before subtitles
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("operation cancelled: %w", ctx.Err())
-		default:
+		if err := StageCheckpoint(ctx); err != nil {
+			return err
 		}
 
-		progress <- ProgressUpdate{0.9, "Generating subtitles..."}
+		progress <- ProgressUpdate{Percentage: 0.9, Message: "Generating subtitles...", Stage: StageSubtitles}
 
 		select {
 		case <-time.After(50 * time.Millisecond):
@@ -121,22 +135,22 @@ func (m *MockScribeEngine) StartProcessing(ctx context.Context, options ScribeOp
 		}
 	}
 
-	// Final result with JSON structure
-	result := fmt.Sprintf(`{
-  "Transcription": "%s",
-  "Translation": "%s"
-}`, transcription, translation)
-
-	progress <- ProgressUpdate{1.0, "Scribing complete.\n" + result}
+	result := &ScribeResult{
+		Transcription:    transcription,
+		Translation:      translation,
+		OutputDir:        "/mock/output/dir",
+		TranscriptSource: TranscriptSourceASR,
+	}
+	progress <- ProgressUpdate{Percentage: 1.0, Message: "Scribing complete.", Result: result}
 
-	fmt.Println("Mock processing completed successfully.")
+	LoggerFrom(ctx).Debug("Mock processing completed")
 	return nil
 }
 
 // ProcessWithContext simulates the full processing pipeline and returns a structured result.
 // This is used by the batch processor.
 func (m *MockScribeEngine) ProcessWithContext(ctx context.Context, options ScribeOptions, progress chan<- ProgressUpdate) (*ScribeResult, error) {
-	fmt.Printf("Mock ProcessWithContext called with options: %+v\n", options)
+	LoggerFrom(ctx).Debug("Mock ProcessWithContext called", "options", options)
 
 	// Check for cancellation before starting
 	select {
@@ -145,38 +159,40 @@ func (m *MockScribeEngine) ProcessWithContext(ctx context.Context, options Scrib
 	default:
 	}
 
+	var timer stageTimer
+
 	// Simulate the full processing pipeline with progress updates
-	progress <- ProgressUpdate{0.0, "Starting processing..."}
+	progress <- ProgressUpdate{Percentage: 0.0, Message: "Starting processing..."}
 
 	// Simulate transcription
+	timer.begin(StageTranscribe)
 	select {
 	case <-time.After(50 * time.Millisecond):
 	case <-ctx.Done():
 		return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
 	}
 
-	progress <- ProgressUpdate{0.3, "Transcribing audio..."}
-	transcription, err := m.Transcribe(options.InputFile)
+	progress <- ProgressUpdate{Percentage: 0.3, Message: "Transcribing audio...", Stage: StageTranscribe}
+	transcription, err := m.TranscribeContext(ctx, options.InputFile)
 	if err != nil {
 		return nil, err
 	}
 
 	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
-	default:
+	if err := StageCheckpoint(ctx); err != nil {
+		return nil, err
 	}
 
 	// Simulate translation
-	progress <- ProgressUpdate{0.6, "Translating text..."}
+	progress <- ProgressUpdate{Percentage: 0.6, Message: "Translating text...", Stage: StageTranslate}
+	timer.begin(StageTranslate)
 	select {
 	case <-time.After(50 * time.Millisecond):
 	case <-ctx.Done():
 		return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
 	}
 
-	translation, err := m.Translate(transcription, options.TargetLanguage)
+	translation, err := m.TranslateContext(ctx, transcription, options.TargetLanguage)
 	if err != nil {
 		return nil, err
 	}
@@ -185,16 +201,17 @@ func (m *MockScribeEngine) ProcessWithContext(ctx context.Context, options Scrib
 		Transcription: transcription,
 		Translation:   translation,
 		OutputDir:     "/mock/output/dir",
+
+		TranscriptSource: TranscriptSourceASR,
 	}
 
 	if options.CreateDubbing {
-		select {
-		case <-ctx.Done():
-			return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
-		default:
+		if err := StageCheckpoint(ctx); err != nil {
+			return nil, err
 		}
 
-		progress <- ProgressUpdate{0.8, "Creating dubbed audio..."}
+		progress <- ProgressUpdate{Percentage: 0.8, Message: "Creating dubbed audio...", Stage: StageDubbing}
+		timer.begin(StageDubbing)
 		select {
 		case <-time.After(30 * time.Millisecond):
 		case <-ctx.Done():
@@ -205,13 +222,12 @@ func (m *MockScribeEngine) ProcessWithContext(ctx context.Context, options Scrib
 	}
 
 	if options.CreateSubtitles {
-		select {
-		case <-ctx.Done():
-			return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
-		default:
+		if err := StageCheckpoint(ctx); err != nil {
+			return nil, err
 		}
 
-		progress <- ProgressUpdate{0.9, "Generating subtitles..."}
+		progress <- ProgressUpdate{Percentage: 0.9, Message: "Generating subtitles...", Stage: StageSubtitles}
+		timer.begin(StageSubtitles)
 		select {
 		case <-time.After(30 * time.Millisecond):
 		case <-ctx.Done():
@@ -225,8 +241,9 @@ func (m *MockScribeEngine) ProcessWithContext(ctx context.Context, options Scrib
 		result.SubtitlesFile = fmt.Sprintf("/mock/output/dir/subtitles.%s", format)
 	}
 
-	progress <- ProgressUpdate{1.0, "Processing complete"}
+	result.StageTimings = timer.finish()
+	progress <- ProgressUpdate{Percentage: 1.0, Message: "Processing complete"}
 
-	fmt.Println("Mock processing with context completed successfully.")
+	LoggerFrom(ctx).Debug("Mock processing with context completed")
 	return result, nil
 }
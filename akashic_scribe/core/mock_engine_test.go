@@ -1,6 +1,8 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"testing"
 )
 
@@ -37,4 +39,16 @@ func TestMockScribeEngine(t *testing.T) {
 			t.Errorf("Translate() returned %q, want %q", translation, expectedTranslation)
 		}
 	})
+	// Test cancellation of the context-aware variants
+	t.Run("ContextCancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := engine.TranscribeContext(ctx, "test_video.mp4"); !errors.Is(err, context.Canceled) {
+			t.Errorf("TranscribeContext() returned %v, want context.Canceled", err)
+		}
+		if _, err := engine.TranslateContext(ctx, "Hello, world.", "Español"); !errors.Is(err, context.Canceled) {
+			t.Errorf("TranslateContext() returned %v, want context.Canceled", err)
+		}
+	})
 }
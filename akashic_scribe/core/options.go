@@ -1,6 +1,9 @@
 package core
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // ScribeOptions represents all user configuration for the transcription and translation process.
 //
@@ -17,10 +20,22 @@ type ScribeOptions struct {
 	InputFile string // Full path to the local video file.
 	InputURL  string // URL of the video to be downloaded.
 
+	// InputDuration is the known length of InputURL, e.g. from a playlist
+	// listing, so estimates need not probe it. Zero means unknown.
+	InputDuration time.Duration
+
 	// Language configuration
 	OriginLanguage string // e.g., "en-US"
 	TargetLanguage string // e.g., "ja-JP"
 
+	// Time range (zero values = whole input)
+	StartTime time.Duration // Offset where processing starts
+	EndTime   time.Duration // Offset where processing stops (0 = end of input)
+
+	// Transcript source (URL inputs only)
+	UsePlatformCaptions bool // Use the platform's captions in the origin language instead of transcribing
+	AllowAutoCaptions   bool // Fall back to automatically generated captions if no uploaded captions exist
+
 	// Subtitle options
 	CreateSubtitles    bool   // Whether to generate subtitles
 	BilingualSubtitles bool   // Whether to include both languages in subtitles
@@ -43,6 +58,12 @@ type ScribeOptions struct {
 	RemoveSilence   bool    // Whether to remove long silences (default false)
 	AudioChannels   int     // Number of audio channels: 1 (mono) or 2 (stereo), default 2
 
+	// Processing
+	Pipeline string // Name of a registered pipeline (empty = "default")
+
+	// Credentials
+	APIKeys map[string]Secret `json:"-"` // Per-job API keys keyed by provider name; override the credential store
+
 	// Output configuration
 	OutputDir string // Optional. If empty, defaults to the input file directory or a sensible default.
 }
@@ -57,10 +78,25 @@ func (s ScribeOptions) String() string {
 	if s.InputURL != "" {
 		result += "  URL: " + s.InputURL + "\n"
 	}
+	if s.UsePlatformCaptions {
+		if s.AllowAutoCaptions {
+			result += "  Platform Captions: Enabled (with auto-captions)\n"
+		} else {
+			result += "  Platform Captions: Enabled\n"
+		}
+	}
 
 	result += "Language Configuration:\n"
 	result += "  Origin: " + s.OriginLanguage + "\n"
 	result += "  Target: " + s.TargetLanguage + "\n"
+	if s.hasTimeRange() {
+		end := "end"
+		if s.EndTime > 0 {
+			end = s.EndTime.String()
+		}
+		result += "Time Range:\n"
+		result += "  " + s.StartTime.String() + " to " + end + "\n"
+	}
 
 	result += "Subtitle Options:\n"
 	if s.CreateSubtitles {
@@ -119,6 +155,17 @@ func (s ScribeOptions) String() string {
 		result += "  Create Dubbing: Disabled\n"
 	}
 
+	if s.Pipeline != "" {
+		result += "Pipeline: " + s.Pipeline + "\n"
+	}
+
+	if len(s.APIKeys) > 0 {
+		result += "API Keys:\n"
+		for _, provider := range sortedProviders(s.APIKeys) {
+			result += "  " + provider + ": " + s.APIKeys[provider].String() + "\n"
+		}
+	}
+
 	if s.OutputDir != "" {
 		result += "Output Directory:\n"
 		result += "  Path: " + s.OutputDir + "\n"
@@ -0,0 +1,453 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultPipelineName is the pipeline used when ScribeOptions.Pipeline is empty.
+const DefaultPipelineName = "default"
+
+// AllStages can be passed to Pipeline.Before and Pipeline.After to run a hook around every stage.
+const AllStages = "*"
+
+// Artifact kinds recorded in PipelineState.Artifacts by the built-in stages.
+const (
+	ArtifactTranscript  = "transcript"
+	ArtifactTranslation = "translation"
+	ArtifactSubtitles   = "subtitles"
+	ArtifactDubbedAudio = "dubbed_audio"
+)
+
+// Stage is a named step of a Pipeline. Stages communicate through the
+// PipelineState: they read their inputs from it and write their outputs to it.
+type Stage struct {
+	Name     string                          // Unique within a pipeline; recorded in StageTimings
+	Progress float64                         // Progress reported when the stage starts
+	Message  string                          // Status reported when the stage starts (empty = none)
+	Skip     func(state *PipelineState) bool // Optional; reports whether the stage does not apply
+	Run      func(ctx context.Context, state *PipelineState) error
+}
+
+// StageHook runs before or after a stage. Hooks can inspect or modify the
+// transcript, translation and artifacts; an error fails the run.
+type StageHook func(ctx context.Context, stage string, state *PipelineState) error
+
+// PipelineState is the data passed between stages of one pipeline run.
+type PipelineState struct {
+	Options     ScribeOptions      // Job options
+	OutputDir   string             // Directory for output files
+	MediaPath   string             // Media being processed: the input file, a download or an extracted clip
+	Captions    *CaptionTranscript // Platform captions used instead of transcription, if any
+	Transcript  string             // Source-language text
+	Translation string             // Target-language text
+	Artifacts   map[string]string  // Output files by kind, e.g. ArtifactSubtitles
+
+	TranscriptSource string // TranscriptSourceASR, TranscriptSourceCaptions or TranscriptSourceAutoCaptions
+	DetectedLanguage string // Source language reported by the transcript source
+
+	Usage    map[string]Usage  // Billable provider usage, keyed by provider name; see RecordUsage
+	ServedBy map[string]string // Provider that served each stage, keyed by stage name; see RecordServedBy
+
+	engine        *realScribeEngine // Runs the built-in stages
+	progress      chan<- ProgressUpdate
+	tempDir       string        // Scratch directory, created on first use and removed when the run ends
+	mediaDuration time.Duration // Duration of MediaPath, once probed
+
+	started    time.Time // When the run started, for ETAs
+	stage      string    // Stage currently running
+	stageStart float64   // Overall progress at the start of the current stage
+	stageEnd   float64   // Overall progress at the end of the current stage
+}
+
+// NewPipelineState creates the state for a run with the given options.
+// progress may be nil.
+func NewPipelineState(options ScribeOptions, progress chan<- ProgressUpdate) *PipelineState {
+	return &PipelineState{
+		Options:   options,
+		OutputDir: options.OutputDir,
+		MediaPath: options.InputFile,
+		Artifacts: make(map[string]string),
+		progress:  progress,
+		started:   time.Now(),
+	}
+}
+
+// Stage returns the name of the stage currently running.
+func (s *PipelineState) Stage() string {
+	return s.stage
+}
+
+// Report sends a progress update at the given overall percentage.
+func (s *PipelineState) Report(percentage float64, message string) {
+	if s.progress == nil {
+		return
+	}
+
+	update := ProgressUpdate{Percentage: percentage, Message: message}
+	if s.stage != "" {
+		update.Stage = s.stage
+		if span := s.stageEnd - s.stageStart; span > 0 {
+			update.StageProgress = clampFraction((percentage - s.stageStart) / span)
+		}
+	}
+	update.ETA = s.eta(percentage)
+	s.progress <- update
+}
+
+// ReportProgress sends a progress update from within a stage.
+// update.StageProgress is mapped onto the stage's share of the overall
+// progress, and Percentage, Stage and ETA are filled in.
+func (s *PipelineState) ReportProgress(update ProgressUpdate) {
+	if s.progress != nil {
+		s.progress <- s.stageUpdate(update)
+	}
+}
+
+// stageUpdate fills in the overall fields of an update from the current stage.
+func (s *PipelineState) stageUpdate(update ProgressUpdate) ProgressUpdate {
+	update.StageProgress = clampFraction(update.StageProgress)
+	update.Stage = s.stage
+	update.Percentage = s.stageStart + update.StageProgress*(s.stageEnd-s.stageStart)
+	update.ETA = s.eta(update.Percentage)
+	return update
+}
+
+// eta estimates the time remaining from the time elapsed so far, assuming
+// progress continues at the same rate. It returns 0 until there is enough
+// progress for a meaningful estimate.
+func (s *PipelineState) eta(percentage float64) time.Duration {
+	if percentage < 0.05 || percentage >= 1.0 {
+		return 0
+	}
+	elapsed := time.Since(s.started)
+	return time.Duration(float64(elapsed) * (1 - percentage) / percentage).Round(time.Second)
+}
+
+// clampFraction limits f to the range 0.0 to 1.0.
+func clampFraction(f float64) float64 {
+	return math.Max(0, math.Min(1, f))
+}
+
+// WorkDir returns a scratch directory for the run, creating it on first use.
+// It is removed when the pipeline finishes.
+func (s *PipelineState) WorkDir() (string, error) {
+	if s.tempDir == "" {
+		dir, err := os.MkdirTemp("", "akashic_scribe_*")
+		if err != nil {
+			return "", fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		s.tempDir = dir
+	}
+	return s.tempDir, nil
+}
+
+// cleanup removes the scratch directory, logging failures to the job's logger.
+func (s *PipelineState) cleanup(ctx context.Context) {
+	if s.tempDir == "" {
+		return
+	}
+	if err := os.RemoveAll(s.tempDir); err != nil {
+		LoggerFrom(ctx).Warn("Failed to clean up temp directory", "dir", s.tempDir, "error", err)
+	}
+	s.tempDir = ""
+}
+
+// result builds the ScribeResult for a finished run.
+func (s *PipelineState) result(timings []StageTiming) *ScribeResult {
+	return &ScribeResult{
+		Transcription:    s.Transcript,
+		Translation:      s.Translation,
+		DubbedAudio:      s.Artifacts[ArtifactDubbedAudio],
+		SubtitlesFile:    s.Artifacts[ArtifactSubtitles],
+		OutputDir:        s.OutputDir,
+		Artifacts:        s.Artifacts,
+		StageTimings:     timings,
+		DetectedLanguage: s.DetectedLanguage,
+		TranscriptSource: s.TranscriptSource,
+		Usage:            s.Usage,
+		ServedBy:         s.ServedBy,
+	}
+}
+
+// Pipeline is an ordered list of stages with hooks around them.
+type Pipeline struct {
+	name   string
+	stages []Stage
+	before map[string][]StageHook
+	after  map[string][]StageHook
+}
+
+// NewPipeline creates an empty pipeline.
+func NewPipeline(name string) *Pipeline {
+	return &Pipeline{
+		name:   name,
+		before: make(map[string][]StageHook),
+		after:  make(map[string][]StageHook),
+	}
+}
+
+// Name returns the pipeline's name.
+func (p *Pipeline) Name() string {
+	return p.name
+}
+
+// StageNames returns the names of the stages in execution order.
+func (p *Pipeline) StageNames() []string {
+	names := make([]string, len(p.stages))
+	for i, stage := range p.stages {
+		names[i] = stage.Name
+	}
+	return names
+}
+
+// Clone returns a copy of the pipeline under a new name, for building a
+// variant of an existing pipeline.
+func (p *Pipeline) Clone(name string) *Pipeline {
+	clone := NewPipeline(name)
+	clone.stages = append(clone.stages, p.stages...)
+	for stage, hooks := range p.before {
+		clone.before[stage] = append([]StageHook(nil), hooks...)
+	}
+	for stage, hooks := range p.after {
+		clone.after[stage] = append([]StageHook(nil), hooks...)
+	}
+	return clone
+}
+
+// index returns the position of the named stage, or -1.
+func (p *Pipeline) index(name string) int {
+	for i, stage := range p.stages {
+		if stage.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// insert validates stage and adds it at position i.
+func (p *Pipeline) insert(i int, stage Stage) error {
+	if stage.Name == "" || stage.Name == AllStages {
+		return fmt.Errorf("invalid stage name %q", stage.Name)
+	}
+	if stage.Run == nil {
+		return fmt.Errorf("stage %s has no Run function", stage.Name)
+	}
+	if p.index(stage.Name) >= 0 {
+		return fmt.Errorf("pipeline %s already has a stage named %s", p.name, stage.Name)
+	}
+
+	p.stages = append(p.stages[:i], append([]Stage{stage}, p.stages[i:]...)...)
+	return nil
+}
+
+// Append adds a stage at the end of the pipeline.
+func (p *Pipeline) Append(stage Stage) error {
+	return p.insert(len(p.stages), stage)
+}
+
+// InsertBefore adds a stage immediately before the named stage.
+func (p *Pipeline) InsertBefore(existing string, stage Stage) error {
+	i := p.index(existing)
+	if i < 0 {
+		return fmt.Errorf("pipeline %s has no stage named %s", p.name, existing)
+	}
+	return p.insert(i, stage)
+}
+
+// InsertAfter adds a stage immediately after the named stage.
+func (p *Pipeline) InsertAfter(existing string, stage Stage) error {
+	i := p.index(existing)
+	if i < 0 {
+		return fmt.Errorf("pipeline %s has no stage named %s", p.name, existing)
+	}
+	return p.insert(i+1, stage)
+}
+
+// Remove deletes the named stage and its hooks.
+func (p *Pipeline) Remove(name string) error {
+	i := p.index(name)
+	if i < 0 {
+		return fmt.Errorf("pipeline %s has no stage named %s", p.name, name)
+	}
+	p.stages = append(p.stages[:i:i], p.stages[i+1:]...)
+	delete(p.before, name)
+	delete(p.after, name)
+	return nil
+}
+
+// Before registers a hook that runs before the named stage, or before every
+// stage for AllStages. Hooks run in registration order.
+func (p *Pipeline) Before(stage string, hook StageHook) error {
+	if stage != AllStages && p.index(stage) < 0 {
+		return fmt.Errorf("pipeline %s has no stage named %s", p.name, stage)
+	}
+	p.before[stage] = append(p.before[stage], hook)
+	return nil
+}
+
+// After registers a hook that runs after the named stage, or after every
+// stage for AllStages. Hooks run in registration order.
+func (p *Pipeline) After(stage string, hook StageHook) error {
+	if stage != AllStages && p.index(stage) < 0 {
+		return fmt.Errorf("pipeline %s has no stage named %s", p.name, stage)
+	}
+	p.after[stage] = append(p.after[stage], hook)
+	return nil
+}
+
+// runHooks runs the hooks for a stage followed by the AllStages hooks.
+func runHooks(ctx context.Context, hooks map[string][]StageHook, stage string, state *PipelineState) error {
+	for _, group := range [][]StageHook{hooks[stage], hooks[AllStages]} {
+		for _, hook := range group {
+			if err := hook(ctx, stage, state); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Run executes every applicable stage in order and returns their timings.
+// Cancellation is checked before each stage, and the state's scratch
+// directory is removed however the run ends.
+func (p *Pipeline) Run(ctx context.Context, state *PipelineState) ([]StageTiming, error) {
+	defer state.cleanup(ctx)
+
+	defer func() { state.stage = "" }()
+
+	var timer stageTimer
+	for i, stage := range p.stages {
+		if stage.Skip != nil && stage.Skip(state) {
+			continue
+		}
+		if err := StageCheckpoint(ctx); err != nil {
+			return nil, err
+		}
+
+		state.stage = stage.Name
+		state.stageStart, state.stageEnd = p.stageSpan(i, state)
+		if stage.Message != "" {
+			state.Report(state.stageStart, stage.Message)
+		}
+		timer.begin(stage.Name)
+		if err := p.runStage(ctx, stage, state); err != nil {
+			return nil, err
+		}
+	}
+
+	return timer.finish(), nil
+}
+
+// runStage runs a stage and its hooks in a span, and logs and records its duration.
+func (p *Pipeline) runStage(ctx context.Context, stage Stage, state *PipelineState) (err error) {
+	// Everything logged during the stage is tagged with its name
+	logger := LoggerFrom(ctx).With("stage", stage.Name)
+	ctx, span := StartSpan(WithLogger(ctx, logger), "stage "+stage.Name,
+		slog.String("pipeline", p.name), slog.String("stage", stage.Name))
+	logger.Debug("Stage started")
+	started := time.Now()
+	defer func() {
+		span.End(err)
+		DefaultMetrics().ObserveStage(p.name, stage.Name, metricStatus(err), time.Since(started))
+	}()
+
+	if err := runHooks(ctx, p.before, stage.Name, state); err != nil {
+		return fmt.Errorf("before %s hook failed: %w", stage.Name, err)
+	}
+	if err := stage.Run(ctx, state); err != nil {
+		logger.Warn("Stage failed", "error", err)
+		return err
+	}
+	if err := runHooks(ctx, p.after, stage.Name, state); err != nil {
+		return fmt.Errorf("after %s hook failed: %w", stage.Name, err)
+	}
+	logger.Info("Stage finished", "duration", time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+// stageSpan returns the range of overall progress covered by stage i: from
+// its Progress to the Progress of the next stage that will run. Stages
+// without a Progress, such as most custom stages, take no share and stay
+// where the previous stage ended.
+func (p *Pipeline) stageSpan(i int, state *PipelineState) (start, end float64) {
+	start = p.stages[i].Progress
+	if start <= 0 && i > 0 {
+		return state.stageEnd, state.stageEnd
+	}
+	for _, next := range p.stages[i+1:] {
+		if next.Progress > start && (next.Skip == nil || !next.Skip(state)) {
+			return start, next.Progress
+		}
+	}
+	return start, 1.0
+}
+
+// pipelineRegistry holds the named pipelines available to jobs.
+var pipelineRegistry = struct {
+	sync.RWMutex
+	pipelines map[string]*Pipeline
+}{pipelines: make(map[string]*Pipeline)}
+
+func init() {
+	for _, p := range builtinPipelines() {
+		if err := RegisterPipeline(p); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterPipeline makes a pipeline available by name, replacing any
+// pipeline with the same name. The registry keeps a copy, so later changes
+// to p do not affect jobs.
+func RegisterPipeline(p *Pipeline) error {
+	if p == nil || p.name == "" {
+		return errors.New("pipeline must have a name")
+	}
+	if len(p.stages) == 0 {
+		return fmt.Errorf("pipeline %s has no stages", p.name)
+	}
+
+	pipelineRegistry.Lock()
+	defer pipelineRegistry.Unlock()
+
+	pipelineRegistry.pipelines[p.name] = p.Clone(p.name)
+	return nil
+}
+
+// LookupPipeline returns a copy of the named pipeline. An empty name selects
+// DefaultPipelineName.
+func LookupPipeline(name string) (*Pipeline, error) {
+	if name == "" {
+		name = DefaultPipelineName
+	}
+
+	pipelineRegistry.RLock()
+	defer pipelineRegistry.RUnlock()
+
+	p, ok := pipelineRegistry.pipelines[name]
+	if !ok {
+		return nil, NewScribeError(ErrorClassInvalidInput, "pipeline", fmt.Errorf("unknown pipeline %q", name))
+	}
+	return p.Clone(name), nil
+}
+
+// PipelineNames returns the names of all registered pipelines, sorted.
+func PipelineNames() []string {
+	pipelineRegistry.RLock()
+	defer pipelineRegistry.RUnlock()
+
+	names := make([]string, 0, len(pipelineRegistry.pipelines))
+	for name := range pipelineRegistry.pipelines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
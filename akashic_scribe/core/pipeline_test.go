@@ -0,0 +1,193 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordStage returns a stage that appends its name to the transcript.
+func recordStage(name string) Stage {
+	return Stage{
+		Name: name,
+		Run: func(ctx context.Context, state *PipelineState) error {
+			state.Transcript += name + ";"
+			return nil
+		},
+	}
+}
+
+func TestPipeline_Builder(t *testing.T) {
+	p := NewPipeline("test")
+	require.NoError(t, p.Append(recordStage("a")))
+	require.NoError(t, p.Append(recordStage("c")))
+	require.NoError(t, p.InsertBefore("c", recordStage("b")))
+	require.NoError(t, p.InsertAfter("c", recordStage("d")))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, p.StageNames())
+
+	require.NoError(t, p.Remove("b"))
+	assert.Equal(t, []string{"a", "c", "d"}, p.StageNames())
+
+	assert.Error(t, p.Append(recordStage("a")), "duplicate names are rejected")
+	assert.Error(t, p.Append(Stage{Name: "no-run"}))
+	assert.Error(t, p.Append(recordStage(AllStages)))
+	assert.Error(t, p.InsertAfter("missing", recordStage("e")))
+	assert.Error(t, p.Remove("missing"))
+	assert.Error(t, p.Before("missing", func(context.Context, string, *PipelineState) error { return nil }))
+}
+
+func TestPipeline_CloneIsIndependent(t *testing.T) {
+	p := NewPipeline("original")
+	require.NoError(t, p.Append(recordStage("a")))
+	require.NoError(t, p.Append(recordStage("b")))
+
+	clone := p.Clone("copy")
+	require.NoError(t, clone.Remove("a"))
+	require.NoError(t, clone.InsertAfter("b", recordStage("c")))
+
+	assert.Equal(t, "copy", clone.Name())
+	assert.Equal(t, []string{"b", "c"}, clone.StageNames())
+	assert.Equal(t, []string{"a", "b"}, p.StageNames())
+}
+
+func TestPipeline_RunWithHooks(t *testing.T) {
+	p := NewPipeline("hooks")
+	require.NoError(t, p.Append(recordStage("a")))
+	require.NoError(t, p.Append(recordStage("b")))
+	require.NoError(t, p.Append(Stage{
+		Name: "skipped",
+		Skip: func(*PipelineState) bool { return true },
+		Run: func(context.Context, *PipelineState) error {
+			t.Error("skipped stage ran")
+			return nil
+		},
+	}))
+
+	require.NoError(t, p.Before("b", func(ctx context.Context, stage string, state *PipelineState) error {
+		state.Transcript += "before-" + stage + ";"
+		return nil
+	}))
+	require.NoError(t, p.After(AllStages, func(ctx context.Context, stage string, state *PipelineState) error {
+		state.Transcript = strings.ToUpper(state.Transcript)
+		state.Artifacts["last"] = stage
+		return nil
+	}))
+
+	state := NewPipelineState(ScribeOptions{}, nil)
+	timings, err := p.Run(context.Background(), state)
+	require.NoError(t, err)
+
+	assert.Equal(t, "A;BEFORE-B;B;", state.Transcript)
+	assert.Equal(t, "b", state.Artifacts["last"])
+	require.Len(t, timings, 2)
+	assert.Equal(t, "a", timings[0].Stage)
+	assert.Equal(t, "b", timings[1].Stage)
+}
+
+func TestPipeline_HookErrorFailsRun(t *testing.T) {
+	p := NewPipeline("failing")
+	require.NoError(t, p.Append(recordStage("a")))
+	require.NoError(t, p.Append(recordStage("b")))
+	require.NoError(t, p.After("a", func(context.Context, string, *PipelineState) error {
+		return errors.New("rejected")
+	}))
+
+	state := NewPipelineState(ScribeOptions{}, nil)
+	_, err := p.Run(context.Background(), state)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after a hook failed: rejected")
+	assert.Equal(t, "a;", state.Transcript, "later stages do not run")
+}
+
+func TestPipeline_RunCancelled(t *testing.T) {
+	p := NewPipeline("cancelled")
+	require.NoError(t, p.Append(recordStage("a")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	state := NewPipelineState(ScribeOptions{}, nil)
+	_, err := p.Run(ctx, state)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, state.Transcript)
+}
+
+func TestPipeline_RunRemovesWorkDir(t *testing.T) {
+	var dir string
+	p := NewPipeline("scratch")
+	require.NoError(t, p.Append(Stage{
+		Name: "scratch",
+		Run: func(ctx context.Context, state *PipelineState) error {
+			var err error
+			dir, err = state.WorkDir()
+			return err
+		},
+	}))
+
+	_, err := p.Run(context.Background(), NewPipelineState(ScribeOptions{}, nil))
+	require.NoError(t, err)
+	require.NotEmpty(t, dir)
+	assert.NoDirExists(t, dir)
+}
+
+func TestPipeline_CleanupFailureLogsToJobLogger(t *testing.T) {
+	p := NewPipeline("scratch")
+	require.NoError(t, p.Append(Stage{
+		Name: "scratch",
+		Run: func(ctx context.Context, state *PipelineState) error {
+			state.tempDir = "invalid\x00dir" // RemoveAll rejects paths with NUL bytes
+			return nil
+		},
+	}))
+
+	var console bytes.Buffer
+	ctx := WithLogger(context.Background(), NewLogger(&console, slog.LevelInfo).With("job_id", "job-3"))
+	_, err := p.Run(ctx, NewPipelineState(ScribeOptions{}, nil))
+	require.NoError(t, err)
+	assert.Contains(t, console.String(), `msg="Failed to clean up temp directory" job_id=job-3`)
+}
+
+func TestPipelineRegistry(t *testing.T) {
+	assert.Contains(t, PipelineNames(), DefaultPipelineName)
+	assert.Contains(t, PipelineNames(), NormalizedPipelineName)
+
+	def, err := LookupPipeline("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPipelineName, def.Name())
+	assert.Equal(t, []string{StageCaptions, StageDownload, StageExtract, StageTranscribe,
+		StageTranslate, StageDubbing, StageSubtitles, StageSave}, def.StageNames())
+
+	normalized, err := LookupPipeline(NormalizedPipelineName)
+	require.NoError(t, err)
+	assert.Equal(t, []string{StageCaptions, StageDownload, StageExtract, StageTranscribe,
+		"normalize", StageTranslate, StageDubbing, StageSubtitles, StageSave}, normalized.StageNames())
+
+	_, err = LookupPipeline("no-such-pipeline")
+	require.Error(t, err)
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+
+	// The registry keeps a copy
+	custom := NewPipeline("registry-test")
+	require.NoError(t, custom.Append(recordStage("a")))
+	require.NoError(t, RegisterPipeline(custom))
+	require.NoError(t, custom.Append(recordStage("b")))
+
+	registered, err := LookupPipeline("registry-test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, registered.StageNames())
+
+	assert.Error(t, RegisterPipeline(NewPipeline("empty")))
+	assert.Error(t, RegisterPipeline(nil))
+}
+
+func TestPipeline_BuiltinStagesNeedEngine(t *testing.T) {
+	p := DefaultPipeline()
+	_, err := p.Run(context.Background(), NewPipelineState(ScribeOptions{InputFile: "video.mp4"}, nil))
+	assert.Error(t, err)
+}
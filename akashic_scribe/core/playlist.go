@@ -0,0 +1,327 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Playlist describes a URL as reported by yt-dlp. Single videos are returned
+// as a Playlist with IsPlaylist false and one entry.
+type Playlist struct {
+	ID         string
+	Title      string
+	Uploader   string
+	IsPlaylist bool            // True for playlists and channels
+	Entries    []PlaylistEntry // Entries in playlist order
+}
+
+// PlaylistEntry is a single video in a playlist.
+type PlaylistEntry struct {
+	Index      int       // 1-based position in the playlist
+	ID         string    // Video ID, used for stable output directories
+	Title      string
+	URL        string
+	UploadDate time.Time // Zero if unknown
+	Duration   time.Duration
+}
+
+// PlaylistFilter selects the entries of a playlist that become jobs.
+// Zero values mean "no restriction".
+type PlaylistFilter struct {
+	Start      int       // First entry to include (1-based)
+	End        int       // Last entry to include (1-based, inclusive)
+	After      time.Time // Only entries uploaded on or after this date
+	Before     time.Time // Only entries uploaded on or before this date
+	MaxEntries int       // Maximum number of entries after the other filters
+}
+
+// PlaylistResolver is implemented by engines that can expand playlist and
+// channel URLs into their individual videos.
+type PlaylistResolver interface {
+	ResolvePlaylist(ctx context.Context, url string) (*Playlist, error)
+}
+
+// Validate checks that the filter's numbers are not negative and that its
+// ranges are not inverted.
+func (f PlaylistFilter) Validate() error {
+	if f.Start < 0 || f.End < 0 || f.MaxEntries < 0 {
+		return NewScribeError(ErrorClassInvalidInput, "playlist", fmt.Errorf("playlist start, end and max entries must not be negative"))
+	}
+	if f.Start > 0 && f.End > 0 && f.End < f.Start {
+		return NewScribeError(ErrorClassInvalidInput, "playlist",
+			fmt.Errorf("playlist end %d must not be before start %d", f.End, f.Start))
+	}
+	if !f.After.IsZero() && !f.Before.IsZero() && f.Before.Before(f.After) {
+		return NewScribeError(ErrorClassInvalidInput, "playlist",
+			fmt.Errorf("playlist date range is inverted: %s is before %s",
+				f.Before.Format(time.DateOnly), f.After.Format(time.DateOnly)))
+	}
+	return nil
+}
+
+// Apply returns the entries of p selected by the filter. Entries with an
+// unknown upload date are excluded when a date filter is set.
+func (f PlaylistFilter) Apply(p *Playlist) []PlaylistEntry {
+	var selected []PlaylistEntry
+	skippedUndated := 0
+
+	for _, entry := range p.Entries {
+		if f.Start > 0 && entry.Index < f.Start {
+			continue
+		}
+		if f.End > 0 && entry.Index > f.End {
+			continue
+		}
+		if !f.After.IsZero() || !f.Before.IsZero() {
+			if entry.UploadDate.IsZero() {
+				skippedUndated++
+				continue
+			}
+			if !f.After.IsZero() && entry.UploadDate.Before(f.After) {
+				continue
+			}
+			if !f.Before.IsZero() && entry.UploadDate.After(f.Before) {
+				continue
+			}
+		}
+
+		selected = append(selected, entry)
+		if f.MaxEntries > 0 && len(selected) >= f.MaxEntries {
+			break
+		}
+	}
+
+	if skippedUndated > 0 {
+		componentLogger("playlist").Info("Skipped entries without an upload date", "playlist", p.ID, "count", skippedUndated)
+	}
+	return selected
+}
+
+// ytDlpPlaylistInfo is the subset of `yt-dlp --flat-playlist -J` output we use.
+type ytDlpPlaylistInfo struct {
+	Type       string              `json:"_type"`
+	ID         string              `json:"id"`
+	Title      string              `json:"title"`
+	Uploader   string              `json:"uploader"`
+	Channel    string              `json:"channel"`
+	URL        string              `json:"url"`
+	WebpageURL string              `json:"webpage_url"`
+	UploadDate string              `json:"upload_date"` // YYYYMMDD
+	Timestamp  float64             `json:"timestamp"`   // Unix time, used if upload_date is missing
+	Duration   float64             `json:"duration"`    // Seconds
+	Entries    []ytDlpPlaylistInfo `json:"entries"`
+}
+
+// ResolvePlaylist runs `yt-dlp --flat-playlist -J` to list the videos behind url
+// without downloading them.
+func (e *realScribeEngine) ResolvePlaylist(ctx context.Context, url string) (*Playlist, error) {
+	if _, err := exec.LookPath("yt-dlp"); err != nil {
+		return nil, NewScribeError(ErrorClassDependency, "dependencies", fmt.Errorf("yt-dlp not found in PATH: %w", err))
+	}
+
+	release, err := AcquireResource(ctx, ResourceDownload)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// approximate_date gives flat YouTube entries an upload date for date filtering
+	cmd := newCommand(ctx, "yt-dlp", "--flat-playlist", "-J",
+		"--extractor-args", "youtubetab:approximate_date", url)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	logCommand(ctx, cmd)
+	output, err := cmd.Output()
+	logToolOutput(ctx, cmd, stderr.Bytes())
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
+		}
+		return nil, NewScribeError(classifyYtDlpError(stderr.String()), "playlist",
+			fmt.Errorf("failed to list %s: %w: %s", url, err, strings.TrimSpace(stderr.String())))
+	}
+
+	return parsePlaylistJSON(output, url)
+}
+
+// parsePlaylistJSON converts yt-dlp's JSON output into a Playlist.
+func parsePlaylistJSON(data []byte, sourceURL string) (*Playlist, error) {
+	var info ytDlpPlaylistInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse yt-dlp output: %w", err)
+	}
+
+	playlist := &Playlist{
+		ID:       info.ID,
+		Title:    info.Title,
+		Uploader: firstNonEmpty(info.Uploader, info.Channel),
+	}
+
+	if info.Type != "playlist" {
+		playlist.Entries = []PlaylistEntry{newPlaylistEntry(info, 1, sourceURL)}
+		return playlist, nil
+	}
+
+	playlist.IsPlaylist = true
+	var flatten func(entries []ytDlpPlaylistInfo)
+	flatten = func(entries []ytDlpPlaylistInfo) {
+		for _, entry := range entries {
+			// Channels list their tabs as nested playlists
+			if entry.Type == "playlist" && len(entry.Entries) > 0 {
+				flatten(entry.Entries)
+				continue
+			}
+
+			url := firstNonEmpty(entry.WebpageURL, entry.URL)
+			if !strings.HasPrefix(url, "http") {
+				componentLogger("playlist").Info("Skipping entry without a URL", "entry", entry.ID)
+				continue
+			}
+			playlist.Entries = append(playlist.Entries, newPlaylistEntry(entry, len(playlist.Entries)+1, url))
+		}
+	}
+	flatten(info.Entries)
+
+	return playlist, nil
+}
+
+// newPlaylistEntry converts a single yt-dlp entry.
+func newPlaylistEntry(info ytDlpPlaylistInfo, index int, url string) PlaylistEntry {
+	entry := PlaylistEntry{
+		Index:    index,
+		ID:       info.ID,
+		Title:    info.Title,
+		URL:      url,
+		Duration: time.Duration(info.Duration * float64(time.Second)),
+	}
+	if date, err := time.Parse("20060102", info.UploadDate); err == nil {
+		entry.UploadDate = date
+	} else if info.Timestamp > 0 {
+		entry.UploadDate = time.Unix(int64(info.Timestamp), 0).UTC().Truncate(24 * time.Hour)
+	}
+	return entry
+}
+
+// firstNonEmpty returns the first non-empty string.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// safePathComponent makes s usable as a single directory name.
+func safePathComponent(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+	s = strings.Trim(s, ".")
+	if s == "" {
+		return "_"
+	}
+	return s
+}
+
+// URLOutputName returns a directory name for the outputs of a video URL: its
+// video or playlist ID (the v or list query parameter), else the last path
+// segment, e.g. "abc" for youtu.be/abc. URLs without either get the host and
+// a short hash of the URL.
+func URLOutputName(rawURL string) string {
+	var name, host string
+	if u, err := url.Parse(rawURL); err == nil {
+		query := u.Query()
+		name = firstNonEmpty(query.Get("v"), query.Get("list"))
+		if segment := path.Base(u.Path); name == "" && segment != "/" && segment != "." {
+			name = segment
+		}
+		host = u.Hostname()
+	}
+	if name == "" {
+		hash := md5.Sum([]byte(rawURL))
+		name = fmt.Sprintf("%s_%x", firstNonEmpty(host, "url"), hash[:4])
+	}
+	return safePathComponent(name)
+}
+
+// ExpandPlaylist returns the options of the jobs to run for options.InputURL.
+// If engine can resolve playlists and the URL is a playlist or channel, there
+// is one job per entry selected by filter, each with its own output directory
+// <OutputDir>/<playlist ID>/<video ID>. Otherwise options is the only job.
+func ExpandPlaylist(ctx context.Context, engine ScribeEngine, options ScribeOptions, filter PlaylistFilter) ([]ScribeOptions, error) {
+	if options.InputURL == "" {
+		return nil, NewScribeError(ErrorClassInvalidInput, "input", fmt.Errorf("no input URL provided"))
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	resolver, ok := engine.(PlaylistResolver)
+	if !ok {
+		return []ScribeOptions{options}, nil
+	}
+
+	playlist, err := resolver.ResolvePlaylist(ctx, options.InputURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve playlist: %w", err)
+	}
+	if !playlist.IsPlaylist {
+		return []ScribeOptions{options}, nil
+	}
+
+	entries := filter.Apply(playlist)
+	if len(entries) == 0 {
+		return nil, NewScribeError(ErrorClassInvalidInput, "playlist",
+			fmt.Errorf("no playlist entries match the filter (%d entries total)", len(playlist.Entries)))
+	}
+
+	outputRoot := options.OutputDir
+	if outputRoot == "" {
+		outputRoot = filepath.Join(".", "akashic_output")
+	}
+	outputRoot = filepath.Join(outputRoot, safePathComponent(firstNonEmpty(playlist.ID, playlist.Title)))
+
+	jobs := make([]ScribeOptions, 0, len(entries))
+	for _, entry := range entries {
+		entryOptions := options
+		entryOptions.InputURL = entry.URL
+		entryOptions.InputDuration = entry.Duration
+		entryOptions.OutputDir = filepath.Join(outputRoot, safePathComponent(firstNonEmpty(entry.ID, fmt.Sprintf("%03d", entry.Index))))
+		jobs = append(jobs, entryOptions)
+	}
+
+	componentLogger("playlist").Info("Expanded playlist", "playlist", firstNonEmpty(playlist.Title, playlist.ID), "jobs", len(jobs))
+	return jobs, nil
+}
+
+// AddURLJobs adds a job for options.InputURL, or one job per selected entry
+// if it is a playlist or channel (see ExpandPlaylist). It returns the IDs of
+// the added jobs in playlist order.
+func (bp *BatchProcessor) AddURLJobs(ctx context.Context, options ScribeOptions, filter PlaylistFilter, opts ...JobOption) ([]string, error) {
+	jobs, err := ExpandPlaylist(ctx, bp.engine, options, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	jobIDs := make([]string, 0, len(jobs))
+	for _, jobOptions := range jobs {
+		jobIDs = append(jobIDs, bp.AddJob(jobOptions, opts...))
+	}
+	return jobIDs, nil
+}
@@ -0,0 +1,212 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const samplePlaylistJSON = `{
+  "_type": "playlist",
+  "id": "PL123",
+  "title": "Conference Talks",
+  "uploader": "Example Org",
+  "entries": [
+    {"_type": "url", "id": "aaa", "title": "Opening", "url": "https://www.youtube.com/watch?v=aaa", "upload_date": "20240110", "duration": 600},
+    {"_type": "url", "id": "bbb", "title": "Keynote", "url": "https://www.youtube.com/watch?v=bbb", "timestamp": 1707955200},
+    {"_type": "url", "id": "ccc", "title": "Private video", "url": "ccc"},
+    {"_type": "playlist", "id": "tab", "title": "Shorts", "entries": [
+      {"_type": "url", "id": "ddd", "title": "Short", "url": "https://www.youtube.com/shorts/ddd", "upload_date": "20240301"}
+    ]},
+    {"_type": "url", "id": "eee", "title": "Closing", "url": "https://www.youtube.com/watch?v=eee"}
+  ]
+}`
+
+func TestParsePlaylistJSON_Playlist(t *testing.T) {
+	playlist, err := parsePlaylistJSON([]byte(samplePlaylistJSON), "https://www.youtube.com/playlist?list=PL123")
+	require.NoError(t, err)
+
+	assert.True(t, playlist.IsPlaylist)
+	assert.Equal(t, "PL123", playlist.ID)
+	assert.Equal(t, "Example Org", playlist.Uploader)
+
+	require.Len(t, playlist.Entries, 4, "Entries without a URL are skipped, nested tabs are flattened")
+	ids := []string{}
+	for i, entry := range playlist.Entries {
+		ids = append(ids, entry.ID)
+		assert.Equal(t, i+1, entry.Index)
+	}
+	assert.Equal(t, []string{"aaa", "bbb", "ddd", "eee"}, ids)
+
+	assert.Equal(t, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), playlist.Entries[0].UploadDate)
+	assert.Equal(t, 10*time.Minute, playlist.Entries[0].Duration)
+	assert.Equal(t, time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC), playlist.Entries[1].UploadDate, "Timestamp fallback")
+	assert.True(t, playlist.Entries[3].UploadDate.IsZero())
+}
+
+func TestParsePlaylistJSON_SingleVideo(t *testing.T) {
+	data := `{"_type": "video", "id": "xyz", "title": "Just one", "upload_date": "20230505"}`
+	playlist, err := parsePlaylistJSON([]byte(data), "https://www.youtube.com/watch?v=xyz&list=PL123")
+	require.NoError(t, err)
+
+	assert.False(t, playlist.IsPlaylist)
+	require.Len(t, playlist.Entries, 1)
+	assert.Equal(t, "https://www.youtube.com/watch?v=xyz&list=PL123", playlist.Entries[0].URL)
+
+	_, err = parsePlaylistJSON([]byte("not json"), "")
+	assert.Error(t, err)
+}
+
+func TestPlaylistFilter_Apply(t *testing.T) {
+	playlist, err := parsePlaylistJSON([]byte(samplePlaylistJSON), "")
+	require.NoError(t, err)
+
+	ids := func(entries []PlaylistEntry) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		filter PlaylistFilter
+		want   []string
+	}{
+		{"no filter", PlaylistFilter{}, []string{"aaa", "bbb", "ddd", "eee"}},
+		{"range", PlaylistFilter{Start: 2, End: 3}, []string{"bbb", "ddd"}},
+		{"open-ended range", PlaylistFilter{Start: 3}, []string{"ddd", "eee"}},
+		{"max entries", PlaylistFilter{MaxEntries: 2}, []string{"aaa", "bbb"}},
+		{"after", PlaylistFilter{After: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}, []string{"bbb", "ddd"}},
+		{"before", PlaylistFilter{Before: time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)}, []string{"aaa", "bbb"}},
+		{"range and date", PlaylistFilter{Start: 2, After: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, []string{"ddd"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ids(tt.filter.Apply(playlist)))
+		})
+	}
+}
+
+func TestPlaylistFilter_Validate(t *testing.T) {
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, PlaylistFilter{}.Validate())
+	assert.NoError(t, PlaylistFilter{Start: 2, End: 2, After: jan, Before: jan, MaxEntries: 1}.Validate())
+	assert.NoError(t, PlaylistFilter{Start: 5, Before: jan}.Validate(), "Open-ended ranges are valid")
+
+	for _, filter := range []PlaylistFilter{
+		{Start: 3, End: 2},
+		{After: feb, Before: jan},
+		{Start: -1},
+		{MaxEntries: -1},
+	} {
+		err := filter.Validate()
+		assert.Error(t, err, "%+v", filter)
+		assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+	}
+}
+
+// playlistEngine is a mock engine that resolves every URL to a fixed playlist.
+type playlistEngine struct {
+	*MockScribeEngine
+	playlist *Playlist
+}
+
+func (e *playlistEngine) ResolvePlaylist(ctx context.Context, url string) (*Playlist, error) {
+	return e.playlist, nil
+}
+
+func TestAddURLJobs_ExpandsPlaylist(t *testing.T) {
+	playlist, err := parsePlaylistJSON([]byte(samplePlaylistJSON), "")
+	require.NoError(t, err)
+
+	bp := NewBatchProcessor(&playlistEngine{MockScribeEngine: NewMockScribeEngine(), playlist: playlist}, 2)
+	bp.Pause()
+	defer bp.Shutdown()
+
+	jobIDs, err := bp.AddURLJobs(context.Background(),
+		ScribeOptions{InputURL: "https://www.youtube.com/playlist?list=PL123", TargetLanguage: "Spanish", OutputDir: "/out"},
+		PlaylistFilter{End: 2}, WithPriority(PriorityHigh))
+	require.NoError(t, err)
+	require.Len(t, jobIDs, 2)
+
+	first, _ := bp.JobSnapshot(jobIDs[0])
+	assert.Equal(t, "https://www.youtube.com/watch?v=aaa", first.Options.InputURL)
+	assert.Equal(t, filepath.Join("/out", "PL123", "aaa"), first.Options.OutputDir)
+	assert.Equal(t, "Spanish", first.Options.TargetLanguage)
+	assert.Equal(t, 10*time.Minute, first.Options.InputDuration, "The listing's duration spares the estimate a probe")
+	assert.Equal(t, PriorityHigh, first.Priority)
+
+	second, _ := bp.JobSnapshot(jobIDs[1])
+	assert.Equal(t, filepath.Join("/out", "PL123", "bbb"), second.Options.OutputDir)
+
+	_, err = bp.AddURLJobs(context.Background(), ScribeOptions{InputURL: "https://example.com/list"},
+		PlaylistFilter{Start: 100})
+	assert.Error(t, err, "A filter matching nothing is an error")
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+}
+
+func TestResolvePlaylist_ClassifiesFailures(t *testing.T) {
+	argsLog := fakeTools(t)
+	ytDlp := filepath.Join(filepath.Dir(argsLog), "yt-dlp")
+
+	require.NoError(t, os.WriteFile(ytDlp, []byte("#!/bin/sh\necho 'ERROR: Unsupported URL: https://example.com/' >&2\nexit 1\n"), 0o755))
+	_, err := NewRealScribeEngine().(PlaylistResolver).ResolvePlaylist(context.Background(), "https://example.com/")
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+
+	require.NoError(t, os.WriteFile(ytDlp, []byte("#!/bin/sh\necho 'ERROR: Unable to download webpage: timed out' >&2\nexit 1\n"), 0o755))
+	_, err = NewRealScribeEngine().(PlaylistResolver).ResolvePlaylist(context.Background(), "https://example.com/list")
+	assert.Equal(t, ErrorClassTransient, ClassifyError(err))
+}
+
+func TestAddURLJobs_SingleVideo(t *testing.T) {
+	single := &Playlist{ID: "xyz", Entries: []PlaylistEntry{{Index: 1, ID: "xyz", URL: "https://example.com/v"}}}
+	bp := NewBatchProcessor(&playlistEngine{MockScribeEngine: NewMockScribeEngine(), playlist: single}, 1)
+	bp.Pause()
+	defer bp.Shutdown()
+
+	jobIDs, err := bp.AddURLJobs(context.Background(), ScribeOptions{InputURL: "https://example.com/v", OutputDir: "/out"}, PlaylistFilter{})
+	require.NoError(t, err)
+	require.Len(t, jobIDs, 1)
+
+	job, _ := bp.JobSnapshot(jobIDs[0])
+	assert.Equal(t, "/out", job.Options.OutputDir, "Single videos keep their options unchanged")
+}
+
+func TestAddURLJobs_EngineWithoutResolver(t *testing.T) {
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	bp.Pause()
+	defer bp.Shutdown()
+
+	jobIDs, err := bp.AddURLJobs(context.Background(), ScribeOptions{InputURL: "https://example.com/playlist"}, PlaylistFilter{})
+	require.NoError(t, err)
+	assert.Len(t, jobIDs, 1)
+
+	_, err = bp.AddURLJobs(context.Background(), ScribeOptions{InputFile: "local.mp4"}, PlaylistFilter{})
+	assert.Error(t, err)
+}
+
+func TestURLOutputName(t *testing.T) {
+	assert.Equal(t, "abc", URLOutputName("https://www.youtube.com/watch?v=abc&t=10"))
+	assert.Equal(t, "abc", URLOutputName("https://youtu.be/abc"))
+	assert.Equal(t, "PL123", URLOutputName("https://www.youtube.com/playlist?list=PL123"))
+	assert.Equal(t, "76543", URLOutputName("https://vimeo.com/76543/"))
+	assert.Regexp(t, `^example.com_[0-9a-f]{8}$`, URLOutputName("https://example.com/"))
+	assert.NotEqual(t, URLOutputName("https://example.com/"), URLOutputName("https://example.org/"))
+}
+
+func TestSafePathComponent(t *testing.T) {
+	assert.Equal(t, "dQw4w9WgXcQ", safePathComponent("dQw4w9WgXcQ"))
+	assert.Equal(t, "a_b_c", safePathComponent("a/b\\c"))
+	assert.Equal(t, "_", safePathComponent(".."))
+	assert.Equal(t, "_", safePathComponent(""))
+}
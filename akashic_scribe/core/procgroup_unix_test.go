@@ -0,0 +1,40 @@
+//go:build unix
+
+package core
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCommand_CancelKillsProcessGroup(t *testing.T) {
+	// The script starts a child, like yt-dlp starting ffmpeg, and waits for it
+	started := filepath.Join(t.TempDir(), "started")
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := newCommand(ctx, "sh", "-c", `sleep 30 & touch "$0"; wait`, started)
+
+	done := make(chan error, 1)
+	go func() { done <- runLoggedCommand(ctx, cmd) }()
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(started)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The child holds the output pipe open, so the command only returns
+	// before WaitDelay if the child was killed along with the shell
+	cancel()
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, exec.ErrWaitDelay)
+	case <-time.After(commandWaitDelay / 2):
+		t.Fatal("Cancelled command did not return")
+	}
+}
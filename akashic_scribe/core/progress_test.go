@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -179,11 +180,11 @@ func TestProgressUpdateStruct(t *testing.T) {
 
 	// Test creating progress updates
 	updates := []ProgressUpdate{
-		{0.0, "Starting..."},
-		{0.25, "Processing..."},
-		{0.50, "Halfway done..."},
-		{0.75, "Almost there..."},
-		{1.0, "Complete!"},
+		{Percentage: 0.0, Message: "Starting..."},
+		{Percentage: 0.25, Message: "Processing..."},
+		{Percentage: 0.50, Message: "Halfway done..."},
+		{Percentage: 0.75, Message: "Almost there..."},
+		{Percentage: 1.0, Message: "Complete!"},
 	}
 
 	assert.Len(updates, 5)
@@ -196,6 +197,88 @@ func TestProgressUpdateStruct(t *testing.T) {
 	assert.Equal("Complete!", updates[4].Message)
 }
 
+// TestParseYtDlpTotalBytes tests parsing of the download size from yt-dlp progress lines.
+func TestParseYtDlpTotalBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(int64(100<<20), parseYtDlpTotalBytes("[download]  45.2% of 100.00MiB at 1.50MiB/s ETA 00:36"))
+	assert.Equal(int64(1536), parseYtDlpTotalBytes("[download]  10.0% of ~ 1.50KiB at 1.00KiB/s"))
+	assert.Equal(int64(2_000_000_000), parseYtDlpTotalBytes("[download]   1.0% of 2.00GB"))
+	assert.Zero(parseYtDlpTotalBytes("[download] 100% in 00:05"))
+}
+
+// TestParseFfmpegOutTime tests parsing of ffmpeg -progress output.
+func TestParseFfmpegOutTime(t *testing.T) {
+	assert := assert.New(t)
+
+	done, ok := parseFfmpegOutTime("out_time=00:01:23.500000")
+	assert.True(ok)
+	assert.Equal(83500*time.Millisecond, done)
+
+	_, ok = parseFfmpegOutTime("out_time_us=83500000")
+	assert.False(ok)
+	_, ok = parseFfmpegOutTime("progress=continue")
+	assert.False(ok)
+}
+
+// TestPipelineState_StageProgress tests that stage progress is mapped onto the overall progress.
+func TestPipelineState_StageProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewPipeline("progress")
+	for _, stage := range []Stage{
+		{Name: "first", Progress: 0.2, Message: "First...", Run: func(ctx context.Context, state *PipelineState) error {
+			state.ReportProgress(ProgressUpdate{StageProgress: 0.5, Message: "Halfway", BytesDone: 50, BytesTotal: 100})
+			return nil
+		}},
+		{Name: "skipped", Progress: 0.4, Skip: func(*PipelineState) bool { return true }, Run: func(context.Context, *PipelineState) error { return nil }},
+		{Name: "custom", Run: func(ctx context.Context, state *PipelineState) error {
+			state.ReportProgress(ProgressUpdate{StageProgress: 0.5, Message: "Custom"})
+			return nil
+		}},
+		{Name: "last", Progress: 0.6, Message: "Last...", Run: func(ctx context.Context, state *PipelineState) error {
+			state.Report(0.8, "Half of last")
+			return nil
+		}},
+	} {
+		assert.NoError(p.Append(stage))
+	}
+
+	progress, stop := drainProgress()
+	_, err := p.Run(context.Background(), NewPipelineState(ScribeOptions{}, progress))
+	updates := stop()
+	assert.NoError(err)
+
+	if assert.Len(updates, 5) {
+		assert.Equal(ProgressUpdate{Percentage: 0.2, Message: "First...", Stage: "first"}, updates[0])
+
+		// The skipped stage is not part of the first stage's share
+		assert.Equal("first", updates[1].Stage)
+		assert.InDelta(0.4, updates[1].Percentage, 1e-9)
+		assert.Equal(0.5, updates[1].StageProgress)
+		assert.Equal(int64(100), updates[1].BytesTotal)
+
+		// Stages without a Progress stay where the previous stage ended
+		assert.Equal("custom", updates[2].Stage)
+		assert.InDelta(0.6, updates[2].Percentage, 1e-9)
+
+		assert.Equal("last", updates[4].Stage)
+		assert.InDelta(0.5, updates[4].StageProgress, 1e-9)
+	}
+}
+
+// TestPipelineState_ETA tests the estimate of the time remaining.
+func TestPipelineState_ETA(t *testing.T) {
+	assert := assert.New(t)
+
+	state := NewPipelineState(ScribeOptions{}, nil)
+	state.started = time.Now().Add(-time.Minute)
+
+	assert.Zero(state.eta(0.01), "Too early for an estimate")
+	assert.Zero(state.eta(1.0))
+	assert.InDelta(float64(3*time.Minute), float64(state.eta(0.25)), float64(time.Second))
+}
+
 // TestProgressSequence tests that progress updates are monotonically increasing.
 func TestProgressSequence(t *testing.T) {
 	assert := assert.New(t)
@@ -0,0 +1,238 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProviderLimits are the limits on requests to one provider, shared by every
+// job in the process. Zero values mean unlimited.
+type ProviderLimits struct {
+	RequestsPerMinute   int // Requests started per minute
+	CharactersPerMinute int // Characters sent per minute, e.g. text for TTS
+	MaxConcurrent       int // Requests in flight at once
+}
+
+// ProviderLimiter enforces the ProviderLimits of one provider. After a
+// provider answers with Retry-After, it also holds every new request back
+// until that time has passed. It is safe for concurrent use.
+type ProviderLimiter struct {
+	provider     string
+	concurrency  *resourceSemaphore
+	mu           sync.Mutex
+	limits       ProviderLimits
+	requests     *tokenBucket // nil = unlimited
+	characters   *tokenBucket // nil = unlimited
+	blockedUntil time.Time    // Set from Retry-After
+}
+
+// providerLimiters holds the process-wide limiter of each provider.
+var providerLimiters = struct {
+	mu       sync.Mutex
+	limiters map[string]*ProviderLimiter
+}{limiters: make(map[string]*ProviderLimiter)}
+
+// ProviderLimiterFor returns the process-wide limiter of provider. Providers
+// without configured limits get an unlimited limiter that still honours
+// Retry-After.
+func ProviderLimiterFor(provider string) *ProviderLimiter {
+	providerLimiters.mu.Lock()
+	defer providerLimiters.mu.Unlock()
+
+	limiter, ok := providerLimiters.limiters[provider]
+	if !ok {
+		limiter = &ProviderLimiter{provider: provider, concurrency: newResourceSemaphore(0)}
+		providerLimiters.limiters[provider] = limiter
+	}
+	return limiter
+}
+
+// SetProviderLimits changes the process-wide limits of provider. Requests
+// already in flight are not interrupted.
+func SetProviderLimits(provider string, limits ProviderLimits) {
+	ProviderLimiterFor(provider).SetLimits(limits)
+}
+
+// ConfigureProviderLimits applies limits, keyed by provider name, to the
+// process-wide limiters. Providers that are not listed keep their limits.
+func ConfigureProviderLimits(limits map[string]ProviderLimits) {
+	for provider, l := range limits {
+		SetProviderLimits(provider, l)
+	}
+}
+
+// SetLimits changes the limiter's limits. Changing a rate refills its budget.
+func (l *ProviderLimiter) SetLimits(limits ProviderLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limits.RequestsPerMinute != l.limits.RequestsPerMinute {
+		l.requests = nil
+		if limits.RequestsPerMinute > 0 {
+			l.requests = newTokenBucket(float64(limits.RequestsPerMinute), time.Minute)
+		}
+	}
+	if limits.CharactersPerMinute != l.limits.CharactersPerMinute {
+		l.characters = nil
+		if limits.CharactersPerMinute > 0 {
+			l.characters = newTokenBucket(float64(limits.CharactersPerMinute), time.Minute)
+		}
+	}
+	l.limits = limits
+	l.concurrency.setLimit(limits.MaxConcurrent)
+}
+
+// Limits returns the limiter's current limits.
+func (l *ProviderLimiter) Limits() ProviderLimits {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limits
+}
+
+// Acquire blocks until a request sending characters characters may start,
+// or ctx is done. The returned function ends the request and must be called
+// exactly once, after the response has been read.
+func (l *ProviderLimiter) Acquire(ctx context.Context, characters int) (func(), error) {
+	if err := l.waitBlocked(ctx); err != nil {
+		return nil, l.cancelled(err)
+	}
+	if err := l.concurrency.acquire(ctx); err != nil {
+		return nil, l.cancelled(err)
+	}
+	var once sync.Once
+	release := func() { once.Do(l.concurrency.release) }
+
+	l.mu.Lock()
+	requests, chars := l.requests, l.characters
+	l.mu.Unlock()
+
+	if requests != nil {
+		if err := requests.wait(ctx, 1); err != nil {
+			release()
+			return nil, l.cancelled(err)
+		}
+	}
+	if chars != nil && characters > 0 {
+		if err := chars.wait(ctx, float64(characters)); err != nil {
+			release()
+			return nil, l.cancelled(err)
+		}
+	}
+	return release, nil
+}
+
+// Backoff holds new requests back for d, e.g. after a Retry-After response.
+// A shorter backoff never shortens one already in effect.
+func (l *ProviderLimiter) Backoff(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+}
+
+// waitBlocked waits until any Retry-After backoff has passed.
+func (l *ProviderLimiter) waitBlocked(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		delay := time.Until(l.blockedUntil)
+		l.mu.Unlock()
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// cancelled wraps the context error of a request that gave up waiting.
+func (l *ProviderLimiter) cancelled(err error) error {
+	return fmt.Errorf("operation cancelled while waiting for %s rate limit: %w", l.provider, err)
+}
+
+// observeResponse backs off for the Retry-After of a 429 or 503 response.
+func (l *ProviderLimiter) observeResponse(ctx context.Context, resp *http.Response) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return
+	}
+	if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		LoggerFrom(ctx).Warn("Provider asked to retry later; holding requests back",
+			"provider", l.provider, "status", resp.StatusCode, "retry_after", delay)
+		l.Backoff(delay)
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := at.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// releasingBody releases a provider request slot when the response body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close closes the body and releases the slot.
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// Do sends req to provider through its shared client, subject to the
+// provider's process-wide limits. characters is the amount of text the
+// request sends, for CharactersPerMinute. The request slot is held until the
+// response body is closed, and Retry-After responses hold back the
+// provider's later requests.
+func (p *Providers) Do(ctx context.Context, provider string, req *http.Request, characters int) (*http.Response, error) {
+	client, err := p.Client(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := ProviderLimiterFor(provider)
+	release, err := limiter.Acquire(ctx, characters)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	limiter.observeResponse(ctx, resp)
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
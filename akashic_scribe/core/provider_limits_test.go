@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	delay, ok := parseRetryAfter("30", now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, delay)
+
+	delay, ok = parseRetryAfter(now.Add(2*time.Minute).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, delay)
+
+	delay, ok = parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Zero(t, delay, "Dates in the past allow an immediate retry")
+
+	for _, invalid := range []string{"", "-5", "soon"} {
+		_, ok := parseRetryAfter(invalid, now)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestProviderLimiter_ConcurrencyAndCharacters(t *testing.T) {
+	limiter := ProviderLimiterFor(t.Name())
+	limiter.SetLimits(ProviderLimits{MaxConcurrent: 1, CharactersPerMinute: 6000})
+	assert.Same(t, limiter, ProviderLimiterFor(t.Name()), "Limiters are process-wide")
+
+	release, err := limiter.Acquire(context.Background(), 6000)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(ctx, 0)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "Only one request may be in flight")
+	release()
+	release() // Releasing twice is harmless
+
+	// The character budget is spent: 10 more characters take 100ms at 100 per second
+	start := time.Now()
+	release, err = limiter.Acquire(context.Background(), 10)
+	require.NoError(t, err)
+	release()
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+}
+
+func TestProviders_DoHonoursRetryAfterAndLimits(t *testing.T) {
+	provider := t.Name()
+	limiter := ProviderLimiterFor(provider)
+	limiter.SetLimits(ProviderLimits{MaxConcurrent: 1})
+
+	status := http.StatusTooManyRequests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	providers := NewProviders(map[string]ProviderConfig{provider: {BaseURL: server.URL}})
+	req, err := providers.NewRequest(context.Background(), provider, "GET", "/", nil)
+	require.NoError(t, err)
+	resp, err := providers.Do(context.Background(), provider, req, 0)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// The slot is held until the body is closed
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(ctx, 0)
+	assert.Error(t, err)
+	resp.Body.Close()
+
+	limiter.mu.Lock()
+	blockedFor := time.Until(limiter.blockedUntil)
+	limiter.mu.Unlock()
+	assert.InDelta(t, time.Second.Seconds(), blockedFor.Seconds(), 0.2, "Retry-After holds later requests back")
+
+	// Waiting for a backoff respects cancellation
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(ctx, 0)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestConfig_ProviderLimits(t *testing.T) {
+	config := DefaultConfig()
+	config.ProviderRequestsPerMinute = map[string]int{ProviderOpenAI: 50, "legacy": 10}
+	config.Providers = map[string]ProviderConfig{
+		ProviderOpenAI: {CharactersPerMinute: 100_000, MaxConcurrentRequests: 4},
+		"local":        {RequestsPerMinute: 600},
+	}
+	require.NoError(t, config.Validate())
+
+	assert.Equal(t, map[string]ProviderLimits{
+		ProviderOpenAI: {RequestsPerMinute: 50, CharactersPerMinute: 100_000, MaxConcurrent: 4},
+		"legacy":       {RequestsPerMinute: 10},
+		"local":        {RequestsPerMinute: 600},
+	}, config.ProviderLimits())
+
+	config.Providers = map[string]ProviderConfig{"local": {MaxConcurrentRequests: -1}}
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,333 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default provider connection settings.
+const (
+	DefaultProviderTimeout        = 5 * time.Minute
+	DefaultProviderConnectTimeout = 30 * time.Second
+)
+
+// ProviderConfig describes how to reach an HTTP-based provider. Zero fields
+// take the provider's defaults from DefaultProviderConfigs.
+type ProviderConfig struct {
+	Type                  string            `json:"type,omitempty"`                    // API the provider speaks: ProviderTypeBuiltin, ProviderTypeOpenAI or ProviderTypeLibreTranslate
+	BaseURL               string            `json:"base_url,omitempty"`                // API root, e.g. "https://api.openai.com/v1"
+	Model                 string            `json:"model,omitempty"`                   // TTS model name, e.g. "tts-1-hd"
+	ChatModel             string            `json:"chat_model,omitempty"`              // Chat model used for translation, e.g. "gpt-4o-mini"
+	NoAPIKey              bool              `json:"no_api_key,omitempty"`              // Send no API key, e.g. to a local server
+	TimeoutSeconds        int               `json:"timeout_seconds,omitempty"`         // Limit on a whole request, including the response body
+	ConnectTimeoutSeconds int               `json:"connect_timeout_seconds,omitempty"` // Limit on connecting and the TLS handshake
+	ProxyURL              string            `json:"proxy_url,omitempty"`               // HTTP proxy (empty = HTTPS_PROXY and related variables)
+	Headers               map[string]string `json:"headers,omitempty"`                 // Added to every request
+	CABundle              string            `json:"ca_bundle,omitempty"`               // PEM file of CAs trusted in addition to the system pool
+
+	// Process-wide limits, shared by all jobs (0 = unlimited)
+	RequestsPerMinute     int `json:"requests_per_minute,omitempty"`
+	CharactersPerMinute   int `json:"characters_per_minute,omitempty"`
+	MaxConcurrentRequests int `json:"max_concurrent_requests,omitempty"`
+}
+
+// Provider types, i.e. the APIs the engine can talk to.
+const (
+	ProviderTypeBuiltin        = "builtin"        // The built-in placeholder engine
+	ProviderTypeOpenAI         = "openai"         // OpenAI and compatible APIs: chat completions and /audio/speech
+	ProviderTypeLibreTranslate = "libretranslate" // LibreTranslate's /translate
+)
+
+// providerTypeCapabilities lists the capabilities each provider type offers.
+var providerTypeCapabilities = map[string][]string{
+	ProviderTypeBuiltin:        {CapabilityTranslation},
+	ProviderTypeOpenAI:         {CapabilityTranslation, CapabilityTTS},
+	ProviderTypeLibreTranslate: {CapabilityTranslation},
+}
+
+// DefaultProviderConfigs returns the settings of the providers the engine
+// uses: the built-in translator and the OpenAI API with tts-1-hd for dubbing.
+func DefaultProviderConfigs() map[string]ProviderConfig {
+	return map[string]ProviderConfig{
+		ProviderBuiltin: {
+			Type: ProviderTypeBuiltin,
+		},
+		ProviderOpenAI: {
+			Type:      ProviderTypeOpenAI,
+			BaseURL:   "https://api.openai.com/v1",
+			Model:     "tts-1-hd",
+			ChatModel: "gpt-4o-mini",
+		},
+	}
+}
+
+// Supports reports whether the provider offers capability.
+func (c ProviderConfig) Supports(capability string) bool {
+	for _, offered := range providerTypeCapabilities[c.Type] {
+		if offered == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the URLs parse and that no timeout or limit is negative. The
+// CA bundle is read when the provider's client is built.
+func (c ProviderConfig) Validate() error {
+	if _, ok := providerTypeCapabilities[c.Type]; c.Type != "" && !ok {
+		return fmt.Errorf("unknown provider type %q", c.Type)
+	}
+	if c.BaseURL != "" {
+		if err := validateHTTPURL(c.BaseURL); err != nil {
+			return fmt.Errorf("invalid base URL: %w", err)
+		}
+	}
+	if c.ProxyURL != "" {
+		if err := validateHTTPURL(c.ProxyURL); err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+	}
+	if c.TimeoutSeconds < 0 || c.ConnectTimeoutSeconds < 0 {
+		return errors.New("timeouts cannot be negative")
+	}
+	if c.RequestsPerMinute < 0 || c.CharactersPerMinute < 0 || c.MaxConcurrentRequests < 0 {
+		return errors.New("limits cannot be negative")
+	}
+	for name := range c.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	return nil
+}
+
+// validateHTTPURL checks that raw is an absolute http or https URL.
+func validateHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http or https URL", raw)
+	}
+	return nil
+}
+
+// withDefaults fills the zero fields of c from def. Headers are merged, with
+// those of c taking precedence.
+func (c ProviderConfig) withDefaults(def ProviderConfig) ProviderConfig {
+	if c.Type == "" {
+		c.Type = def.Type
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = def.BaseURL
+	}
+	if c.Model == "" {
+		c.Model = def.Model
+	}
+	if c.ChatModel == "" {
+		c.ChatModel = def.ChatModel
+	}
+	if c.TimeoutSeconds == 0 {
+		c.TimeoutSeconds = def.TimeoutSeconds
+	}
+	if c.ConnectTimeoutSeconds == 0 {
+		c.ConnectTimeoutSeconds = def.ConnectTimeoutSeconds
+	}
+	if c.ProxyURL == "" {
+		c.ProxyURL = def.ProxyURL
+	}
+	if c.CABundle == "" {
+		c.CABundle = def.CABundle
+	}
+	if c.RequestsPerMinute == 0 {
+		c.RequestsPerMinute = def.RequestsPerMinute
+	}
+	if c.CharactersPerMinute == 0 {
+		c.CharactersPerMinute = def.CharactersPerMinute
+	}
+	if c.MaxConcurrentRequests == 0 {
+		c.MaxConcurrentRequests = def.MaxConcurrentRequests
+	}
+	if len(def.Headers) > 0 {
+		headers := make(map[string]string, len(def.Headers)+len(c.Headers))
+		for name, value := range def.Headers {
+			headers[name] = value
+		}
+		for name, value := range c.Headers {
+			headers[name] = value
+		}
+		c.Headers = headers
+	}
+	return c
+}
+
+// Limits returns the provider's process-wide limits.
+func (c ProviderConfig) Limits() ProviderLimits {
+	return ProviderLimits{
+		RequestsPerMinute:   c.RequestsPerMinute,
+		CharactersPerMinute: c.CharactersPerMinute,
+		MaxConcurrent:       c.MaxConcurrentRequests,
+	}
+}
+
+// timeout returns the whole-request timeout.
+func (c ProviderConfig) timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return DefaultProviderTimeout
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// connectTimeout returns the connect and TLS handshake timeout.
+func (c ProviderConfig) connectTimeout() time.Duration {
+	if c.ConnectTimeoutSeconds <= 0 {
+		return DefaultProviderConnectTimeout
+	}
+	return time.Duration(c.ConnectTimeoutSeconds) * time.Second
+}
+
+// newHTTPClient builds a client that honours the proxy, timeouts and CA bundle.
+func (c ProviderConfig) newHTTPClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: c.connectTimeout(), KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = c.connectTimeout()
+
+	if c.ProxyURL != "" {
+		proxy, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if c.CABundle != "" {
+		pem, err := os.ReadFile(c.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", c.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &http.Client{Transport: transport, Timeout: c.timeout()}, nil
+}
+
+// Providers holds the configuration of the HTTP-based providers and one
+// shared HTTP client per provider, so connections are reused across requests
+// and jobs. It is safe for concurrent use.
+type Providers struct {
+	configs map[string]ProviderConfig
+	chains  map[string][]string // Fallback chains, keyed by capability
+	mu      sync.Mutex
+	clients map[string]*http.Client
+}
+
+// NewProviders returns providers configured by configs, keyed by provider
+// name, on top of DefaultProviderConfigs.
+func NewProviders(configs map[string]ProviderConfig) *Providers {
+	merged := DefaultProviderConfigs()
+	for name, config := range configs {
+		merged[name] = config.withDefaults(merged[name])
+	}
+	return &Providers{configs: merged, chains: DefaultProviderChains(), clients: make(map[string]*http.Client)}
+}
+
+// NewProviders returns the configured providers with the configured
+// fallback chains.
+func (c *Config) NewProviders() *Providers {
+	providers := NewProviders(c.Providers)
+	for capability, chain := range c.Fallbacks {
+		providers.chains[capability] = chain
+	}
+	return providers
+}
+
+// Config returns the configuration of provider.
+func (p *Providers) Config(provider string) ProviderConfig {
+	return p.configs[provider]
+}
+
+// Client returns the shared HTTP client of provider, building it on first use.
+func (p *Providers) Client(provider string) (*http.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[provider]; ok {
+		return client, nil
+	}
+	client, err := p.configs[provider].newHTTPClient()
+	if err != nil {
+		return nil, NewScribeError(ErrorClassInvalidInput, "provider", fmt.Errorf("%s: %w", provider, err))
+	}
+	p.clients[provider] = client
+	return client, nil
+}
+
+// NewRequest creates a request to path under the provider's base URL, with
+// the configured headers set.
+func (p *Providers) NewRequest(ctx context.Context, provider, method, path string, body io.Reader) (*http.Request, error) {
+	config := p.configs[provider]
+	if config.BaseURL == "" {
+		return nil, NewScribeError(ErrorClassInvalidInput, "provider", fmt.Errorf("no base URL configured for %s", provider))
+	}
+
+	endpoint := strings.TrimSuffix(config.BaseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range config.Headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// defaultProviders is used when no providers are attached to the context.
+var defaultProviders = sync.OnceValue(func() *Providers {
+	return NewProviders(nil)
+})
+
+// providersKey is the context key for the providers used by a job.
+type providersKey struct{}
+
+// WithProviders returns a context under which engines reach HTTP-based
+// providers through providers. BatchProcessor attaches its providers this way.
+func WithProviders(ctx context.Context, providers *Providers) context.Context {
+	return context.WithValue(ctx, providersKey{}, providers)
+}
+
+// ProvidersFrom returns the providers attached to ctx, or ones with the
+// default configuration.
+func ProvidersFrom(ctx context.Context) *Providers {
+	if providers, ok := ctx.Value(providersKey{}).(*Providers); ok {
+		return providers
+	}
+	return defaultProviders()
+}
+
+// SetProviders replaces the providers used by jobs started afterwards. nil
+// selects the default configuration.
+func (bp *BatchProcessor) SetProviders(providers *Providers) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	bp.providers = providers
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProviders_MergesDefaults(t *testing.T) {
+	providers := NewProviders(map[string]ProviderConfig{
+		ProviderOpenAI: {Model: "tts-1", TimeoutSeconds: 10},
+		"local":        {BaseURL: "http://localhost:8080/v1"},
+	})
+
+	openai := providers.Config(ProviderOpenAI)
+	assert.Equal(t, "https://api.openai.com/v1", openai.BaseURL)
+	assert.Equal(t, "tts-1", openai.Model)
+	assert.Equal(t, 10*time.Second, openai.timeout())
+	assert.Equal(t, DefaultProviderConnectTimeout, openai.connectTimeout())
+	assert.Equal(t, "http://localhost:8080/v1", providers.Config("local").BaseURL)
+
+	client, err := providers.Client(ProviderOpenAI)
+	require.NoError(t, err)
+	again, err := providers.Client(ProviderOpenAI)
+	require.NoError(t, err)
+	assert.Same(t, client, again, "Clients are shared")
+	assert.Equal(t, 10*time.Second, client.Timeout)
+
+	for _, invalid := range []ProviderConfig{
+		{BaseURL: "api.openai.com"},
+		{ProxyURL: "socks://proxy"},
+		{TimeoutSeconds: -1},
+		{Headers: map[string]string{"Bad Header": "x"}},
+	} {
+		assert.Error(t, invalid.Validate(), "%+v", invalid)
+		config := DefaultConfig()
+		config.Providers = map[string]ProviderConfig{ProviderOpenAI: invalid}
+		assert.Error(t, config.Validate())
+	}
+}
+
+func TestGenerateOpenAITTS_UsesProviderConfig(t *testing.T) {
+	var got struct {
+		path, auth, org string
+		body            map[string]any
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path = r.URL.Path
+		got.auth = r.Header.Get("Authorization")
+		got.org = r.Header.Get("OpenAI-Organization")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got.body))
+		w.Write([]byte("mp3 audio"))
+	}))
+	defer server.Close()
+
+	ctx := WithProviders(context.Background(), NewProviders(map[string]ProviderConfig{
+		ProviderOpenAI: {
+			BaseURL: server.URL + "/v1/",
+			Model:   "local-tts",
+			Headers: map[string]string{"OpenAI-Organization": "org-123"},
+		},
+	}))
+	output := filepath.Join(t.TempDir(), "speech.mp3")
+	engine := &realScribeEngine{}
+	require.NoError(t, engine.generateOpenAITTS(ctx, ProviderOpenAI, "hello", "alloy", 1.0, "sk-test", output))
+
+	assert.Equal(t, "/v1/audio/speech", got.path)
+	assert.Equal(t, "Bearer sk-test", got.auth)
+	assert.Equal(t, "org-123", got.org)
+	assert.Equal(t, "local-tts", got.body["model"])
+	audio, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "mp3 audio", string(audio))
+}
+
+func TestProviders_ProxyAndCABundle(t *testing.T) {
+	// A plain HTTP proxy receives requests with absolute URLs
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	providers := NewProviders(map[string]ProviderConfig{
+		"local": {BaseURL: "http://tts.internal/v1", ProxyURL: proxy.URL},
+	})
+	req, err := providers.NewRequest(context.Background(), "local", "GET", "models", nil)
+	require.NoError(t, err)
+	client, err := providers.Client("local")
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "http://tts.internal/v1/models", proxied)
+
+	// A server with a private CA is only trusted with the bundle
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+	require.NoError(t, os.WriteFile(bundle, certPEM, 0o644))
+
+	for _, tc := range []struct {
+		config  ProviderConfig
+		trusted bool
+	}{
+		{ProviderConfig{BaseURL: tlsServer.URL}, false},
+		{ProviderConfig{BaseURL: tlsServer.URL, CABundle: bundle}, true},
+	} {
+		providers := NewProviders(map[string]ProviderConfig{"private": tc.config})
+		req, err := providers.NewRequest(context.Background(), "private", "GET", "/", nil)
+		require.NoError(t, err)
+		client, err := providers.Client("private")
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if tc.trusted {
+			require.NoError(t, err)
+			resp.Body.Close()
+		} else {
+			assert.Error(t, err)
+		}
+	}
+
+	_, err = NewProviders(map[string]ProviderConfig{
+		"broken": {CABundle: filepath.Join(t.TempDir(), "missing.pem")},
+	}).Client("broken")
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+}
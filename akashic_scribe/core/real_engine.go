@@ -8,15 +8,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // realScribeEngine is the actual implementation of the ScribeEngine interface.
@@ -32,12 +35,14 @@ func NewRealScribeEngine() ScribeEngine {
 func (e *realScribeEngine) checkDependencies() error {
 	// Check for yt-dlp
 	if _, err := exec.LookPath("yt-dlp"); err != nil {
-		return fmt.Errorf("yt-dlp not found: %w. Please install yt-dlp to process video URLs", err)
+		return NewScribeError(ErrorClassDependency, "dependency check",
+			fmt.Errorf("yt-dlp not found: %w. Please install yt-dlp to process video URLs: %s", err, toolFix(installFixes, "yt-dlp", runtime.GOOS)))
 	}
 
 	// Check for ffmpeg
 	if _, err := exec.LookPath("ffmpeg"); err != nil {
-		return fmt.Errorf("ffmpeg not found: %w. Please install ffmpeg to process video/audio files", err)
+		return NewScribeError(ErrorClassDependency, "dependency check",
+			fmt.Errorf("ffmpeg not found: %w. Please install ffmpeg to process video/audio files: %s", err, toolFix(installFixes, "ffmpeg", runtime.GOOS)))
 	}
 
 	return nil
@@ -57,6 +62,30 @@ func parseYtDlpProgress(line string) (float64, bool) {
 	return 0, false
 }
 
+// ytDlpSizePattern matches the total size in yt-dlp progress lines, e.g. "of ~100.00MiB".
+var ytDlpSizePattern = regexp.MustCompile(`of\s+~?\s*(\d+\.?\d*)([KMGT]?i?B)\b`)
+
+// ytDlpSizeUnits are the byte multipliers of the units yt-dlp prints.
+var ytDlpSizeUnits = map[string]float64{
+	"B": 1, "KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30, "TiB": 1 << 40,
+	"KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12,
+}
+
+// parseYtDlpTotalBytes extracts the total download size from a yt-dlp
+// progress line. It returns 0 when the size is unknown.
+func parseYtDlpTotalBytes(line string) int64 {
+	matches := ytDlpSizePattern.FindStringSubmatch(line)
+	if len(matches) < 3 {
+		return 0
+	}
+	size, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0
+	}
+
+	return int64(size * ytDlpSizeUnits[matches[2]])
+}
+
 // parseFfmpegProgress parses ffmpeg output to extract encoding progress.
 // ffmpeg outputs progress like: "time=00:01:23.45 bitrate=1234.5kbits/s speed=2.5x"
 func parseFfmpegProgress(line string, duration float64) (float64, bool) {
@@ -81,46 +110,56 @@ func parseFfmpegProgress(line string, duration float64) (float64, bool) {
 }
 
 // getVideoDuration extracts the actual duration of a video file using ffprobe.
-// Returns duration in seconds. Falls back to 180 seconds (3 minutes) if extraction fails.
+// Falls back to 3 minutes if extraction fails.
 func (e *realScribeEngine) getVideoDuration(ctx context.Context, videoPath string) time.Duration {
-	// Use ffprobe to get video duration
+	duration, err := probeDuration(ctx, videoPath)
+	if err != nil {
+		LoggerFrom(ctx).Warn("Failed to get video duration; using default 3 minutes", "error", err)
+		return 3 * time.Minute
+	}
+
+	LoggerFrom(ctx).Debug("Video duration detected", "duration", duration)
+	return duration
+}
+
+// probeDuration reads the duration of a media file with ffprobe.
+func probeDuration(ctx context.Context, path string) (time.Duration, error) {
 	// ffprobe -v error -show_entries format=duration -of default=noprint_wrappers=1:nokey=1 <file>
-	cmd := exec.CommandContext(ctx, "ffprobe",
+	cmd := newCommand(ctx, "ffprobe",
 		"-v", "error",
 		"-show_entries", "format=duration",
 		"-of", "default=noprint_wrappers=1:nokey=1",
-		videoPath)
+		path)
 
+	logCommand(ctx, cmd)
 	output, err := cmd.Output()
 	if err != nil {
-		log.Printf("Warning: Failed to get video duration with ffprobe: %v. Using default 3 minutes.", err)
-		return 3 * time.Minute
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			logToolOutput(ctx, cmd, exitErr.Stderr)
+		}
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
 	}
 
 	// Parse the duration (in seconds)
 	durationStr := strings.TrimSpace(string(output))
 	durationSec, err := strconv.ParseFloat(durationStr, 64)
 	if err != nil {
-		log.Printf("Warning: Failed to parse video duration '%s': %v. Using default 3 minutes.", durationStr, err)
-		return 3 * time.Minute
+		return 0, fmt.Errorf("failed to parse duration %q: %w", durationStr, err)
 	}
-
-	duration := time.Duration(durationSec * float64(time.Second))
-	log.Printf("Video duration detected: %.2f seconds (%.2f minutes)", durationSec, durationSec/60)
-	return duration
+	return time.Duration(durationSec * float64(time.Second)), nil
 }
 
 // runCommandWithProgress runs a command and reports progress via a channel.
-// It monitors stdout/stderr and calls the progress parser function.
-// The command can be cancelled via the context.
+// Each line of stdout and stderr is passed to parseFunc, and the updates it
+// returns are sent on progressChan. The command can be cancelled via the
+// context. On failure, the error includes the last lines of stderr. Both
+// streams are copied into the job log attached to ctx.
 func (e *realScribeEngine) runCommandWithProgress(
 	ctx context.Context,
 	cmd *exec.Cmd,
-	baseProgress float64,
-	progressRange float64,
 	progressChan chan<- ProgressUpdate,
-	statusMsg string,
-	parseFunc func(string) (float64, bool),
+	parseFunc func(string) (ProgressUpdate, bool),
 ) error {
 	// Create pipes for stderr (where yt-dlp and ffmpeg output progress)
 	stderr, err := cmd.StderrPipe()
@@ -134,50 +173,51 @@ func (e *realScribeEngine) runCommandWithProgress(
 		return fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
+	logCommand(ctx, cmd)
 	if err := cmd.Start(); err != nil {
 		return fmt.Errorf("failed to start command: %w", err)
 	}
 
-	// Channel to signal when command completes
-	done := make(chan error, 1)
-
-	// Read progress from both stdout and stderr
-	go func() {
-		scanner := bufio.NewScanner(stderr)
+	// scan reports progress from one output stream; it keeps reading after
+	// cancellation so the command never blocks on a full pipe
+	var tail []string
+	scan := func(r io.Reader, keepTail bool) {
+		scanner := bufio.NewScanner(r)
 		for scanner.Scan() {
 			line := scanner.Text()
-			if parseFunc != nil {
-				if progress, ok := parseFunc(line); ok {
-					actualProgress := baseProgress + (progress * progressRange)
-					select {
-					case progressChan <- ProgressUpdate{actualProgress, statusMsg}:
-					case <-ctx.Done():
-						return
-					}
+			logToolLine(ctx, cmd, line)
+			if keepTail {
+				if tail = append(tail, line); len(tail) > 5 {
+					tail = tail[1:]
 				}
 			}
-		}
-	}()
-
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if parseFunc != nil {
-				if progress, ok := parseFunc(line); ok {
-					actualProgress := baseProgress + (progress * progressRange)
-					select {
-					case progressChan <- ProgressUpdate{actualProgress, statusMsg}:
-					case <-ctx.Done():
-						return
-					}
+			if parseFunc == nil || progressChan == nil || ctx.Err() != nil {
+				continue
+			}
+			if update, ok := parseFunc(line); ok {
+				select {
+				case progressChan <- update:
+				case <-ctx.Done():
 				}
 			}
 		}
+	}
+
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		scan(stderr, true)
+	}()
+	go func() {
+		defer readers.Done()
+		scan(stdout, false)
 	}()
 
-	// Wait for command to complete in goroutine
+	// Wait for command to complete in goroutine; pipes must be drained before Wait
+	done := make(chan error, 1)
 	go func() {
+		readers.Wait()
 		done <- cmd.Wait()
 	}()
 
@@ -187,7 +227,7 @@ func (e *realScribeEngine) runCommandWithProgress(
 		// Context cancelled - kill the process
 		if cmd.Process != nil {
 			if err := cmd.Process.Kill(); err != nil {
-				log.Printf("Warning: failed to kill process: %v", err)
+				LoggerFrom(ctx).Warn("Failed to kill process", "error", err)
 			}
 		}
 		// Wait for the command to actually exit
@@ -195,12 +235,57 @@ func (e *realScribeEngine) runCommandWithProgress(
 		return fmt.Errorf("operation cancelled: %w", ctx.Err())
 	case err := <-done:
 		// Command completed
+		if err != nil && len(tail) > 0 {
+			return fmt.Errorf("%w: %s", err, strings.Join(tail, "\n"))
+		}
+		return err
+	}
+}
+
+// runDownload runs a yt-dlp download command while holding a download slot.
+func (e *realScribeEngine) runDownload(ctx context.Context, cmd *exec.Cmd, progress chan<- ProgressUpdate, parseFunc func(string) (ProgressUpdate, bool)) error {
+	release, err := AcquireResource(ctx, ResourceDownload)
+	if err != nil {
 		return err
 	}
+	defer release()
+
+	return e.runCommandWithProgress(ctx, cmd, progress, parseFunc)
+}
+
+// transcribeWithLimits runs transcribe while holding an ffmpeg slot, since
+// audio extraction is the CPU-heavy part of transcription.
+func (e *realScribeEngine) transcribeWithLimits(ctx context.Context, videoPath string) (string, error) {
+	release, err := AcquireResource(ctx, ResourceFFmpeg)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	return e.TranscribeContext(ctx, videoPath)
+}
+
+// generateDubbingWithLimits runs generateDubbing while holding an ffmpeg
+// slot, since dubbing finishes with an ffmpeg conversion. Each TTS provider
+// tried waits for its own rate limit.
+func (e *realScribeEngine) generateDubbingWithLimits(ctx context.Context, translation string, opts ScribeOptions, outputDir string) (string, served, error) {
+	release, err := AcquireResource(ctx, ResourceFFmpeg)
+	if err != nil {
+		return "", served{}, err
+	}
+	defer release()
+
+	return e.generateDubbing(ctx, translation, opts, outputDir)
 }
 
 // Transcribe takes a video source (local path or URL) and returns the transcription.
 func (e *realScribeEngine) Transcribe(videoSource string) (string, error) {
+	return e.TranscribeContext(context.Background(), videoSource)
+}
+
+// TranscribeContext implements Transcribe. yt-dlp and ffmpeg are killed when
+// ctx is cancelled, and their output goes to the job log attached to ctx.
+func (e *realScribeEngine) TranscribeContext(ctx context.Context, videoSource string) (string, error) {
 	// Check dependencies
 	if err := e.checkDependencies(); err != nil {
 		return "", err
@@ -213,7 +298,7 @@ func (e *realScribeEngine) Transcribe(videoSource string) (string, error) {
 	}
 	defer func() {
 		if err := os.RemoveAll(tempDir); err != nil {
-			log.Printf("Warning: failed to clean up temp directory %s: %v", tempDir, err)
+			LoggerFrom(ctx).Warn("Failed to clean up temp directory", "dir", tempDir, "error", err)
 		}
 	}()
 
@@ -221,11 +306,16 @@ func (e *realScribeEngine) Transcribe(videoSource string) (string, error) {
 
 	// Check if the videoSource is a URL or a local file.
 	if strings.HasPrefix(videoSource, "http") {
-		// Download the video from the URL using yt-dlp.
+		// Download the video from the URL using yt-dlp, selecting the
+		// format as the download stage does; there is no time range here.
 		videoPath = filepath.Join(tempDir, "downloaded_video.%(ext)s")
-		cmd := exec.Command("yt-dlp", "-o", videoPath, videoSource)
-		if err := cmd.Run(); err != nil {
-			return "", fmt.Errorf("failed to download video: %w", err)
+		args := append([]string{"--no-playlist"}, ytDlpDownloadArgs(ScribeOptions{})...)
+		cmd := newCommand(ctx, "yt-dlp", append(args, "-o", videoPath, videoSource)...)
+		if err := e.runDownload(ctx, cmd, nil, nil); err != nil {
+			if ctx.Err() != nil {
+				return "", err
+			}
+			return "", downloadError(err)
 		}
 
 		// Find the actual downloaded file
@@ -237,15 +327,15 @@ func (e *realScribeEngine) Transcribe(videoSource string) (string, error) {
 	} else {
 		// Verify local file exists
 		if _, err := os.Stat(videoSource); err != nil {
-			return "", fmt.Errorf("input file not found: %w", err)
+			return "", NewScribeError(ErrorClassInvalidInput, "input", fmt.Errorf("input file not found: %w", err))
 		}
 		videoPath = videoSource
 	}
 
 	// Extract the audio from the video file using ffmpeg.
 	audioPath := filepath.Join(tempDir, "extracted_audio.wav")
-	cmd := exec.Command("ffmpeg", "-i", videoPath, "-vn", "-acodec", "pcm_s16le", "-ar", "16000", "-ac", "1", audioPath)
-	if err := cmd.Run(); err != nil {
+	cmd := newCommand(ctx, "ffmpeg", "-i", videoPath, "-vn", "-acodec", "pcm_s16le", "-ar", "16000", "-ac", "1", audioPath)
+	if err := runLoggedCommand(ctx, cmd); err != nil {
 		return "", fmt.Errorf("failed to extract audio: %w", err)
 	}
 
@@ -264,6 +354,22 @@ func (e *realScribeEngine) Transcribe(videoSource string) (string, error) {
 
 // Translate takes a text and a target language, and returns the translation.
 func (e *realScribeEngine) Translate(text string, targetLanguage string) (string, error) {
+	return e.TranslateContext(context.Background(), text, targetLanguage)
+}
+
+// TranslateContext implements Translate with the first provider of the
+// translation chain attached to ctx that succeeds.
+func (e *realScribeEngine) TranslateContext(ctx context.Context, text string, targetLanguage string) (string, error) {
+	var translation string
+	_, err := withFallback(ctx, CapabilityTranslation, func(ctx context.Context, provider string) (usage Usage, err error) {
+		translation, usage, err = e.translateWith(ctx, provider, text, ScribeOptions{TargetLanguage: targetLanguage})
+		return usage, err
+	})
+	return translation, err
+}
+
+// translateBuiltin is the built-in translator.
+func (e *realScribeEngine) translateBuiltin(text string, targetLanguage string) (string, error) {
 	// --- Placeholder for actual translation ---
 	// In a real implementation, you would call a translation service here.
 	// For now, we will just return a dummy translation.
@@ -362,55 +468,86 @@ func validateDubbingParams(opts ScribeOptions) error {
 // GenerateDubbing generates dubbed audio from translated text using TTS.
 // This function supports both OpenAI TTS and custom voice synthesis.
 func (e *realScribeEngine) GenerateDubbing(translation string, opts ScribeOptions, outputDir string) (string, error) {
+	audioPath, _, err := e.generateDubbing(context.Background(), translation, opts, outputDir)
+	return audioPath, err
+}
+
+// generateDubbing implements GenerateDubbing, trying the TTS providers of
+// the context's fallback chain in turn, and reports which one served it.
+// Tool output goes to the job log attached to ctx.
+func (e *realScribeEngine) generateDubbing(ctx context.Context, translation string, opts ScribeOptions, outputDir string) (string, served, error) {
 	// Set default parameters
 	setDefaultDubbingParams(&opts)
 
 	// Validate parameters
 	if err := validateDubbingParams(opts); err != nil {
-		return "", fmt.Errorf("invalid dubbing parameters: %w", err)
+		return "", served{}, NewScribeError(ErrorClassInvalidInput, "dubbing", fmt.Errorf("invalid dubbing parameters: %w", err))
 	}
 
 	// Create temp file for raw TTS output
 	tempDir, err := os.MkdirTemp("", "akashic_scribe_tts_*")
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp directory: %w", err)
+		return "", served{}, fmt.Errorf("failed to create temp directory: %w", err)
 	}
 	defer func() {
 		if err := os.RemoveAll(tempDir); err != nil {
-			log.Printf("Warning: failed to clean up temp directory %s: %v", tempDir, err)
+			LoggerFrom(ctx).Warn("Failed to clean up temp directory", "dir", tempDir, "error", err)
 		}
 	}()
 
 	rawAudioPath := filepath.Join(tempDir, "raw_tts.mp3")
 
 	// Generate TTS audio
+	var by served
 	if opts.UseCustomVoice {
 		// For custom voices, we would need a voice cloning service
 		// For now, we'll use a simulated approach with pitch/speed modifications
-		return "", errors.New("custom voice synthesis not yet implemented - requires voice cloning service integration")
+		return "", served{}, errors.New("custom voice synthesis not yet implemented - requires voice cloning service integration")
 	} else {
-		// Use OpenAI TTS API
-		apiKey := os.Getenv("OPENAI_API_KEY")
-		if apiKey == "" {
-			return "", errors.New("OPENAI_API_KEY environment variable not set - required for TTS generation")
-		}
-
-		if err := e.generateOpenAITTS(translation, opts.VoiceModel, opts.VoiceSpeed, apiKey, rawAudioPath); err != nil {
-			return "", fmt.Errorf("failed to generate TTS audio: %w", err)
+		by, err = withFallback(ctx, CapabilityTTS, func(ctx context.Context, provider string) (Usage, error) {
+			return e.synthesize(ctx, provider, translation, opts, rawAudioPath)
+		})
+		if err != nil {
+			return "", served{}, fmt.Errorf("failed to generate TTS audio: %w", err)
 		}
 	}
 
 	// Process audio with ffmpeg to apply additional effects and format conversion
 	finalAudioPath := filepath.Join(outputDir, fmt.Sprintf("dubbed_audio.%s", opts.AudioFormat))
-	if err := e.processAudio(rawAudioPath, finalAudioPath, opts); err != nil {
-		return "", fmt.Errorf("failed to process audio: %w", err)
+	if err := e.processAudio(ctx, rawAudioPath, finalAudioPath, opts); err != nil {
+		return "", served{}, fmt.Errorf("failed to process audio: %w", err)
+	}
+
+	return finalAudioPath, by, nil
+}
+
+// synthesize speaks text with provider into outputPath and returns the usage
+// to bill for it.
+func (e *realScribeEngine) synthesize(ctx context.Context, provider, text string, opts ScribeOptions, outputPath string) (Usage, error) {
+	config := ProvidersFrom(ctx).Config(provider)
+	if config.Type != ProviderTypeOpenAI {
+		return Usage{}, NewScribeError(ErrorClassInvalidInput, "tts",
+			fmt.Errorf("provider %s (type %q) cannot synthesize speech", provider, config.Type))
 	}
 
-	return finalAudioPath, nil
+	key, err := providerKey(ctx, opts, provider)
+	if err != nil {
+		return Usage{}, err
+	}
+	if err := e.generateOpenAITTS(ctx, provider, text, opts.VoiceModel, opts.VoiceSpeed, key, outputPath); err != nil {
+		return Usage{}, err
+	}
+	return Usage{Characters: int64(utf8.RuneCountInString(text))}, nil
 }
 
-// generateOpenAITTS calls the OpenAI TTS API to generate speech audio.
-func (e *realScribeEngine) generateOpenAITTS(text, model string, speed float64, apiKey, outputPath string) error {
+// ProviderOpenAI is the provider name used for OpenAI API rate limits.
+const ProviderOpenAI = "openai"
+
+// generateOpenAITTS calls the TTS API of provider, OpenAI or a compatible
+// server, to generate speech audio. The endpoint, model and HTTP client come
+// from the context's providers, and the request is subject to the provider's
+// process-wide limits. It is traced and recorded in the provider metrics.
+func (e *realScribeEngine) generateOpenAITTS(ctx context.Context, provider, text, model string, speed float64, apiKey Secret, outputPath string) (err error) {
 	// Validate model
 	validModels := map[string]bool{
 		"alloy":   true,
@@ -425,8 +562,9 @@ func (e *realScribeEngine) generateOpenAITTS(text, model string, speed float64,
 	}
 
 	// Prepare API request
+	providers := ProvidersFrom(ctx)
 	requestBody := map[string]interface{}{
-		"model": "tts-1-hd", // Use high-quality TTS model
+		"model": providers.Config(provider).Model,
 		"input": text,
 		"voice": model,
 		"speed": speed,
@@ -438,24 +576,36 @@ func (e *realScribeEngine) generateOpenAITTS(text, model string, speed float64,
 	}
 
 	// Make API request
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/audio/speech", bytes.NewBuffer(jsonBody))
+	ctx, span := StartSpan(ctx, "provider "+provider, slog.String("provider", provider), slog.String("operation", "tts"))
+	started := time.Now()
+	defer func() {
+		span.End(err)
+		DefaultMetrics().ObserveProviderRequest(provider, time.Since(started), err)
+	}()
+
+	req, err := providers.NewRequest(ctx, provider, "POST", "/audio/speech", bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey.Reveal())
+	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 5 * time.Minute}
-	resp, err := client.Do(req)
+	resp, err := providers.Do(ctx, provider, req, utf8.RuneCountInString(text))
 	if err != nil {
-		return fmt.Errorf("failed to make API request: %w", err)
+		if ctx.Err() != nil || ClassifyError(err) == ErrorClassInvalidInput {
+			return err
+		}
+		return NewScribeError(ErrorClassTransient, "tts", fmt.Errorf("failed to make API request: %w", err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+		return NewScribeError(classifyHTTPStatus(resp.StatusCode), "tts",
+			fmt.Errorf("%s API error (status %d): %s", provider, resp.StatusCode, string(bodyBytes)))
 	}
 
 	// Save audio to file
@@ -473,7 +623,7 @@ func (e *realScribeEngine) generateOpenAITTS(text, model string, speed float64,
 }
 
 // processAudio applies audio effects and converts to the desired format using ffmpeg.
-func (e *realScribeEngine) processAudio(inputPath, outputPath string, opts ScribeOptions) error {
+func (e *realScribeEngine) processAudio(ctx context.Context, inputPath, outputPath string, opts ScribeOptions) error {
 	// Build ffmpeg command with audio filters
 	args := []string{"-i", inputPath}
 
@@ -538,11 +688,14 @@ func (e *realScribeEngine) processAudio(inputPath, outputPath string, opts Scrib
 	args = append(args, "-y", outputPath)
 
 	// Execute ffmpeg
-	cmd := exec.Command("ffmpeg", args...)
+	cmd := newCommand(ctx, "ffmpeg", args...)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
+	logCommand(ctx, cmd)
+	err := cmd.Run()
+	logToolOutput(ctx, cmd, stderr.Bytes())
+	if err != nil {
 		return fmt.Errorf("ffmpeg processing failed: %w\nStderr: %s", err, stderr.String())
 	}
 
@@ -550,403 +703,51 @@ func (e *realScribeEngine) processAudio(inputPath, outputPath string, opts Scrib
 }
 
 // StartProcessing runs the full pipeline and reports progress.
-// The operation can be cancelled via the provided context.
+// The operation can be cancelled via the provided context. The final progress
+// update carries the result in its Result field.
 func (e *realScribeEngine) StartProcessing(ctx context.Context, options ScribeOptions, progress chan<- ProgressUpdate) error {
-	// Check for cancellation before starting
-	select {
-	case <-ctx.Done():
-		return fmt.Errorf("operation cancelled before start: %w", ctx.Err())
-	default:
-	}
-
-	// Check dependencies first
-	if err := e.checkDependencies(); err != nil {
-		progress <- ProgressUpdate{0.0, fmt.Sprintf("Dependency check failed: %v", err)}
-		return err
-	}
-
 	opts := options
-
-	progress <- ProgressUpdate{0.01, "Preparing job..."}
-
-	// Step 1: Download or use local file
-	var videoPath string
-	if opts.InputFile != "" {
-		// Verify file exists
-		if _, err := os.Stat(opts.InputFile); err != nil {
-			progress <- ProgressUpdate{0.0, fmt.Sprintf("Input file not found: %v", err)}
-			return fmt.Errorf("input file not found: %w", err)
-		}
-		videoPath = opts.InputFile
-		progress <- ProgressUpdate{0.05, "Using local video file."}
-	} else if opts.InputURL != "" {
-		// Check for cancellation before download
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("operation cancelled: %w", ctx.Err())
-		default:
-		}
-
-		progress <- ProgressUpdate{0.05, "Starting video download..."}
-
-		// Create temporary directory for downloads
-		tempDir, err := os.MkdirTemp("", "akashic_scribe_download_*")
-		if err != nil {
-			progress <- ProgressUpdate{0.0, fmt.Sprintf("Failed to create temp directory: %v", err)}
-			return fmt.Errorf("failed to create temp directory: %w", err)
-		}
-		defer func() {
-			if err := os.RemoveAll(tempDir); err != nil {
-				log.Printf("Warning: failed to clean up temp directory %s: %v", tempDir, err)
-			}
-		}()
-
-		videoPath = filepath.Join(tempDir, "downloaded_video.%(ext)s")
-		cmd := exec.Command("yt-dlp", "-o", videoPath, "--newline", opts.InputURL)
-
-		// Use progress tracking for download (0.05 to 0.20 = 15% range)
-		if err := e.runCommandWithProgress(ctx, cmd, 0.05, 0.15, progress, "Downloading video...", parseYtDlpProgress); err != nil {
-			progress <- ProgressUpdate{0.0, fmt.Sprintf("Failed to download video: %v", err)}
-			return fmt.Errorf("failed to download video: %w", err)
-		}
-
-		// Find the actual downloaded file
-		files, err := filepath.Glob(filepath.Join(tempDir, "downloaded_video.*"))
-		if err != nil || len(files) == 0 {
-			progress <- ProgressUpdate{0.0, "Downloaded file not found"}
-			return errors.New("downloaded file not found")
-		}
-		videoPath = files[0]
-		progress <- ProgressUpdate{0.20, "Download complete"}
-	} else {
-		progress <- ProgressUpdate{0.0, "No input file or URL provided."}
-		return errors.New("no input file or URL")
+	if opts.OutputDir == "" {
+		opts.OutputDir = DefaultOutputDir(opts)
 	}
 
-	// Step 2: Extract audio (no need to extract separately, Transcribe handles this)
-	progress <- ProgressUpdate{0.25, "Preparing for transcription..."}
-
-	// Check for cancellation before transcription
-	select {
-	case <-ctx.Done():
-		return fmt.Errorf("operation cancelled: %w", ctx.Err())
-	default:
-	}
-
-	// Step 3: Transcription (20% to 50% = 30% range)
-	progress <- ProgressUpdate{0.30, "Transcribing audio..."}
-	transcription, err := e.Transcribe(videoPath)
-	if err != nil {
-		progress <- ProgressUpdate{0.0, fmt.Sprintf("Transcription failed: %v", err)}
-		return fmt.Errorf("transcription failed: %w", err)
-	}
-	progress <- ProgressUpdate{0.50, "Transcription complete"}
-
-	// Check for cancellation before translation
-	select {
-	case <-ctx.Done():
-		return fmt.Errorf("operation cancelled: %w", ctx.Err())
-	default:
-	}
-
-	// Step 4: Translation (50% to 65% = 15% range)
-	progress <- ProgressUpdate{0.50, "Translating text..."}
-	translation, err := e.Translate(transcription, opts.TargetLanguage)
+	result, err := e.runPipeline(ctx, opts, progress)
 	if err != nil {
-		progress <- ProgressUpdate{0.0, fmt.Sprintf("Translation failed: %v", err)}
-		return fmt.Errorf("translation failed: %w", err)
-	}
-	progress <- ProgressUpdate{0.65, "Translation complete"}
-
-	// Prepare output directory early (needed for dubbing and final outputs)
-	outputDir := opts.OutputDir
-	if outputDir == "" {
-		if opts.InputFile != "" {
-			outputDir = filepath.Dir(opts.InputFile)
-		} else {
-			outputDir = filepath.Join(os.TempDir(), "akashic_scribe_output")
+		// Nobody may be reading progress after cancellation
+		if ctx.Err() == nil {
+			progress <- ProgressUpdate{Percentage: 0.0, Message: fmt.Sprintf("Processing failed: %v", err)}
 		}
+		return err
 	}
-	if err := os.MkdirAll(outputDir, 0o755); err != nil {
-		progress <- ProgressUpdate{0.0, fmt.Sprintf("Failed to prepare output directory: %v", err)}
-		return fmt.Errorf("failed to prepare output directory: %w", err)
-	}
-
-	// Step 5: (Optional) Dubbing (65% to 85% = 20% range)
-	var dubbedAudioPath string
-	if opts.CreateDubbing {
-		// Check for cancellation before dubbing
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("operation cancelled: %w", ctx.Err())
-		default:
-		}
-
-		progress <- ProgressUpdate{0.68, "Synthesizing dubbed audio with TTS..."}
-
-		// Set default dubbing parameters
-		setDefaultDubbingParams(&opts)
-
-		// Generate dubbed audio
-		audioPath, err := e.GenerateDubbing(translation, opts, outputDir)
-		if err != nil {
-			progress <- ProgressUpdate{0.68, fmt.Sprintf("Warning: Dubbing failed: %v", err)}
-			// Don't fail the entire process, just log the warning
-			// User can still get transcription and translation
-		} else {
-			dubbedAudioPath = audioPath
-			progress <- ProgressUpdate{0.85, "Dubbed audio generated successfully"}
-		}
-	}
-
-	// Step 6: (Optional) Subtitles (85% to 90% = 5% range)
-	if opts.CreateSubtitles {
-		// Check for cancellation before subtitle generation
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("operation cancelled: %w", ctx.Err())
-		default:
-		}
-
-		progress <- ProgressUpdate{0.87, "Generating subtitles..."}
-	}
-
-	// Step 7: Compose final output (90% to 95% = 5% range)
-	progress <- ProgressUpdate{0.92, "Preparing final output..."}
-
-	// Step 8: Complete
-	result := struct {
-		Transcription string
-		Translation   string
-		DubbedAudio   string `json:",omitempty"`
-		SubtitlesFile string `json:",omitempty"`
-	}{
-		Transcription: transcription,
-		Translation:   translation,
-		DubbedAudio:   dubbedAudioPath,
-	}
-
-	// Save outputs to disk (outputDir already prepared earlier)
-	progress <- ProgressUpdate{0.97, "Saving outputs..."}
-
-	// Write transcription and translation
-	if err := os.WriteFile(filepath.Join(outputDir, "transcription.txt"), []byte(transcription), 0o644); err != nil {
-		progress <- ProgressUpdate{0.0, fmt.Sprintf("Failed to write transcription: %v", err)}
-		return fmt.Errorf("failed to write transcription: %w", err)
-	}
-	if err := os.WriteFile(filepath.Join(outputDir, "translation.txt"), []byte(translation), 0o644); err != nil {
-		progress <- ProgressUpdate{0.0, fmt.Sprintf("Failed to write translation: %v", err)}
-		return fmt.Errorf("failed to write translation: %w", err)
-	}
-	if opts.CreateSubtitles {
-		// Use enhanced subtitle generator
-		subtitleGen := NewSubtitleGenerator()
-
-		// Get actual video duration using ffprobe
-		videoDuration := e.getVideoDuration(ctx, videoPath)
-		subtitleGen.CreateDefaultSegments(transcription, translation, videoDuration)
-
-		// Determine subtitle format
-		format := opts.SubtitleFormat
-		if format == "" {
-			format = "srt" // default to SRT
-		}
 
-		// Generate subtitle content
-		var subtitleContent string
-		var extension string
+	progress <- ProgressUpdate{Percentage: 1.0, Message: "Scribing complete.", Result: result}
 
-		if format == "vtt" {
-			subtitleContent = subtitleGen.GenerateVTT(opts.BilingualSubtitles, opts.SubtitlePosition)
-			extension = ".vtt"
-		} else {
-			subtitleContent = subtitleGen.GenerateSRT(opts.BilingualSubtitles, opts.SubtitlePosition)
-			extension = ".srt"
-		}
+	return nil
+}
 
-		subtitlesPath := filepath.Join(outputDir, "subtitles"+extension)
-		if err := os.WriteFile(subtitlesPath, []byte(subtitleContent), 0o644); err != nil {
-			progress <- ProgressUpdate{0.0, fmt.Sprintf("Failed to write subtitles: %v", err)}
-			return fmt.Errorf("failed to write subtitles: %w", err)
-		}
-		result.SubtitlesFile = subtitlesPath
+// DefaultOutputDir returns the directory interactive processing writes to
+// when options.OutputDir is empty: the input file's directory, or a shared
+// directory under the system temp directory for URLs.
+func DefaultOutputDir(options ScribeOptions) string {
+	if options.InputFile != "" {
+		return filepath.Dir(options.InputFile)
 	}
-
-	resultJSON, _ := json.MarshalIndent(result, "", "  ")
-	completionMsg := fmt.Sprintf("Scribing complete.\nOutput saved to: %s\n%s", outputDir, string(resultJSON))
-	progress <- ProgressUpdate{1.0, completionMsg}
-
-	return nil
+	return filepath.Join(os.TempDir(), "akashic_scribe_output")
 }
 
 // ProcessWithContext runs the full pipeline with context and returns a structured result.
 // This method is used by the batch processor for better result handling.
 func (e *realScribeEngine) ProcessWithContext(ctx context.Context, opts ScribeOptions, progress chan<- ProgressUpdate) (*ScribeResult, error) {
-	// Check dependencies first
-	if err := e.checkDependencies(); err != nil {
-		return nil, err
-	}
-
-	// Determine output directory
-	outputDir := opts.OutputDir
-	if outputDir == "" {
-		outputDir = filepath.Join(".", "akashic_output_"+time.Now().Format("20060102_150405"))
-	}
-
-	// Create output directory
-	if err := os.MkdirAll(outputDir, 0o755); err != nil {
-		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	if opts.OutputDir == "" {
+		opts.OutputDir = filepath.Join(".", "akashic_output_"+time.Now().Format("20060102_150405"))
 	}
 
-	// Step 1: Obtain video file (download if URL, or use local file)
-	progress <- ProgressUpdate{0.0, "Starting..."}
-
-	var videoPath string
-
-	if opts.InputFile != "" {
-		// Verify local file exists
-		if _, err := os.Stat(opts.InputFile); err != nil {
-			return nil, fmt.Errorf("input file not found: %w", err)
-		}
-		videoPath = opts.InputFile
-		progress <- ProgressUpdate{0.05, "Using local file"}
-	} else if opts.InputURL != "" {
-		// Create temporary directory for download
-		tempDir, err := os.MkdirTemp("", "akashic_scribe_download_*")
-		if err != nil {
-			return nil, fmt.Errorf("failed to create temp directory: %w", err)
-		}
-		defer func() {
-			if err := os.RemoveAll(tempDir); err != nil {
-				log.Printf("Warning: failed to clean up temp directory %s: %v", tempDir, err)
-			}
-		}()
-
-		progress <- ProgressUpdate{0.05, "Downloading video..."}
-
-		// Download video using yt-dlp with progress tracking and cancellation support
-		videoPath = filepath.Join(tempDir, "downloaded_video.%(ext)s")
-		cmd := exec.CommandContext(ctx, "yt-dlp", "-o", videoPath, opts.InputURL)
-		
-		// Use runCommandWithProgress for cancellable downloads with progress reporting
-		if err := e.runCommandWithProgress(ctx, cmd, 0.05, 0.15, progress, "Downloading video...", parseYtDlpProgress); err != nil {
-			return nil, fmt.Errorf("failed to download video: %w", err)
-		}
-
-		// Find the actual downloaded file
-		files, err := filepath.Glob(filepath.Join(tempDir, "downloaded_video.*"))
-		if err != nil || len(files) == 0 {
-			return nil, errors.New("downloaded file not found")
-		}
-		videoPath = files[0]
-		progress <- ProgressUpdate{0.20, "Download complete"}
-	} else {
-		return nil, errors.New("no input file or URL provided")
-	}
-
-	// Step 2: Transcription
-	progress <- ProgressUpdate{0.30, "Transcribing audio..."}
-	transcription, err := e.Transcribe(videoPath)
-	if err != nil {
-		return nil, fmt.Errorf("transcription failed: %w", err)
-	}
-	progress <- ProgressUpdate{0.50, "Transcription complete"}
-
-	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
-	default:
-	}
-
-	// Step 3: Translation
-	progress <- ProgressUpdate{0.50, "Translating text..."}
-	translation, err := e.Translate(transcription, opts.TargetLanguage)
+	result, err := e.runPipeline(ctx, opts, progress)
 	if err != nil {
-		return nil, fmt.Errorf("translation failed: %w", err)
-	}
-	progress <- ProgressUpdate{0.65, "Translation complete"}
-
-	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
-	default:
-	}
-
-	// Step 4: Optional dubbing
-	var dubbedAudioPath string
-	if opts.CreateDubbing {
-		progress <- ProgressUpdate{0.70, "Generating dubbed audio..."}
-		dubbedAudioPath, err = e.GenerateDubbing(translation, opts, outputDir)
-		if err != nil {
-			log.Printf("Warning: Dubbing failed but continuing: %v", err)
-			progress <- ProgressUpdate{0.80, "Dubbing failed, continuing without audio..."}
-		} else {
-			progress <- ProgressUpdate{0.80, "Dubbing complete"}
-		}
-	}
-
-	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
-	default:
-	}
-
-	// Step 5: Optional subtitles
-	var subtitlesPath string
-	if opts.CreateSubtitles {
-		progress <- ProgressUpdate{0.87, "Generating subtitles..."}
-
-		subtitleGen := NewSubtitleGenerator()
-		// Get actual video duration using ffprobe
-		videoDuration := e.getVideoDuration(ctx, videoPath)
-		subtitleGen.CreateDefaultSegments(transcription, translation, videoDuration)
-
-		format := opts.SubtitleFormat
-		if format == "" {
-			format = "srt"
-		}
-
-		var subtitleContent string
-		var extension string
-
-		if format == "vtt" {
-			subtitleContent = subtitleGen.GenerateVTT(opts.BilingualSubtitles, opts.SubtitlePosition)
-			extension = ".vtt"
-		} else {
-			subtitleContent = subtitleGen.GenerateSRT(opts.BilingualSubtitles, opts.SubtitlePosition)
-			extension = ".srt"
-		}
-
-		subtitlesPath = filepath.Join(outputDir, "subtitles"+extension)
-		if err := os.WriteFile(subtitlesPath, []byte(subtitleContent), 0o644); err != nil {
-			return nil, fmt.Errorf("failed to write subtitles: %w", err)
-		}
-	}
-
-	// Step 6: Save outputs
-	progress <- ProgressUpdate{0.97, "Saving outputs..."}
-
-	if err := os.WriteFile(filepath.Join(outputDir, "transcription.txt"), []byte(transcription), 0o644); err != nil {
-		return nil, fmt.Errorf("failed to write transcription: %w", err)
-	}
-
-	if err := os.WriteFile(filepath.Join(outputDir, "translation.txt"), []byte(translation), 0o644); err != nil {
-		return nil, fmt.Errorf("failed to write translation: %w", err)
-	}
-
-	// Create result
-	result := &ScribeResult{
-		Transcription: transcription,
-		Translation:   translation,
-		DubbedAudio:   dubbedAudioPath,
-		SubtitlesFile: subtitlesPath,
-		OutputDir:     outputDir,
+		return nil, err
 	}
 
-	progress <- ProgressUpdate{1.0, "Processing complete"}
+	progress <- ProgressUpdate{Percentage: 1.0, Message: "Processing complete"}
 
 	return result, nil
 }
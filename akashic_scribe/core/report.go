@@ -0,0 +1,422 @@
+package core
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReportFormat identifies an export format for a BatchReport.
+type ReportFormat string
+
+const (
+	ReportJSON ReportFormat = "json"
+	ReportCSV  ReportFormat = "csv"
+	ReportHTML ReportFormat = "html"
+)
+
+// JobReport summarises a single batch job.
+type JobReport struct {
+	ID               string             `json:"id"`
+	Input            string             `json:"input"`
+	OriginLanguage   string             `json:"origin_language,omitempty"`
+	TargetLanguage   string             `json:"target_language,omitempty"`
+	DetectedLanguage string             `json:"detected_language,omitempty"`
+	Priority         string             `json:"priority"`
+	Status           string             `json:"status"`
+	Attempts         int                `json:"attempts"`
+	QueuedAt         time.Time          `json:"queued_at"`
+	StartTime        time.Time          `json:"start_time"`
+	EndTime          time.Time          `json:"end_time"`
+	DurationSeconds  float64            `json:"duration_seconds"`
+	StageTimings     []StageTiming      `json:"stage_timings,omitempty"`
+	Error            string             `json:"error,omitempty"`
+	ErrorClass       string             `json:"error_class,omitempty"`
+	OutputDir        string             `json:"output_dir,omitempty"`
+	OutputFiles      []string           `json:"output_files,omitempty"`
+	ProviderCosts    map[string]float64 `json:"provider_costs,omitempty"`
+	Usage            map[string]Usage   `json:"usage,omitempty"`
+	TotalCost        float64            `json:"total_cost"`
+}
+
+// BatchReport summarises a batch run, one JobReport per job in queue order.
+type BatchReport struct {
+	GeneratedAt     time.Time   `json:"generated_at"`
+	TotalJobs       int         `json:"total_jobs"`
+	Completed       int         `json:"completed"`
+	Failed          int         `json:"failed"`
+	Cancelled       int         `json:"cancelled"`
+	Pending         int         `json:"pending"`
+	Running         int         `json:"running"`
+	Paused          int         `json:"paused"`
+	DurationSeconds float64     `json:"duration_seconds"` // Wall-clock time from the first start to the last end
+	TotalCost       float64     `json:"total_cost"`
+	Budget          float64     `json:"budget,omitempty"` // Batch budget in USD (0 = unlimited)
+	Jobs            []JobReport `json:"jobs"`
+}
+
+// Report builds a BatchReport from the current state of all jobs.
+func (bp *BatchProcessor) Report() *BatchReport {
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+
+	jobs := make([]*BatchJob, 0, len(bp.jobs))
+	for _, job := range bp.jobs {
+		jobs = append(jobs, job)
+	}
+	report := NewBatchReport(jobs)
+	report.Budget = bp.budget
+	return report
+}
+
+// NewBatchReport builds a report from a set of jobs. The jobs must not be
+// modified concurrently; use BatchProcessor.Report for live processors.
+func NewBatchReport(jobs []*BatchJob) *BatchReport {
+	sorted := make([]*BatchJob, len(jobs))
+	copy(sorted, jobs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].QueuedAt.Before(sorted[j].QueuedAt)
+	})
+
+	report := &BatchReport{
+		GeneratedAt: time.Now(),
+		TotalJobs:   len(sorted),
+		Jobs:        make([]JobReport, 0, len(sorted)),
+	}
+
+	var first, last time.Time
+	for _, job := range sorted {
+		switch job.Status {
+		case JobCompleted:
+			report.Completed++
+		case JobFailed:
+			report.Failed++
+		case JobCancelled:
+			report.Cancelled++
+		case JobPending:
+			report.Pending++
+		case JobRunning:
+			report.Running++
+		case JobPaused:
+			report.Paused++
+		}
+
+		if !job.StartTime.IsZero() && (first.IsZero() || job.StartTime.Before(first)) {
+			first = job.StartTime
+		}
+		if job.EndTime.After(last) {
+			last = job.EndTime
+		}
+
+		jobReport := newJobReport(job)
+		report.TotalCost += jobReport.TotalCost
+		report.Jobs = append(report.Jobs, jobReport)
+	}
+
+	if !first.IsZero() && last.After(first) {
+		report.DurationSeconds = last.Sub(first).Seconds()
+	}
+
+	return report
+}
+
+// newJobReport summarises a single job.
+func newJobReport(job *BatchJob) JobReport {
+	input := job.Options.InputFile
+	if input == "" {
+		input = job.Options.InputURL
+	}
+
+	jr := JobReport{
+		ID:             job.ID,
+		Input:          input,
+		OriginLanguage: job.Options.OriginLanguage,
+		TargetLanguage: job.Options.TargetLanguage,
+		Priority:       job.Priority.String(),
+		Status:         job.Status.String(),
+		Attempts:       job.Attempts,
+		QueuedAt:       job.QueuedAt,
+		StartTime:      job.StartTime,
+		EndTime:        job.EndTime,
+	}
+
+	if !job.StartTime.IsZero() && job.EndTime.After(job.StartTime) {
+		jr.DurationSeconds = job.EndTime.Sub(job.StartTime).Seconds()
+	}
+
+	if job.Error != nil {
+		jr.Error = job.Error.Error()
+		jr.ErrorClass = job.ErrorClass.String()
+	}
+
+	if result := job.Result; result != nil {
+		jr.DetectedLanguage = result.DetectedLanguage
+		jr.StageTimings = result.StageTimings
+		jr.OutputDir = result.OutputDir
+		for _, file := range []string{result.SubtitlesFile, result.DubbedAudio} {
+			if file != "" {
+				jr.OutputFiles = append(jr.OutputFiles, file)
+			}
+		}
+		if len(result.ProviderCosts) > 0 {
+			jr.ProviderCosts = make(map[string]float64, len(result.ProviderCosts))
+			for provider, cost := range result.ProviderCosts {
+				jr.ProviderCosts[provider] = cost
+				jr.TotalCost += cost
+			}
+		}
+		if len(result.Usage) > 0 {
+			jr.Usage = make(map[string]Usage, len(result.Usage))
+			for provider, usage := range result.Usage {
+				jr.Usage[provider] = usage
+			}
+		}
+	}
+
+	return jr
+}
+
+// stageNames returns every stage name that appears in the report, in first-seen order.
+func (r *BatchReport) stageNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, job := range r.Jobs {
+		for _, timing := range job.StageTimings {
+			if !seen[timing.Stage] {
+				seen[timing.Stage] = true
+				names = append(names, timing.Stage)
+			}
+		}
+	}
+	return names
+}
+
+// stageSeconds returns the total time spent in stage, in seconds.
+func (jr JobReport) stageSeconds(stage string) float64 {
+	var total time.Duration
+	for _, timing := range jr.StageTimings {
+		if timing.Stage == stage {
+			total += timing.Duration
+		}
+	}
+	return total.Seconds()
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *BatchReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	return nil
+}
+
+// WriteCSV writes one row per job. Stage timings become one column per stage
+// (in seconds) so the file can be analysed in a spreadsheet.
+func (r *BatchReport) WriteCSV(w io.Writer) error {
+	stages := r.stageNames()
+
+	header := []string{
+		"id", "input", "status", "priority", "attempts", "origin_language", "target_language",
+		"detected_language", "queued_at", "start_time", "end_time", "duration_seconds",
+	}
+	for _, stage := range stages {
+		header = append(header, stage+"_seconds")
+	}
+	header = append(header, "error", "error_class", "output_dir", "output_files", "total_cost")
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write report header: %w", err)
+	}
+
+	for _, job := range r.Jobs {
+		row := []string{
+			job.ID,
+			job.Input,
+			job.Status,
+			job.Priority,
+			strconv.Itoa(job.Attempts),
+			job.OriginLanguage,
+			job.TargetLanguage,
+			job.DetectedLanguage,
+			formatReportTime(job.QueuedAt),
+			formatReportTime(job.StartTime),
+			formatReportTime(job.EndTime),
+			formatSeconds(job.DurationSeconds),
+		}
+		for _, stage := range stages {
+			row = append(row, formatSeconds(job.stageSeconds(stage)))
+		}
+		row = append(row,
+			job.Error,
+			job.ErrorClass,
+			job.OutputDir,
+			strings.Join(job.OutputFiles, ";"),
+			formatCost(job.TotalCost),
+		)
+
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write report row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// WriteHTML writes the report as a self-contained HTML page with inline styles.
+func (r *BatchReport) WriteHTML(w io.Writer) error {
+	data := struct {
+		*BatchReport
+		Stages []string
+	}{r, r.stageNames()}
+
+	if err := reportTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	return nil
+}
+
+// Write writes the report in the given format.
+func (r *BatchReport) Write(w io.Writer, format ReportFormat) error {
+	switch format {
+	case ReportJSON:
+		return r.WriteJSON(w)
+	case ReportCSV:
+		return r.WriteCSV(w)
+	case ReportHTML:
+		return r.WriteHTML(w)
+	default:
+		return fmt.Errorf("unsupported report format: %s", format)
+	}
+}
+
+// SaveToFile writes the report to path, choosing the format from the file
+// extension (.json, .csv, .html or .htm).
+func (r *BatchReport) SaveToFile(path string) error {
+	var format ReportFormat
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = ReportJSON
+	case ".csv":
+		format = ReportCSV
+	case ".html", ".htm":
+		format = ReportHTML
+	default:
+		return fmt.Errorf("cannot determine report format from file name: %s", path)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+
+	if err := r.Write(file, format); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close report file: %w", err)
+	}
+	return nil
+}
+
+// formatReportTime formats a timestamp for CSV output, leaving zero times empty.
+func formatReportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatSeconds formats a duration in seconds with millisecond precision.
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
+
+// formatCost formats a cost in USD.
+func formatCost(cost float64) string {
+	return strconv.FormatFloat(cost, 'f', 4, 64)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time":    formatReportTime,
+	"seconds": formatSeconds,
+	"cost":    formatCost,
+	"stage":   func(job JobReport, stage string) string { return formatSeconds(job.stageSeconds(stage)) },
+	"lower":   strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Akashic Scribe Batch Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 2em; color: #222; }
+h1 { color: #4b2a7b; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9em; }
+th, td { border: 1px solid #ddd; padding: 6px 8px; text-align: left; vertical-align: top; }
+th { background: #f3eefb; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.summary span { display: inline-block; margin-right: 1.5em; }
+.completed { color: #1a7f37; }
+.failed { color: #cf222e; }
+.cancelled { color: #9a6700; }
+.error { color: #cf222e; max-width: 30em; }
+</style>
+</head>
+<body>
+<h1>Batch Report</h1>
+<p>Generated {{time .GeneratedAt}}</p>
+<p class="summary">
+<span>Total: {{.TotalJobs}}</span>
+<span class="completed">Completed: {{.Completed}}</span>
+<span class="failed">Failed: {{.Failed}}</span>
+<span class="cancelled">Cancelled: {{.Cancelled}}</span>
+<span>Pending: {{.Pending}}</span>
+<span>Running: {{.Running}}</span>
+<span>Paused: {{.Paused}}</span>
+<span>Duration: {{seconds .DurationSeconds}}s</span>
+<span>Cost: ${{cost .TotalCost}}{{if .Budget}} of ${{cost .Budget}} budget{{end}}</span>
+</p>
+<table>
+<thead>
+<tr>
+<th>Job</th><th>Input</th><th>Status</th><th>Attempts</th><th>Languages</th><th>Duration (s)</th>
+{{- range .Stages}}<th>{{.}} (s)</th>{{end}}
+<th>Outputs</th><th>Cost</th><th>Error</th>
+</tr>
+</thead>
+<tbody>
+{{- $stages := .Stages}}
+{{- range .Jobs}}
+<tr>
+<td>{{.ID}}</td>
+<td>{{.Input}}</td>
+<td class="{{lower .Status}}">{{.Status}}</td>
+<td class="num">{{.Attempts}}</td>
+<td>{{.OriginLanguage}} &rarr; {{.TargetLanguage}}{{if .DetectedLanguage}} (detected {{.DetectedLanguage}}){{end}}</td>
+<td class="num">{{seconds .DurationSeconds}}</td>
+{{- $job := .}}{{range $stages}}<td class="num">{{stage $job .}}</td>{{end}}
+<td>{{if .OutputDir}}{{.OutputDir}}{{end}}{{range .OutputFiles}}<br>{{.}}{{end}}</td>
+<td class="num">${{cost .TotalCost}}</td>
+<td class="error">{{if .Error}}{{.ErrorClass}}: {{.Error}}{{end}}</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleReportJobs returns a completed and a failed job for report tests.
+func sampleReportJobs() []*BatchJob {
+	start := time.Date(2025, 11, 20, 22, 0, 0, 0, time.UTC)
+
+	return []*BatchJob{
+		{
+			ID:         "job_failed",
+			Options:    ScribeOptions{InputURL: "https://example.com/watch?v=1", TargetLanguage: "German"},
+			Status:     JobFailed,
+			Error:      NewScribeError(ErrorClassTransient, "download", errors.New("failed to download video")),
+			ErrorClass: ErrorClassTransient,
+			Attempts:   4,
+			QueuedAt:   start.Add(time.Second),
+			StartTime:  start.Add(time.Minute),
+			EndTime:    start.Add(3 * time.Minute),
+		},
+		{
+			ID:        "job_done",
+			Options:   ScribeOptions{InputFile: "/videos/talk, part 1.mp4", OriginLanguage: "en-US", TargetLanguage: "Spanish"},
+			Status:    JobCompleted,
+			Attempts:  1,
+			QueuedAt:  start,
+			StartTime: start,
+			EndTime:   start.Add(90 * time.Second),
+			Result: &ScribeResult{
+				OutputDir:        "/out/talk",
+				SubtitlesFile:    "/out/talk/subtitles.srt",
+				DetectedLanguage: "en",
+				StageTimings: []StageTiming{
+					{Stage: StageTranscribe, Start: start, Duration: 60 * time.Second},
+					{Stage: StageTranslate, Start: start.Add(60 * time.Second), Duration: 30 * time.Second},
+				},
+				ProviderCosts: map[string]float64{"openai": 0.25},
+			},
+		},
+	}
+}
+
+func TestNewBatchReport(t *testing.T) {
+	report := NewBatchReport(sampleReportJobs())
+
+	assert.Equal(t, 2, report.TotalJobs)
+	assert.Equal(t, 1, report.Completed)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 180.0, report.DurationSeconds)
+	assert.Equal(t, 0.25, report.TotalCost)
+
+	require.Len(t, report.Jobs, 2)
+	assert.Equal(t, "job_done", report.Jobs[0].ID, "Jobs should be listed in queue order")
+
+	done := report.Jobs[0]
+	assert.Equal(t, "/videos/talk, part 1.mp4", done.Input)
+	assert.Equal(t, 90.0, done.DurationSeconds)
+	assert.Equal(t, "en", done.DetectedLanguage)
+	assert.Equal(t, []string{"/out/talk/subtitles.srt"}, done.OutputFiles)
+	assert.Empty(t, done.Error)
+
+	failed := report.Jobs[1]
+	assert.Equal(t, "https://example.com/watch?v=1", failed.Input)
+	assert.Equal(t, "failed to download video", failed.Error)
+	assert.Equal(t, "Transient", failed.ErrorClass)
+	assert.Equal(t, 4, failed.Attempts)
+}
+
+func TestBatchReport_WriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewBatchReport(sampleReportJobs()).WriteJSON(&buf))
+
+	var decoded BatchReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, 2, decoded.TotalJobs)
+	require.Len(t, decoded.Jobs, 2)
+	assert.Equal(t, 60*time.Second, decoded.Jobs[0].StageTimings[0].Duration)
+	assert.Equal(t, 0.25, decoded.Jobs[0].ProviderCosts["openai"])
+}
+
+func TestBatchReport_WriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewBatchReport(sampleReportJobs()).WriteCSV(&buf))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3, "Header plus one row per job")
+
+	header := records[0]
+	assert.Contains(t, header, "transcribe_seconds")
+	assert.Contains(t, header, "translate_seconds")
+
+	column := func(name string) int {
+		for i, h := range header {
+			if h == name {
+				return i
+			}
+		}
+		t.Fatalf("Column %s not found", name)
+		return -1
+	}
+
+	assert.Equal(t, "/videos/talk, part 1.mp4", records[1][column("input")], "Commas must survive CSV quoting")
+	assert.Equal(t, "60.000", records[1][column("transcribe_seconds")])
+	assert.Equal(t, "0.2500", records[1][column("total_cost")])
+	assert.Equal(t, "0.000", records[2][column("transcribe_seconds")])
+	assert.Equal(t, "Transient", records[2][column("error_class")])
+}
+
+func TestBatchReport_WriteHTML(t *testing.T) {
+	jobs := sampleReportJobs()
+	jobs[0].Error = NewScribeError(ErrorClassInvalidInput, "input", errors.New("<script>alert(1)</script>"))
+
+	var buf bytes.Buffer
+	require.NoError(t, NewBatchReport(jobs).WriteHTML(&buf))
+
+	html := buf.String()
+	assert.True(t, strings.HasPrefix(html, "<!DOCTYPE html>"))
+	assert.Contains(t, html, "<style>", "Report should be self-contained")
+	assert.NotContains(t, html, "<link", "Report should not reference external resources")
+	assert.Contains(t, html, "job_done")
+	assert.Contains(t, html, "transcribe (s)")
+	assert.NotContains(t, html, "<script>alert(1)</script>", "Errors must be escaped")
+}
+
+func TestBatchReport_SaveToFile(t *testing.T) {
+	report := NewBatchReport(sampleReportJobs())
+	dir := t.TempDir()
+
+	for _, name := range []string{"report.json", "report.csv", "report.html"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, report.SaveToFile(path))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Positive(t, info.Size())
+	}
+
+	assert.Error(t, report.SaveToFile(filepath.Join(dir, "report.xlsx")))
+}
+
+func TestBatchProcessor_Report(t *testing.T) {
+	bp := NewBatchProcessor(NewMockScribeEngine(), 2)
+
+	bp.AddJob(ScribeOptions{InputFile: "first.mp4", TargetLanguage: "Spanish", CreateSubtitles: true})
+	bp.AddJob(ScribeOptions{InputFile: "second.mp4", TargetLanguage: "French"})
+	bp.Wait()
+
+	report := bp.Report()
+	assert.Equal(t, 2, report.Completed)
+	require.Len(t, report.Jobs, 2)
+	assert.Equal(t, "first.mp4", report.Jobs[0].Input)
+
+	stages := make([]string, 0)
+	for _, timing := range report.Jobs[0].StageTimings {
+		stages = append(stages, timing.Stage)
+	}
+	assert.Equal(t, []string{StageTranscribe, StageTranslate, StageSubtitles}, stages)
+	assert.Positive(t, report.Jobs[0].DurationSeconds)
+}
@@ -0,0 +1,424 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// NormalizedPipelineName is a built-in pipeline that normalizes whitespace
+// and punctuation spacing in the transcript before translation.
+const NormalizedPipelineName = "normalized"
+
+// builtinPipelines returns the pipelines registered at startup.
+func builtinPipelines() []*Pipeline {
+	normalized := DefaultPipeline().Clone(NormalizedPipelineName)
+	if err := normalized.InsertBefore(StageTranslate, NormalizeTextStage()); err != nil {
+		panic(err)
+	}
+	return []*Pipeline{DefaultPipeline(), normalized}
+}
+
+// DefaultPipeline returns a new copy of the built-in processing pipeline:
+// captions, download, extract, transcribe, translate, dubbing, subtitles and
+// save. Use it as the starting point for custom pipelines.
+func DefaultPipeline() *Pipeline {
+	p := NewPipeline(DefaultPipelineName)
+	p.stages = []Stage{
+		{
+			Name: StageCaptions, Progress: 0.02, Message: "Fetching platform captions...",
+			Skip: func(s *PipelineState) bool { return !s.Options.UsePlatformCaptions || s.Options.InputFile != "" },
+			Run:  engineStage((*realScribeEngine).captionsStage),
+		},
+		{
+			Name: StageDownload, Progress: 0.05, Message: "Downloading video...",
+			Skip: func(s *PipelineState) bool { return s.Options.InputFile != "" || s.Captions != nil },
+			Run:  engineStage((*realScribeEngine).downloadStage),
+		},
+		{
+			Name: StageExtract, Progress: 0.10, Message: "Extracting time range...",
+			Skip: func(s *PipelineState) bool { return s.Options.InputFile == "" || !s.Options.hasTimeRange() },
+			Run:  engineStage((*realScribeEngine).extractStage),
+		},
+		{
+			Name: StageTranscribe, Progress: 0.30, Message: "Transcribing audio...",
+			Skip: func(s *PipelineState) bool { return s.Captions != nil },
+			Run:  engineStage((*realScribeEngine).transcribeStage),
+		},
+		{
+			Name: StageTranslate, Progress: 0.50, Message: "Translating text...",
+			Run: engineStage((*realScribeEngine).translateStage),
+		},
+		{
+			Name: StageDubbing, Progress: 0.68, Message: "Synthesizing dubbed audio...",
+			Skip: func(s *PipelineState) bool { return !s.Options.CreateDubbing },
+			Run:  engineStage((*realScribeEngine).dubbingStage),
+		},
+		{
+			Name: StageSubtitles, Progress: 0.87, Message: "Generating subtitles...",
+			Skip: func(s *PipelineState) bool { return !s.Options.CreateSubtitles },
+			Run:  engineStage((*realScribeEngine).subtitlesStage),
+		},
+		{
+			Name: StageSave, Progress: 0.97, Message: "Saving outputs...",
+			Run: saveStage,
+		},
+	}
+	return p
+}
+
+// engineStage adapts a built-in stage that needs the real engine.
+func engineStage(run func(e *realScribeEngine, ctx context.Context, state *PipelineState) error) func(context.Context, *PipelineState) error {
+	return func(ctx context.Context, state *PipelineState) error {
+		if state.engine == nil {
+			return errors.New("built-in stages must be run by the processing engine")
+		}
+		return run(state.engine, ctx, state)
+	}
+}
+
+// runPipeline validates opts and runs the pipeline named by opts.Pipeline.
+// opts.OutputDir must be set.
+func (e *realScribeEngine) runPipeline(ctx context.Context, opts ScribeOptions, progress chan<- ProgressUpdate) (*ScribeResult, error) {
+	if err := StageCheckpoint(ctx); err != nil {
+		return nil, err
+	}
+	pipeline, err := LookupPipeline(opts.Pipeline)
+	if err != nil {
+		return nil, err
+	}
+	attachCommandHooks(ctx, pipeline)
+	if err := e.checkDependencies(); err != nil {
+		return nil, err
+	}
+	if err := validateTimeRange(opts); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case opts.InputFile != "":
+		if _, err := os.Stat(opts.InputFile); err != nil {
+			return nil, NewScribeError(ErrorClassInvalidInput, "input", fmt.Errorf("input file not found: %w", err))
+		}
+	case opts.InputURL != "":
+	default:
+		return nil, NewScribeError(ErrorClassInvalidInput, "input", errors.New("no input file or URL provided"))
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Keep a log of the job, including tool output, next to its outputs
+	jobLog, ctx, err := openJobLog(ctx, opts.OutputDir)
+	if err != nil {
+		LoggerFrom(ctx).Warn("Job log unavailable", "error", err)
+	} else {
+		defer jobLog.Close()
+	}
+	logger := LoggerFrom(ctx)
+	logger.Info("Processing started", "pipeline", pipeline.Name(), "input", firstNonEmpty(opts.InputFile, opts.InputURL),
+		"target_language", opts.TargetLanguage, "output_dir", opts.OutputDir)
+
+	state := NewPipelineState(opts, progress)
+	state.engine = e
+	state.Report(0.0, "Starting...")
+
+	ctx, span := StartSpan(ctx, "pipeline "+pipeline.Name(), slog.String("pipeline", pipeline.Name()))
+	timings, err := pipeline.Run(ctx, state)
+	span.End(err)
+	if err != nil {
+		logger.Error("Processing failed", "error", err, "error_class", ClassifyError(err).String())
+		return nil, err
+	}
+	result := state.result(timings)
+	result.ProviderCosts = PriceTableFrom(ctx).Costs(state.Usage)
+	logger.Info("Processing finished", "transcript_source", state.TranscriptSource, "cost", result.TotalCost())
+	return result, nil
+}
+
+// mediaDuration returns the duration of the media being processed, probing
+// it with ffprobe the first time.
+func (e *realScribeEngine) mediaDuration(ctx context.Context, state *PipelineState) time.Duration {
+	if state.mediaDuration == 0 {
+		state.mediaDuration = e.getVideoDuration(ctx, state.MediaPath)
+	}
+	return state.mediaDuration
+}
+
+// captionsStage uses the platform's captions as the transcript if available.
+func (e *realScribeEngine) captionsStage(ctx context.Context, state *PipelineState) error {
+	captions, err := e.platformCaptions(ctx, state.Options)
+	if err != nil || captions == nil {
+		return err
+	}
+
+	state.Captions = captions
+	state.Transcript = captions.Text()
+	state.TranscriptSource = captions.Source
+	state.DetectedLanguage = captions.Language
+	state.ReportProgress(ProgressUpdate{
+		StageProgress: 1.0,
+		Message:       fmt.Sprintf("Using platform captions (%s)", captions.Language),
+		Payload:       captions,
+	})
+	return nil
+}
+
+// downloadStage downloads the input URL with yt-dlp.
+func (e *realScribeEngine) downloadStage(ctx context.Context, state *PipelineState) error {
+	dir, err := state.WorkDir()
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"--no-playlist", "--newline"}, ytDlpDownloadArgs(state.Options)...)
+	args = append(args, "-o", filepath.Join(dir, "downloaded_video.%(ext)s"), state.Options.InputURL)
+	cmd := newCommand(ctx, "yt-dlp", args...)
+
+	parse := func(line string) (ProgressUpdate, bool) {
+		fraction, ok := parseYtDlpProgress(line)
+		if !ok {
+			return ProgressUpdate{}, false
+		}
+		update := ProgressUpdate{Message: "Downloading video...", StageProgress: fraction}
+		if total := parseYtDlpTotalBytes(line); total > 0 {
+			update.BytesTotal = total
+			update.BytesDone = int64(fraction * float64(total))
+		}
+		return state.stageUpdate(update), true
+	}
+	if err := e.runDownload(ctx, cmd, state.progress, parse); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("operation cancelled: %w", ctx.Err())
+		}
+		return downloadError(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "downloaded_video.*"))
+	if err != nil || len(files) == 0 {
+		return errors.New("downloaded file not found")
+	}
+	state.MediaPath = files[0]
+	state.ReportProgress(ProgressUpdate{StageProgress: 1.0, Message: "Download complete"})
+	return nil
+}
+
+// extractStage cuts the requested time range out of a local input file.
+func (e *realScribeEngine) extractStage(ctx context.Context, state *PipelineState) error {
+	clipPath, err := e.extractClip(ctx, state)
+	if err != nil {
+		return err
+	}
+	state.MediaPath = clipPath
+	state.ReportProgress(ProgressUpdate{StageProgress: 1.0, Message: "Time range extracted"})
+	return nil
+}
+
+// transcribeStage transcribes the input media.
+func (e *realScribeEngine) transcribeStage(ctx context.Context, state *PipelineState) error {
+	transcription, err := e.transcribeWithLimits(ctx, state.MediaPath)
+	if err != nil {
+		return fmt.Errorf("transcription failed: %w", err)
+	}
+
+	state.Transcript = transcription
+	state.TranscriptSource = TranscriptSourceASR
+	state.RecordUsage(ProviderBuiltin, Usage{AudioMinutes: e.mediaDuration(ctx, state).Minutes()})
+	state.Report(0.50, "Transcription complete")
+	return nil
+}
+
+// translateStage translates the transcript into the target language with
+// the first provider of the translation chain that succeeds.
+func (e *realScribeEngine) translateStage(ctx context.Context, state *PipelineState) error {
+	var translation string
+	by, err := withFallback(ctx, CapabilityTranslation, func(ctx context.Context, provider string) (usage Usage, err error) {
+		translation, usage, err = e.translateWith(ctx, provider, state.Transcript, state.Options)
+		return usage, err
+	})
+	if err != nil {
+		return fmt.Errorf("translation failed: %w", err)
+	}
+
+	state.Translation = translation
+	state.recordServed(StageTranslate, by)
+	state.Report(0.65, "Translation complete")
+	return nil
+}
+
+// dubbingStage synthesizes dubbed audio. Failures are reported but do not
+// fail the run, since the transcript and translation are still useful.
+func (e *realScribeEngine) dubbingStage(ctx context.Context, state *PipelineState) error {
+	audioPath, by, err := e.generateDubbingWithLimits(ctx, state.Translation, state.Options, state.OutputDir)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("operation cancelled: %w", ctx.Err())
+		}
+		LoggerFrom(ctx).Warn("Dubbing failed; continuing without dubbed audio", "error", err)
+		state.Report(0.85, fmt.Sprintf("Warning: Dubbing failed: %v", err))
+		return nil
+	}
+
+	state.Artifacts[ArtifactDubbedAudio] = audioPath
+	state.recordServed(StageDubbing, by)
+	state.Report(0.85, "Dubbed audio generated successfully")
+	return nil
+}
+
+// subtitlesStage writes the subtitle file, timed by the caption cues if
+// captions were used and spread over the media duration otherwise.
+func (e *realScribeEngine) subtitlesStage(ctx context.Context, state *PipelineState) error {
+	opts := state.Options
+
+	subtitleGen := NewSubtitleGenerator()
+	if state.Captions != nil {
+		// Caption cues carry real timing
+		subtitleGen.CreateTimedSegments(state.Captions.Segments, state.Translation)
+	} else {
+		// Spread the cues over the actual video duration
+		subtitleGen.CreateDefaultSegments(state.Transcript, state.Translation, e.mediaDuration(ctx, state))
+		// The clip starts at StartTime in the source video
+		subtitleGen.Offset(opts.StartTime)
+	}
+
+	var subtitleContent, extension string
+	if opts.SubtitleFormat == "vtt" {
+		subtitleContent = subtitleGen.GenerateVTT(opts.BilingualSubtitles, opts.SubtitlePosition)
+		extension = ".vtt"
+	} else {
+		subtitleContent = subtitleGen.GenerateSRT(opts.BilingualSubtitles, opts.SubtitlePosition)
+		extension = ".srt"
+	}
+
+	subtitlesPath := filepath.Join(state.OutputDir, "subtitles"+extension)
+	if err := os.WriteFile(subtitlesPath, []byte(subtitleContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write subtitles: %w", err)
+	}
+	state.Artifacts[ArtifactSubtitles] = subtitlesPath
+	return nil
+}
+
+// saveStage writes the transcript and translation to the output directory.
+func saveStage(ctx context.Context, state *PipelineState) error {
+	transcriptPath := filepath.Join(state.OutputDir, "transcription.txt")
+	if err := os.WriteFile(transcriptPath, []byte(state.Transcript), 0o644); err != nil {
+		return fmt.Errorf("failed to write transcription: %w", err)
+	}
+	translationPath := filepath.Join(state.OutputDir, "translation.txt")
+	if err := os.WriteFile(translationPath, []byte(state.Translation), 0o644); err != nil {
+		return fmt.Errorf("failed to write translation: %w", err)
+	}
+
+	state.Artifacts[ArtifactTranscript] = transcriptPath
+	state.Artifacts[ArtifactTranslation] = translationPath
+	return nil
+}
+
+// Patterns used by NormalizeText.
+var (
+	normalizeSpacePattern       = regexp.MustCompile(`\s+`)
+	normalizePunctuationPattern = regexp.MustCompile(`\s+([,.!?;:])`)
+)
+
+// NormalizeText collapses runs of whitespace and removes spaces before punctuation.
+func NormalizeText(text string) string {
+	text = normalizeSpacePattern.ReplaceAllString(strings.TrimSpace(text), " ")
+	return normalizePunctuationPattern.ReplaceAllString(text, "$1")
+}
+
+// NormalizeTextStage returns a stage named "normalize" that applies
+// NormalizeText to the transcript and translation.
+func NormalizeTextStage() Stage {
+	return Stage{
+		Name: "normalize",
+		Run: func(ctx context.Context, state *PipelineState) error {
+			state.Transcript = NormalizeText(state.Transcript)
+			state.Translation = NormalizeText(state.Translation)
+			return nil
+		},
+	}
+}
+
+// ProfanityFilterStage returns a stage named "profanity_filter" that masks
+// the given words, case-insensitively and as whole words, in the transcript
+// and translation. Place it after translation to filter both.
+func ProfanityFilterStage(words []string) Stage {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	var pattern *regexp.Regexp
+	if len(quoted) > 0 {
+		pattern = regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	}
+
+	mask := func(text string) string {
+		return pattern.ReplaceAllStringFunc(text, func(word string) string {
+			return strings.Repeat("*", len([]rune(word)))
+		})
+	}
+
+	return Stage{
+		Name: "profanity_filter",
+		Skip: func(state *PipelineState) bool { return pattern == nil },
+		Run: func(ctx context.Context, state *PipelineState) error {
+			state.Transcript = mask(state.Transcript)
+			state.Translation = mask(state.Translation)
+			return nil
+		},
+	}
+}
+
+// CommandStage returns a stage that runs an external command, e.g. a
+// post-processing script, in the output directory. The command receives the
+// run's state in AKASHIC_* environment variables; a non-zero exit fails the run.
+func CommandStage(name string, command ...string) Stage {
+	return Stage{
+		Name:    name,
+		Message: "Running " + name + "...",
+		Run: func(ctx context.Context, state *PipelineState) error {
+			if len(command) == 0 {
+				return fmt.Errorf("stage %s has no command", name)
+			}
+
+			cmd := newCommand(ctx, command[0], command[1:]...)
+			cmd.Dir = state.OutputDir
+			cmd.Env = append(os.Environ(), stateEnv(state)...)
+			logCommand(ctx, cmd)
+			output, err := cmd.CombinedOutput()
+			logToolOutput(ctx, cmd, output)
+			if err != nil {
+				if ctx.Err() != nil {
+					return fmt.Errorf("operation cancelled: %w", ctx.Err())
+				}
+				return fmt.Errorf("stage %s failed: %w: %s", name, err, lastLines(string(output), 5))
+			}
+			return nil
+		},
+	}
+}
+
+// stateEnv describes a pipeline state as environment variables.
+func stateEnv(state *PipelineState) []string {
+	env := []string{
+		"AKASHIC_OUTPUT_DIR=" + state.OutputDir,
+		"AKASHIC_MEDIA_PATH=" + state.MediaPath,
+		"AKASHIC_INPUT_FILE=" + state.Options.InputFile,
+		"AKASHIC_INPUT_URL=" + state.Options.InputURL,
+		"AKASHIC_ORIGIN_LANGUAGE=" + state.Options.OriginLanguage,
+		"AKASHIC_TARGET_LANGUAGE=" + state.Options.TargetLanguage,
+	}
+	for kind, path := range state.Artifacts {
+		env = append(env, "AKASHIC_ARTIFACT_"+strings.ToUpper(kind)+"="+path)
+	}
+	return env
+}
@@ -0,0 +1,329 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTools installs shell-script stand-ins for yt-dlp, ffmpeg and ffprobe at
+// the front of PATH. yt-dlp records its arguments in the returned log file.
+func fakeTools(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools are shell scripts")
+	}
+
+	dir := t.TempDir()
+	argsLog := filepath.Join(dir, "yt-dlp.args")
+
+	scripts := map[string]string{
+		// Writes the file named by -o with %(ext)s replaced, or prints
+		// metadata for a 90 second video with -J
+		"yt-dlp": `#!/bin/sh
+echo "$@" >> "` + argsLog + `"
+if [ "$1" = "-J" ]; then echo '{"title": "Talk", "duration": 90.0}'; exit 0; fi
+out=""
+while [ $# -gt 0 ]; do
+  if [ "$1" = "-o" ]; then shift; out="$1"; fi
+  shift
+done
+echo "[download]  50.0% of 1.00MiB"
+echo "[download] 100.0% of 1.00MiB"
+printf 'audio' > "$(echo "$out" | sed 's/%(ext)s/m4a/')"
+`,
+		// Writes the last argument (the output file)
+		"ffmpeg": `#!/bin/sh
+for last; do :; done
+printf 'audio' > "$last"
+`,
+		"ffprobe": `#!/bin/sh
+echo 12.5
+`,
+	}
+	for name, script := range scripts {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755))
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return argsLog
+}
+
+// drainProgress collects progress updates until the returned function is called.
+func drainProgress() (chan ProgressUpdate, func() []ProgressUpdate) {
+	progress := make(chan ProgressUpdate)
+	done := make(chan []ProgressUpdate)
+	go func() {
+		var updates []ProgressUpdate
+		for update := range progress {
+			updates = append(updates, update)
+		}
+		done <- updates
+	}()
+	return progress, func() []ProgressUpdate {
+		close(progress)
+		return <-done
+	}
+}
+
+// stageNames returns the stage names of a result's timings.
+func stageNames(result *ScribeResult) []string {
+	var names []string
+	for _, timing := range result.StageTimings {
+		names = append(names, timing.Stage)
+	}
+	return names
+}
+
+func TestRealEngine_ProcessLocalFile(t *testing.T) {
+	fakeTools(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.mp4")
+	require.NoError(t, os.WriteFile(input, []byte("video"), 0o644))
+
+	progress, stop := drainProgress()
+	result, err := NewRealScribeEngine().ProcessWithContext(context.Background(), ScribeOptions{
+		InputFile:       input,
+		TargetLanguage:  "ja-JP",
+		CreateSubtitles: true,
+		OutputDir:       filepath.Join(dir, "out"),
+	}, progress)
+	updates := stop()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{StageTranscribe, StageTranslate, StageSubtitles, StageSave}, stageNames(result))
+	assert.Equal(t, TranscriptSourceASR, result.TranscriptSource)
+	assert.Equal(t, filepath.Join(dir, "out"), result.OutputDir)
+	assert.FileExists(t, filepath.Join(dir, "out", "transcription.txt"))
+	assert.FileExists(t, filepath.Join(dir, "out", "translation.txt"))
+	assert.Equal(t, filepath.Join(dir, "out", "subtitles.srt"), result.SubtitlesFile)
+	assert.FileExists(t, result.SubtitlesFile)
+
+	require.NotEmpty(t, updates)
+	assert.Equal(t, 0.0, updates[0].Percentage)
+	assert.Equal(t, 1.0, updates[len(updates)-1].Percentage)
+}
+
+func TestRealEngine_ProcessURLDownloadsAudioOnly(t *testing.T) {
+	argsLog := fakeTools(t)
+	dir := t.TempDir()
+
+	progress, stop := drainProgress()
+	result, err := NewRealScribeEngine().ProcessWithContext(context.Background(), ScribeOptions{
+		InputURL:       "https://example.com/watch?v=abc",
+		TargetLanguage: "ja-JP",
+		OutputDir:      dir,
+	}, progress)
+	updates := stop()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{StageDownload, StageTranscribe, StageTranslate, StageSave}, stageNames(result))
+
+	// Download progress is reported in bytes
+	var downloads []ProgressUpdate
+	for _, update := range updates {
+		if update.Stage == StageDownload && update.BytesTotal > 0 {
+			downloads = append(downloads, update)
+		}
+	}
+	require.Len(t, downloads, 2)
+	assert.Equal(t, int64(1<<20), downloads[0].BytesTotal)
+	assert.Equal(t, int64(1<<19), downloads[0].BytesDone)
+	assert.Equal(t, 0.5, downloads[0].StageProgress)
+	assert.Equal(t, 1.0, downloads[1].StageProgress)
+
+	args, err := os.ReadFile(argsLog)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "--no-playlist")
+	assert.Contains(t, string(args), "-f bestaudio/best")
+
+	// The scratch directory holding the download is removed
+	fields := strings.Fields(string(args))
+	for i, field := range fields {
+		if field == "-o" && i+1 < len(fields) {
+			assert.NoDirExists(t, filepath.Dir(fields[i+1]))
+		}
+	}
+}
+
+func TestRealEngine_PermanentDownloadFailureIsInvalidInput(t *testing.T) {
+	argsLog := fakeTools(t)
+	script := "#!/bin/sh\necho 'ERROR: [youtube] abc: Private video. Sign in if you have been granted access' >&2\nexit 1\n"
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(argsLog), "yt-dlp"), []byte(script), 0o755))
+
+	progress, stop := drainProgress()
+	_, err := NewRealScribeEngine().ProcessWithContext(context.Background(), ScribeOptions{
+		InputURL:       "https://example.com/watch?v=abc",
+		TargetLanguage: "ja-JP",
+		OutputDir:      t.TempDir(),
+	}, progress)
+	stop()
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err), "Private videos are not retried")
+
+	_, err = NewRealScribeEngine().Transcribe("https://example.com/watch?v=abc")
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+}
+
+func TestRealEngine_TranscribeURLSelectsAudioFormat(t *testing.T) {
+	argsLog := fakeTools(t)
+
+	_, err := NewRealScribeEngine().Transcribe("https://example.com/watch?v=abc")
+	require.NoError(t, err)
+
+	args, err := os.ReadFile(argsLog)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "--no-playlist -f bestaudio/best -o ")
+}
+
+func TestRealEngine_StartProcessingReportsResult(t *testing.T) {
+	fakeTools(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.mp4")
+	require.NoError(t, os.WriteFile(input, []byte("video"), 0o644))
+
+	progress, stop := drainProgress()
+	err := NewRealScribeEngine().StartProcessing(context.Background(), ScribeOptions{
+		InputFile:      input,
+		TargetLanguage: "ja-JP",
+	}, progress)
+	updates := stop()
+	require.NoError(t, err)
+
+	final := updates[len(updates)-1]
+	assert.Equal(t, 1.0, final.Percentage)
+	assert.Equal(t, "Scribing complete.", final.Message)
+
+	result := final.Result
+	require.NotNil(t, result)
+	assert.NotEmpty(t, result.Transcription)
+	assert.NotEmpty(t, result.Translation)
+	assert.Equal(t, dir, result.OutputDir)
+}
+
+func TestRealEngine_ProcessInvalidInput(t *testing.T) {
+	fakeTools(t)
+	engine := NewRealScribeEngine()
+
+	progress, stop := drainProgress()
+	_, err := engine.ProcessWithContext(context.Background(), ScribeOptions{OutputDir: t.TempDir()}, progress)
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+
+	_, err = engine.ProcessWithContext(context.Background(), ScribeOptions{
+		InputFile: filepath.Join(t.TempDir(), "missing.mp4"),
+	}, progress)
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+	stop()
+}
+
+func TestRealEngine_ProcessCancelled(t *testing.T) {
+	fakeTools(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.mp4")
+	require.NoError(t, os.WriteFile(input, []byte("video"), 0o644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	progress, stop := drainProgress()
+	err := NewRealScribeEngine().StartProcessing(ctx, ScribeOptions{InputFile: input}, progress)
+	updates := stop()
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, updates, "no progress is reported after cancellation")
+	assert.NoFileExists(t, filepath.Join(dir, "transcription.txt"))
+}
+
+func TestRealEngine_CustomPipeline(t *testing.T) {
+	fakeTools(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.mp4")
+	require.NoError(t, os.WriteFile(input, []byte("video"), 0o644))
+
+	p := DefaultPipeline().Clone("custom-test")
+	require.NoError(t, p.After(StageTranscribe, func(ctx context.Context, stage string, state *PipelineState) error {
+		state.Transcript = "hooked: " + state.Transcript
+		return nil
+	}))
+	require.NoError(t, p.Append(CommandStage("post", "sh", "-c",
+		`printf '%s' "$AKASHIC_ARTIFACT_TRANSCRIPT" > post.txt`)))
+	require.NoError(t, p.Append(Stage{
+		Name: "register",
+		Run: func(ctx context.Context, state *PipelineState) error {
+			state.Artifacts["post"] = filepath.Join(state.OutputDir, "post.txt")
+			return nil
+		},
+	}))
+	require.NoError(t, RegisterPipeline(p))
+
+	outputDir := filepath.Join(dir, "out")
+	progress, stop := drainProgress()
+	result, err := NewRealScribeEngine().ProcessWithContext(context.Background(), ScribeOptions{
+		InputFile: input,
+		Pipeline:  "custom-test",
+		OutputDir: outputDir,
+	}, progress)
+	stop()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(result.Transcription, "hooked: "))
+	assert.Equal(t, []string{StageTranscribe, StageTranslate, StageSave, "post", "register"}, stageNames(result))
+
+	post, err := os.ReadFile(filepath.Join(outputDir, "post.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(outputDir, "transcription.txt"), string(post))
+	assert.Equal(t, filepath.Join(outputDir, "post.txt"), result.Artifacts["post"])
+	assert.Equal(t, filepath.Join(outputDir, "transcription.txt"), result.Artifacts[ArtifactTranscript])
+}
+
+func TestRealEngine_UnknownPipeline(t *testing.T) {
+	fakeTools(t)
+
+	progress, stop := drainProgress()
+	_, err := NewRealScribeEngine().ProcessWithContext(context.Background(), ScribeOptions{
+		InputFile: "talk.mp4",
+		Pipeline:  "no-such-pipeline",
+		OutputDir: t.TempDir(),
+	}, progress)
+	stop()
+
+	require.Error(t, err)
+	assert.Equal(t, ErrorClassInvalidInput, ClassifyError(err))
+}
+
+func TestCommandStage_Failure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	state := NewPipelineState(ScribeOptions{OutputDir: t.TempDir()}, nil)
+	err := CommandStage("fail", "sh", "-c", "echo broken >&2; exit 3").Run(context.Background(), state)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stage fail failed")
+	assert.Contains(t, err.Error(), "broken")
+}
+
+func TestNormalizeText(t *testing.T) {
+	assert.Equal(t, "Hello, world! How are you?", NormalizeText("  Hello ,  world !\n\nHow   are you ? "))
+	assert.Equal(t, "", NormalizeText(" \n\t "))
+}
+
+func TestProfanityFilterStage(t *testing.T) {
+	stage := ProfanityFilterStage([]string{"darn", " heck ", ""})
+	state := NewPipelineState(ScribeOptions{}, nil)
+	state.Transcript = "Darn it, what the heck. Darnation stays."
+	state.Translation = "darn"
+
+	require.False(t, stage.Skip(state))
+	require.NoError(t, stage.Run(context.Background(), state))
+	assert.Equal(t, "**** it, what the ****. Darnation stays.", state.Transcript)
+	assert.Equal(t, "****", state.Translation)
+
+	assert.True(t, ProfanityFilterStage(nil).Skip(state), "an empty word list is a no-op")
+}
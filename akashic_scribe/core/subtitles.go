@@ -2,9 +2,11 @@ package core
 
 import (
 	"fmt"
+	"math"
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // SubtitleSegment represents a single subtitle entry with timing information.
@@ -178,6 +180,52 @@ func (sg *SubtitleGenerator) CreateDefaultSegments(originalText, translatedText
 	}
 }
 
+// CreateTimedSegments creates segments that follow the timing of existing cues,
+// such as platform captions. The cue text becomes the original text and the
+// translated text is spread across the cues in proportion to their length.
+func (sg *SubtitleGenerator) CreateTimedSegments(cues []SubtitleSegment, translatedText string) {
+	if len(cues) == 0 {
+		return
+	}
+
+	// Languages written without spaces are split per character instead of per word
+	units := strings.Fields(translatedText)
+	separator := " "
+	if len(units) < len(cues) {
+		units = strings.Split(strings.Join(units, ""), "")
+		separator = ""
+	}
+
+	total := 0
+	for _, cue := range cues {
+		total += utf8.RuneCountInString(cue.Text)
+	}
+
+	weight, next := 0, 0
+	for i, cue := range cues {
+		weight += utf8.RuneCountInString(cue.Text)
+		end := len(units)
+		if total > 0 && i < len(cues)-1 {
+			end = int(math.Round(float64(weight) / float64(total) * float64(len(units))))
+		}
+		if end < next {
+			end = next
+		}
+
+		sg.AddSegment(cue.StartTime, cue.EndTime, strings.Join(units[next:end], separator), cue.Text)
+		next = end
+	}
+}
+
+// Offset shifts all segments by d, e.g. to align subtitles for a clip with
+// the full video it was cut from.
+func (sg *SubtitleGenerator) Offset(d time.Duration) {
+	for i := range sg.segments {
+		sg.segments[i].StartTime += d
+		sg.segments[i].EndTime += d
+	}
+}
+
 // splitIntoSentences splits text into sentences using robust regex patterns.
 // Handles common edge cases like abbreviations, multiple spaces, and end-of-text.
 func splitIntoSentences(text string) []string {
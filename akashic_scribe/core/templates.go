@@ -4,7 +4,6 @@ import (
 	"crypto/md5"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -91,8 +90,10 @@ func (tm *TemplateManager) SaveTemplate(template *ProjectTemplate) error {
 // LoadTemplate loads a template by name.
 func (tm *TemplateManager) LoadTemplate(name string) (*ProjectTemplate, error) {
 	// Check cache first
-	if template, exists := tm.templates[name]; exists {
-		return template, nil
+	cached, exists := tm.templates[name]
+	DefaultMetrics().RecordCacheLookup("templates", exists)
+	if exists {
+		return cached, nil
 	}
 
 	// Try to load from disk
@@ -133,13 +134,13 @@ func (tm *TemplateManager) LoadAll() error {
 		filePath := filepath.Join(tm.templatesDir, entry.Name())
 		data, err := os.ReadFile(filePath)
 		if err != nil {
-			log.Printf("Warning: Could not read template file %s: %v", filePath, err)
+			componentLogger("templates").Warn("Could not read template file", "path", filePath, "error", err)
 			continue // Skip files we can't read
 		}
 
 		var template ProjectTemplate
 		if err := json.Unmarshal(data, &template); err != nil {
-			log.Printf("Warning: Could not parse template file %s: %v", filePath, err)
+			componentLogger("templates").Warn("Could not parse template file", "path", filePath, "error", err)
 			continue // Skip invalid templates
 		}
 
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Span is a timed operation in a trace: a batch job, a pipeline run, a
+// stage or a provider request. It mirrors the part of the OpenTelemetry span
+// API the engine uses, so a Tracer can forward spans to an OpenTelemetry SDK.
+type Span interface {
+	SetAttributes(attrs ...slog.Attr) // Adds attributes to the span
+	End(err error)                    // Finishes the span; err is nil on success
+}
+
+// Tracer starts spans. The returned context carries the new span, so spans
+// started from it become its children.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span)
+}
+
+// tracerHolder lets an interface value be stored in an atomic.Pointer.
+type tracerHolder struct {
+	tracer Tracer
+}
+
+// defaultTracer starts the spans of the engine and BatchProcessor.
+var defaultTracer atomic.Pointer[tracerHolder]
+
+// SetTracer installs the tracer used for all spans. nil disables tracing,
+// which is the default.
+func SetTracer(tracer Tracer) {
+	if tracer == nil {
+		defaultTracer.Store(nil)
+		return
+	}
+	defaultTracer.Store(&tracerHolder{tracer: tracer})
+}
+
+// StartSpan starts a span with the installed tracer. Without a tracer it
+// returns ctx and a span that does nothing.
+func StartSpan(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	holder := defaultTracer.Load()
+	if holder == nil {
+		return ctx, noopSpan{}
+	}
+	return holder.tracer.Start(ctx, name, attrs...)
+}
+
+// noopSpan is the span returned when tracing is disabled.
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...slog.Attr) {}
+func (noopSpan) End(error)                  {}
+
+// SpanRecord is a finished span kept by a SpanRecorder.
+type SpanRecord struct {
+	TraceID    string
+	SpanID     string
+	ParentID   string // Empty for root spans
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes []slog.Attr
+	Error      string
+}
+
+// Duration returns how long the span took.
+func (r SpanRecord) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// SpanRecorder is a Tracer that keeps finished spans in memory, for tests
+// and for inspecting where a job spent its time.
+type SpanRecorder struct {
+	mu    sync.Mutex
+	spans []SpanRecord
+}
+
+// NewSpanRecorder creates an empty SpanRecorder.
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+// recordedSpanKey is the context key for the SpanRecorder span a context belongs to.
+type recordedSpanKey struct{}
+
+// Start implements Tracer.
+func (r *SpanRecorder) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	span := &recordedSpan{
+		recorder: r,
+		record: SpanRecord{
+			SpanID:     randomHex(8),
+			Name:       name,
+			Start:      time.Now(),
+			Attributes: attrs,
+		},
+	}
+	if parent, ok := ctx.Value(recordedSpanKey{}).(*recordedSpan); ok {
+		span.record.TraceID = parent.record.TraceID
+		span.record.ParentID = parent.record.SpanID
+	} else {
+		span.record.TraceID = randomHex(16)
+	}
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+// Spans returns the finished spans in the order they ended.
+func (r *SpanRecorder) Spans() []SpanRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]SpanRecord(nil), r.spans...)
+}
+
+// recordedSpan is a span started by a SpanRecorder.
+type recordedSpan struct {
+	recorder *SpanRecorder
+	mu       sync.Mutex
+	record   SpanRecord
+	ended    bool
+}
+
+// SetAttributes implements Span.
+func (s *recordedSpan) SetAttributes(attrs ...slog.Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.record.Attributes = append(s.record.Attributes, attrs...)
+}
+
+// End implements Span. Only the first call has an effect.
+func (s *recordedSpan) End(err error) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.record.End = time.Now()
+	if err != nil {
+		s.record.Error = err.Error()
+	}
+	record := s.record
+	s.mu.Unlock()
+
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+
+	s.recorder.spans = append(s.recorder.spans, record)
+}
+
+// randomHex returns n random bytes as a hex string.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordSpans installs a SpanRecorder for the rest of the test.
+func recordSpans(t *testing.T) *SpanRecorder {
+	t.Helper()
+
+	recorder := NewSpanRecorder()
+	SetTracer(recorder)
+	t.Cleanup(func() { SetTracer(nil) })
+	return recorder
+}
+
+// spanNamed returns the recorded span with the given name.
+func spanNamed(t *testing.T, spans []SpanRecord, name string) SpanRecord {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	require.Failf(t, "span not recorded", "no span named %q in %v", name, spans)
+	return SpanRecord{}
+}
+
+// spanAttr returns the value of a span attribute as a string.
+func spanAttr(span SpanRecord, key string) string {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value.String()
+		}
+	}
+	return ""
+}
+
+func TestStartSpan_DisabledByDefault(t *testing.T) {
+	ctx := context.Background()
+	spanCtx, span := StartSpan(ctx, "noop")
+	assert.Equal(t, ctx, spanCtx)
+	span.End(nil)
+}
+
+func TestSpanRecorder_StagesAreChildrenOfThePipeline(t *testing.T) {
+	recorder := recordSpans(t)
+	fakeTools(t)
+
+	progress, stop := drainProgress()
+	_, err := NewRealScribeEngine().ProcessWithContext(context.Background(), ScribeOptions{
+		InputURL:       "https://example.com/watch?v=talk",
+		TargetLanguage: "ja-JP",
+		OutputDir:      t.TempDir(),
+	}, progress)
+	stop()
+	require.NoError(t, err)
+
+	spans := recorder.Spans()
+	pipeline := spanNamed(t, spans, "pipeline default")
+	assert.Empty(t, pipeline.ParentID)
+
+	for _, name := range []string{"stage download", "stage transcribe", "stage translate", "stage save"} {
+		stage := spanNamed(t, spans, name)
+		assert.Equal(t, pipeline.TraceID, stage.TraceID, name)
+		assert.Equal(t, pipeline.SpanID, stage.ParentID, name)
+		assert.False(t, stage.End.Before(stage.Start), name)
+	}
+}
+
+func TestSpanRecorder_RecordsErrorsAndJobs(t *testing.T) {
+	recorder := recordSpans(t)
+
+	ctx, parent := StartSpan(context.Background(), "parent")
+	_, child := StartSpan(ctx, "child")
+	child.End(errors.New("failed"))
+	child.End(nil)
+	parent.End(nil)
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 2, "End only records a span once")
+	assert.Equal(t, "failed", spans[0].Error)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentID)
+
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	defer bp.Shutdown()
+	jobID := bp.AddJob(ScribeOptions{InputFile: "a.mp4", TargetLanguage: "Spanish"})
+	bp.Wait()
+
+	job := spanNamed(t, recorder.Spans(), "job")
+	assert.Empty(t, job.Error)
+	assert.Equal(t, jobID, spanAttr(job, "job_id"))
+	assert.Equal(t, "Completed", spanAttr(job, "status"))
+}
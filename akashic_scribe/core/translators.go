@@ -0,0 +1,168 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// translateWith translates text into opts.TargetLanguage with provider and
+// returns the translation and the usage to bill for it.
+func (e *realScribeEngine) translateWith(ctx context.Context, provider, text string, opts ScribeOptions) (string, Usage, error) {
+	switch config := ProvidersFrom(ctx).Config(provider); config.Type {
+	case ProviderTypeBuiltin:
+		translation, err := e.translateBuiltin(text, opts.TargetLanguage)
+		if err != nil {
+			return "", Usage{}, err
+		}
+		return translation, Usage{Tokens: estimateTokens(text) + estimateTokens(translation)}, nil
+	case ProviderTypeOpenAI:
+		return translateChat(ctx, provider, text, opts)
+	case ProviderTypeLibreTranslate:
+		return translateLibre(ctx, provider, text, opts)
+	default:
+		return "", Usage{}, NewScribeError(ErrorClassInvalidInput, "translate",
+			fmt.Errorf("provider %s (type %q) cannot translate", provider, config.Type))
+	}
+}
+
+// translateChat translates text with an OpenAI-compatible chat completions API.
+func translateChat(ctx context.Context, provider, text string, opts ScribeOptions) (string, Usage, error) {
+	key, err := providerKey(ctx, opts, provider)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	instruction := "Translate the user's text into " + opts.TargetLanguage
+	if opts.OriginLanguage != "" {
+		instruction += " from " + opts.OriginLanguage
+	}
+	request := map[string]interface{}{
+		"model": ProvidersFrom(ctx).Config(provider).ChatModel,
+		"messages": []map[string]string{
+			{"role": "system", "content": instruction + ". Reply with the translation only."},
+			{"role": "user", "content": text},
+		},
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			TotalTokens int64 `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := postProviderJSON(ctx, provider, "translate", "/chat/completions", key, request, utf8.RuneCountInString(text), &response); err != nil {
+		return "", Usage{}, err
+	}
+	if len(response.Choices) == 0 {
+		return "", Usage{}, NewScribeError(ErrorClassTransient, "translate", fmt.Errorf("%s returned no translation", provider))
+	}
+
+	translation := strings.TrimSpace(response.Choices[0].Message.Content)
+	tokens := response.Usage.TotalTokens
+	if tokens == 0 {
+		tokens = estimateTokens(text) + estimateTokens(translation)
+	}
+	return translation, Usage{Tokens: tokens}, nil
+}
+
+// translateLibre translates text with a LibreTranslate server.
+func translateLibre(ctx context.Context, provider, text string, opts ScribeOptions) (string, Usage, error) {
+	key, err := providerKey(ctx, opts, provider)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	source := languageCode(opts.OriginLanguage)
+	if source == "" {
+		source = "auto"
+	}
+	request := map[string]interface{}{
+		"q":      text,
+		"source": source,
+		"target": languageCode(opts.TargetLanguage),
+		"format": "text",
+	}
+	if key != "" {
+		request["api_key"] = key.Reveal()
+		key = ""
+	}
+
+	var response struct {
+		TranslatedText string `json:"translatedText"`
+	}
+	characters := utf8.RuneCountInString(text)
+	if err := postProviderJSON(ctx, provider, "translate", "/translate", key, request, characters, &response); err != nil {
+		return "", Usage{}, err
+	}
+	return response.TranslatedText, Usage{Characters: int64(characters)}, nil
+}
+
+// languageCode returns the language part of a tag such as "ja-JP", in lower case.
+func languageCode(tag string) string {
+	language, _, _ := strings.Cut(tag, "-")
+	return strings.ToLower(strings.TrimSpace(language))
+}
+
+// postProviderJSON posts body as JSON to path under provider's base URL and
+// decodes the JSON response into out. key, if set, is sent as a bearer
+// token. The request is subject to the provider's limits, traced and recorded
+// in the provider metrics. Network failures and HTTP 429 and 5xx responses
+// are classified as transient.
+func postProviderJSON(ctx context.Context, provider, op, path string, key Secret, body interface{}, characters int, out interface{}) (err error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ctx, span := StartSpan(ctx, "provider "+provider, slog.String("provider", provider), slog.String("operation", op))
+	started := time.Now()
+	defer func() {
+		span.End(err)
+		DefaultMetrics().ObserveProviderRequest(provider, time.Since(started), err)
+	}()
+
+	providers := ProvidersFrom(ctx)
+	req, err := providers.NewRequest(ctx, provider, "POST", path, bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key.Reveal())
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := providers.Do(ctx, provider, req, characters)
+	if err != nil {
+		if ctx.Err() != nil || ClassifyError(err) == ErrorClassInvalidInput {
+			return err
+		}
+		return NewScribeError(ErrorClassTransient, op, fmt.Errorf("failed to make %s request: %w", provider, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return NewScribeError(classifyHTTPStatus(resp.StatusCode), op,
+			fmt.Errorf("%s API error (status %d): %s", provider, resp.StatusCode, string(bodyBytes)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return NewScribeError(ErrorClassTransient, op, fmt.Errorf("truncated %s response: %w", provider, err))
+		}
+		return fmt.Errorf("failed to decode %s response: %w", provider, err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// ProviderBuiltin is the provider name under which the built-in placeholder
+// transcription and translation record their usage. It has no price by default.
+const ProviderBuiltin = "builtin"
+
+// ErrBudgetExceeded is returned for batch jobs that were not started because
+// the batch budget has been spent.
+var ErrBudgetExceeded = errors.New("batch budget exceeded")
+
+// Usage is the billable work a provider did for a job.
+type Usage struct {
+	Characters   int64   `json:"characters,omitempty"`    // Characters synthesized by TTS
+	AudioMinutes float64 `json:"audio_minutes,omitempty"` // Minutes of audio transcribed
+	Tokens       int64   `json:"tokens,omitempty"`        // Tokens translated, input and output
+}
+
+// Add adds other to u.
+func (u *Usage) Add(other Usage) {
+	u.Characters += other.Characters
+	u.AudioMinutes += other.AudioMinutes
+	u.Tokens += other.Tokens
+}
+
+// ProviderPrice is what a provider charges, in USD.
+type ProviderPrice struct {
+	PerMillionCharacters float64 `json:"per_million_characters,omitempty"` // TTS
+	PerAudioMinute       float64 `json:"per_audio_minute,omitempty"`       // Transcription
+	PerMillionTokens     float64 `json:"per_million_tokens,omitempty"`     // Translation
+}
+
+// Cost returns the price of usage.
+func (p ProviderPrice) Cost(usage Usage) float64 {
+	return float64(usage.Characters)/1e6*p.PerMillionCharacters +
+		usage.AudioMinutes*p.PerAudioMinute +
+		float64(usage.Tokens)/1e6*p.PerMillionTokens
+}
+
+// validate checks that no price is negative.
+func (p ProviderPrice) validate() error {
+	if p.PerMillionCharacters < 0 || p.PerAudioMinute < 0 || p.PerMillionTokens < 0 {
+		return errors.New("prices cannot be negative")
+	}
+	return nil
+}
+
+// PriceTable holds the prices of each provider, keyed by provider name.
+// Usage of providers missing from the table costs nothing.
+type PriceTable map[string]ProviderPrice
+
+// DefaultPriceTable returns list prices for the providers the engine uses:
+// OpenAI tts-1-hd for dubbing and Whisper for transcription.
+func DefaultPriceTable() PriceTable {
+	return PriceTable{
+		ProviderOpenAI: {PerMillionCharacters: 30, PerAudioMinute: 0.006},
+	}
+}
+
+// Merge returns a copy of t with the prices in overrides replacing those of
+// the same providers.
+func (t PriceTable) Merge(overrides PriceTable) PriceTable {
+	merged := make(PriceTable, len(t)+len(overrides))
+	for provider, price := range t {
+		merged[provider] = price
+	}
+	for provider, price := range overrides {
+		merged[provider] = price
+	}
+	return merged
+}
+
+// Costs prices the usage of each provider. Providers without usage are omitted.
+func (t PriceTable) Costs(usage map[string]Usage) map[string]float64 {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	costs := make(map[string]float64, len(usage))
+	for provider, u := range usage {
+		costs[provider] = t[provider].Cost(u)
+	}
+	return costs
+}
+
+// priceTableKey is the context key for the price table used to cost a job.
+type priceTableKey struct{}
+
+// WithPriceTable returns a context under which pipeline runs cost their usage
+// with prices. BatchProcessor attaches its price table this way.
+func WithPriceTable(ctx context.Context, prices PriceTable) context.Context {
+	return context.WithValue(ctx, priceTableKey{}, prices)
+}
+
+// PriceTableFrom returns the price table attached to ctx, or DefaultPriceTable.
+func PriceTableFrom(ctx context.Context) PriceTable {
+	if prices, ok := ctx.Value(priceTableKey{}).(PriceTable); ok {
+		return prices
+	}
+	return DefaultPriceTable()
+}
+
+// TotalCost returns the sum of the result's provider costs.
+func (r *ScribeResult) TotalCost() float64 {
+	var total float64
+	for _, cost := range r.ProviderCosts {
+		total += cost
+	}
+	return total
+}
+
+// RecordUsage adds usage by provider to the run. Custom stages that call paid
+// APIs record their usage this way so it is costed with the built-in stages.
+func (s *PipelineState) RecordUsage(provider string, usage Usage) {
+	if s.Usage == nil {
+		s.Usage = make(map[string]Usage)
+	}
+	total := s.Usage[provider]
+	total.Add(usage)
+	s.Usage[provider] = total
+}
+
+// estimateTokens approximates the number of LLM tokens in text, at about
+// four characters per token.
+func estimateTokens(text string) int64 {
+	return int64(math.Ceil(float64(utf8.RuneCountInString(text)) / 4))
+}
+
+// SetPriceTable replaces the prices used to cost jobs started afterwards.
+// nil selects DefaultPriceTable.
+func (bp *BatchProcessor) SetPriceTable(prices PriceTable) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	bp.prices = prices
+}
+
+// SetBudget sets the most the batch may spend, in USD (0 = unlimited). Once
+// completed jobs have cost that much, jobs that have not started yet fail
+// with ErrBudgetExceeded. Jobs already running finish, so the budget can be
+// overrun by the cost of the jobs in flight.
+func (bp *BatchProcessor) SetBudget(budget float64) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	bp.budget = budget
+}
+
+// Spending returns the budget and the estimated cost of the jobs completed so far.
+func (bp *BatchProcessor) Spending() (budget, spent float64) {
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+
+	return bp.budget, bp.spent
+}
+
+// checkBudget returns an error if the batch budget has been spent.
+func (bp *BatchProcessor) checkBudget() error {
+	budget, spent := bp.Spending()
+	if budget > 0 && spent >= budget {
+		return NewScribeError(ErrorClassInvalidInput, "budget",
+			fmt.Errorf("%w: spent $%.4f of $%.4f", ErrBudgetExceeded, spent, budget))
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceTable_Costs(t *testing.T) {
+	prices := DefaultPriceTable().Merge(PriceTable{
+		ProviderBuiltin: {PerMillionTokens: 2},
+	})
+	assert.Equal(t, 30.0, prices[ProviderOpenAI].PerMillionCharacters, "Merge keeps the defaults of other providers")
+
+	costs := prices.Costs(map[string]Usage{
+		ProviderOpenAI:  {Characters: 100_000, AudioMinutes: 10},
+		ProviderBuiltin: {Tokens: 500_000},
+		"unpriced":      {Tokens: 1_000_000},
+	})
+	assert.InDelta(t, 3.06, costs[ProviderOpenAI], 1e-9)
+	assert.InDelta(t, 1.0, costs[ProviderBuiltin], 1e-9)
+	assert.Contains(t, costs, "unpriced")
+	assert.Zero(t, costs["unpriced"])
+
+	assert.Nil(t, prices.Costs(nil))
+	assert.InDelta(t, 4.06, (&ScribeResult{ProviderCosts: costs}).TotalCost(), 1e-9)
+
+	config := DefaultConfig()
+	config.Prices = PriceTable{ProviderOpenAI: {PerAudioMinute: -1}}
+	assert.Error(t, config.Validate())
+	config.Prices = nil
+	config.BatchBudget = -5
+	assert.Error(t, config.Validate())
+}
+
+func TestPipeline_RecordsUsageAndCosts(t *testing.T) {
+	fakeTools(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.mp4")
+	require.NoError(t, os.WriteFile(input, []byte("video"), 0o644))
+
+	ctx := WithPriceTable(context.Background(), PriceTable{ProviderBuiltin: {PerAudioMinute: 0.6}})
+	progress, stop := drainProgress()
+	result, err := NewRealScribeEngine().ProcessWithContext(ctx, ScribeOptions{
+		InputFile:       input,
+		TargetLanguage:  "ja-JP",
+		OutputDir:       filepath.Join(dir, "out"),
+		CreateSubtitles: true,
+	}, progress)
+	stop()
+	require.NoError(t, err)
+
+	usage := result.Usage[ProviderBuiltin]
+	assert.InDelta(t, 12.5/60, usage.AudioMinutes, 1e-9, "The fake ffprobe reports 12.5 seconds")
+	assert.Equal(t, estimateTokens(result.Transcription)+estimateTokens(result.Translation), usage.Tokens)
+	assert.InDelta(t, 0.125, result.ProviderCosts[ProviderBuiltin], 1e-9)
+	assert.NotContains(t, result.Usage, ProviderOpenAI, "Nothing was synthesized")
+}
+
+func TestBatchProcessor_BudgetHaltsNewJobs(t *testing.T) {
+	engine := newScriptedEngine(func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error) {
+		return &ScribeResult{ProviderCosts: map[string]float64{ProviderOpenAI: 0.6}}, nil
+	})
+
+	bp := NewBatchProcessor(engine, 1)
+	defer bp.Shutdown()
+	bp.SetBudget(1.0)
+
+	ids := []string{
+		bp.AddJob(ScribeOptions{InputFile: "a.mp4"}),
+		bp.AddJob(ScribeOptions{InputFile: "b.mp4"}),
+		bp.AddJob(ScribeOptions{InputFile: "c.mp4"}),
+	}
+	bp.Wait()
+
+	for _, id := range ids[:2] {
+		job, _ := bp.JobSnapshot(id)
+		assert.Equal(t, JobCompleted, job.Status)
+	}
+	halted, _ := bp.JobSnapshot(ids[2])
+	assert.Equal(t, JobFailed, halted.Status)
+	assert.True(t, errors.Is(halted.Error, ErrBudgetExceeded))
+	assert.Equal(t, ErrorClassInvalidInput, halted.ErrorClass)
+	assert.Equal(t, int32(2), engine.calls.Load(), "The halted job never reached the engine")
+
+	budget, spent := bp.Spending()
+	assert.Equal(t, 1.0, budget)
+	assert.InDelta(t, 1.2, spent, 1e-9)
+
+	report := bp.Report()
+	assert.Equal(t, 1.0, report.Budget)
+	assert.InDelta(t, 1.2, report.TotalCost, 1e-9)
+}
@@ -0,0 +1,377 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Subfolders created inside every watched folder.
+const (
+	WatchDoneDir   = "done"   // Successfully processed input files
+	WatchErrorDir  = "error"  // Input files whose job failed or was cancelled
+	WatchOutputDir = "output" // Default location for job outputs
+)
+
+// DefaultWatchStableDuration is how long a file's size must stay unchanged
+// before it is considered fully written.
+const DefaultWatchStableDuration = 5 * time.Second
+
+// WatchFolder maps a watched folder to the template applied to its files.
+type WatchFolder struct {
+	Path      string `json:"path"`                 // Folder to watch (not recursive)
+	Template  string `json:"template,omitempty"`   // Template applied to new files (empty = config defaults only)
+	OutputDir string `json:"output_dir,omitempty"` // Output root (empty = <path>/output); each file gets its own subfolder, e.g. talk_mp4
+	Pipeline  string `json:"pipeline,omitempty"`   // Pipeline for new files (empty = the template's or "default")
+}
+
+// watchExtensions lists the media file extensions picked up by a FolderWatcher.
+var watchExtensions = map[string]bool{
+	".mp4": true, ".mkv": true, ".mov": true, ".avi": true, ".webm": true, ".m4v": true, ".flv": true,
+	".mp3": true, ".wav": true, ".m4a": true, ".flac": true, ".ogg": true, ".aac": true, ".opus": true,
+}
+
+// FolderWatcher enqueues media files dropped into watched folders and moves
+// them into done/error subfolders once their job finishes.
+type FolderWatcher struct {
+	bp        *BatchProcessor
+	templates *TemplateManager // Optional; required for folders with a template
+	config    *Config          // Defaults for options the folder's template leaves empty
+	folders   map[string]WatchFolder
+
+	stableFor    time.Duration
+	pollInterval time.Duration
+
+	candidates map[string]*watchCandidate // Files waiting to become stable
+	inFlight   map[string]watchJob        // Job ID -> file being processed
+	queued     map[string]bool            // Paths with a job in flight
+}
+
+// watchCandidate tracks a file that may still be being written.
+type watchCandidate struct {
+	size    int64
+	modTime time.Time
+	since   time.Time // When size and modTime were last seen to change
+}
+
+// watchJob links an enqueued job to its input file.
+type watchJob struct {
+	path   string
+	folder WatchFolder
+}
+
+// WatchOption configures a FolderWatcher.
+type WatchOption func(*FolderWatcher)
+
+// WithStableDuration sets how long a file's size must stay unchanged before it is enqueued.
+func WithStableDuration(d time.Duration) WatchOption {
+	return func(w *FolderWatcher) {
+		w.stableFor = d
+	}
+}
+
+// withPollInterval sets how often candidate files are checked for stability.
+func withPollInterval(d time.Duration) WatchOption {
+	return func(w *FolderWatcher) {
+		w.pollInterval = d
+	}
+}
+
+// NewFolderWatcher creates a watcher that enqueues files into bp.
+// templates may be nil if no folder uses a template; config may be nil.
+func NewFolderWatcher(bp *BatchProcessor, templates *TemplateManager, config *Config, folders []WatchFolder, opts ...WatchOption) (*FolderWatcher, error) {
+	if len(folders) == 0 {
+		return nil, errors.New("no watch folders configured")
+	}
+
+	w := &FolderWatcher{
+		bp:           bp,
+		templates:    templates,
+		config:       config,
+		folders:      make(map[string]WatchFolder),
+		stableFor:    DefaultWatchStableDuration,
+		pollInterval: time.Second,
+		candidates:   make(map[string]*watchCandidate),
+		inFlight:     make(map[string]watchJob),
+		queued:       make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	for _, folder := range folders {
+		path, err := filepath.Abs(folder.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid watch folder %s: %w", folder.Path, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("watch folder not accessible: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("watch folder is not a directory: %s", path)
+		}
+		if folder.Template != "" {
+			if templates == nil {
+				return nil, fmt.Errorf("watch folder %s uses template %q but no templates are available", path, folder.Template)
+			}
+			if _, err := templates.LoadTemplate(folder.Template); err != nil {
+				return nil, fmt.Errorf("watch folder %s: %w", path, err)
+			}
+		}
+
+		if folder.Pipeline != "" {
+			if _, err := LookupPipeline(folder.Pipeline); err != nil {
+				return nil, fmt.Errorf("watch folder %s: %w", path, err)
+			}
+		}
+
+		for _, sub := range []string{WatchDoneDir, WatchErrorDir} {
+			if err := os.MkdirAll(filepath.Join(path, sub), 0o755); err != nil {
+				return nil, fmt.Errorf("failed to create %s folder: %w", sub, err)
+			}
+		}
+
+		folder.Path = path
+		w.folders[path] = folder
+	}
+
+	return w, nil
+}
+
+// Run watches the folders until ctx is cancelled. Files already present when
+// Run starts are picked up too. Jobs still running when Run returns are left
+// to the BatchProcessor; their input files stay in place.
+func (w *FolderWatcher) Run(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	for path := range w.folders {
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		componentLogger("watch").Info("Watching folder", "path", path)
+		w.scan(path)
+	}
+
+	sub := w.bp.Subscribe(0)
+	defer w.bp.Unsubscribe(sub)
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			w.handleFileEvent(event)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			componentLogger("watch").Error("Watcher error", "error", err)
+
+		case event, ok := <-sub.Events():
+			if !ok {
+				return nil // Processor shut down
+			}
+			if event.Type.IsTerminal() {
+				w.finish(event)
+			}
+
+		case <-ticker.C:
+			w.enqueueStable()
+		}
+	}
+}
+
+// scan registers every eligible file already present in a folder.
+func (w *FolderWatcher) scan(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		componentLogger("watch").Error("Failed to scan folder", "path", dir, "error", err)
+		return
+	}
+	for _, entry := range entries {
+		w.track(filepath.Join(dir, entry.Name()))
+	}
+}
+
+// handleFileEvent updates candidates for a file system event.
+func (w *FolderWatcher) handleFileEvent(event fsnotify.Event) {
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		delete(w.candidates, event.Name)
+		return
+	}
+	if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+		w.track(event.Name)
+	}
+}
+
+// track starts or refreshes stability tracking for path if it is an eligible media file.
+func (w *FolderWatcher) track(path string) {
+	if w.queued[path] || !isWatchableFile(path) {
+		return
+	}
+	if _, ok := w.folders[filepath.Dir(path)]; !ok {
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+
+	if c, ok := w.candidates[path]; ok {
+		if c.size != info.Size() || !c.modTime.Equal(info.ModTime()) {
+			c.size, c.modTime, c.since = info.Size(), info.ModTime(), time.Now()
+		}
+		return
+	}
+	w.candidates[path] = &watchCandidate{size: info.Size(), modTime: info.ModTime(), since: time.Now()}
+}
+
+// isWatchableFile reports whether path looks like a finished media file.
+func isWatchableFile(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~") {
+		return false
+	}
+	return watchExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// enqueueStable enqueues candidates whose size has not changed for stableFor.
+func (w *FolderWatcher) enqueueStable() {
+	now := time.Now()
+	for path, c := range w.candidates {
+		info, err := os.Stat(path)
+		if err != nil {
+			delete(w.candidates, path)
+			continue
+		}
+		if info.Size() != c.size || !info.ModTime().Equal(c.modTime) {
+			c.size, c.modTime, c.since = info.Size(), info.ModTime(), now
+			continue
+		}
+		if c.size == 0 || now.Sub(c.since) < w.stableFor {
+			continue
+		}
+
+		delete(w.candidates, path)
+		w.enqueue(path)
+	}
+}
+
+// enqueue adds a job for a stable file.
+func (w *FolderWatcher) enqueue(path string) {
+	folder := w.folders[filepath.Dir(path)]
+
+	options, err := w.optionsFor(folder, path)
+	if err != nil {
+		componentLogger("watch").Warn("Cannot process file", "path", path, "error", err)
+		w.moveTo(folder, path, WatchErrorDir, err)
+		return
+	}
+
+	jobID := w.bp.AddJob(options)
+	w.inFlight[jobID] = watchJob{path: path, folder: folder}
+	w.queued[path] = true
+	componentLogger("watch").Info("Enqueued file", "path", path, "job_id", jobID)
+}
+
+// optionsFor builds the job options for a file in folder.
+func (w *FolderWatcher) optionsFor(folder WatchFolder, path string) (ScribeOptions, error) {
+	outputRoot := folder.OutputDir
+	if outputRoot == "" {
+		outputRoot = filepath.Join(folder.Path, WatchOutputDir)
+	}
+	// Keep the extension so talk.mp4 and talk.mkv get separate folders
+	ext := filepath.Ext(path)
+	name := strings.TrimSuffix(filepath.Base(path), ext)
+	if ext != "" {
+		name += "_" + ext[1:]
+	}
+
+	options := ScribeOptions{
+		InputFile: path,
+		OutputDir: filepath.Join(outputRoot, name),
+	}
+
+	if folder.Template != "" {
+		if err := w.templates.ApplyTemplate(folder.Template, &options); err != nil {
+			return options, err
+		}
+	}
+	if folder.Pipeline != "" {
+		options.Pipeline = folder.Pipeline
+	}
+	if w.config != nil {
+		if err := ApplyConfigToOptions(w.config, &options); err != nil {
+			return options, err
+		}
+	}
+
+	return options, nil
+}
+
+// finish moves the input file of a finished job into done or error.
+func (w *FolderWatcher) finish(event BatchEvent) {
+	job, ok := w.inFlight[event.JobID]
+	if !ok {
+		return
+	}
+	delete(w.inFlight, event.JobID)
+	delete(w.queued, job.path)
+
+	if event.Type == EventJobSucceeded {
+		w.moveTo(job.folder, job.path, WatchDoneDir, nil)
+		return
+	}
+
+	err := event.Error
+	if err == nil {
+		err = errors.New(event.Type.String())
+	}
+	w.moveTo(job.folder, job.path, WatchErrorDir, err)
+}
+
+// moveTo moves path into the given subfolder, avoiding name collisions.
+// For the error folder, the error is written next to the file.
+func (w *FolderWatcher) moveTo(folder WatchFolder, path, subdir string, jobErr error) {
+	name := filepath.Base(path)
+	target := filepath.Join(folder.Path, subdir, name)
+	if _, err := os.Stat(target); err == nil {
+		ext := filepath.Ext(name)
+		name = fmt.Sprintf("%s_%s%s", strings.TrimSuffix(name, ext), time.Now().Format("20060102_150405"), ext)
+		target = filepath.Join(folder.Path, subdir, name)
+	}
+
+	if err := os.Rename(path, target); err != nil {
+		componentLogger("watch").Error("Failed to move file", "path", path, "to", subdir, "error", err)
+		return
+	}
+
+	if jobErr != nil {
+		if err := os.WriteFile(target+".error.txt", []byte(jobErr.Error()+"\n"), 0o644); err != nil {
+			componentLogger("watch").Error("Failed to write error file", "path", target, "error", err)
+		}
+		componentLogger("watch").Info("Moved file", "path", path, "to", subdir, "error", jobErr)
+		return
+	}
+	componentLogger("watch").Info("Moved file", "path", path, "to", subdir)
+}
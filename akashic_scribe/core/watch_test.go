@@ -0,0 +1,173 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startWatcher runs a watcher with fast polling until the test ends.
+func startWatcher(t *testing.T, bp *BatchProcessor, templates *TemplateManager, folders ...WatchFolder) {
+	t.Helper()
+
+	watcher, err := NewFolderWatcher(bp, templates, nil, folders,
+		WithStableDuration(100*time.Millisecond), withPollInterval(10*time.Millisecond))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, watcher.Run(ctx))
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+		bp.Shutdown()
+	})
+}
+
+// waitForFile waits until path exists.
+func waitForFile(t *testing.T, path string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "expected %s to exist", path)
+}
+
+func TestFolderWatcher_ProcessesAndMovesToDone(t *testing.T) {
+	dir := t.TempDir()
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+
+	// Files already present when the watcher starts are processed too
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "existing.mp4"), []byte("video"), 0o644))
+
+	startWatcher(t, bp, nil, WatchFolder{Path: dir})
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "talk.mkv"), []byte("video"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "talk.mp4"), []byte("video"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not media"), 0o644))
+
+	waitForFile(t, filepath.Join(dir, WatchDoneDir, "existing.mp4"))
+	waitForFile(t, filepath.Join(dir, WatchDoneDir, "talk.mkv"))
+	waitForFile(t, filepath.Join(dir, WatchDoneDir, "talk.mp4"))
+
+	assert.NoFileExists(t, filepath.Join(dir, "talk.mkv"))
+	assert.FileExists(t, filepath.Join(dir, "notes.txt"), "Non-media files should be ignored")
+
+	outputDirs := make(map[string]string)
+	for _, job := range bp.JobSnapshots() {
+		outputDirs[filepath.Base(job.Options.InputFile)] = job.Options.OutputDir
+	}
+	assert.Equal(t, map[string]string{
+		"existing.mp4": filepath.Join(dir, WatchOutputDir, "existing_mp4"),
+		"talk.mkv":     filepath.Join(dir, WatchOutputDir, "talk_mkv"),
+		"talk.mp4":     filepath.Join(dir, WatchOutputDir, "talk_mp4"),
+	}, outputDirs, "Files differing only in extension get separate output folders")
+}
+
+func TestFolderWatcher_FailedJobMovesToError(t *testing.T) {
+	dir := t.TempDir()
+	engine := newScriptedEngine(func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error) {
+		return nil, NewScribeError(ErrorClassInvalidInput, "input", errors.New("unsupported codec"))
+	})
+	bp := NewBatchProcessor(engine, 1)
+	startWatcher(t, bp, nil, WatchFolder{Path: dir})
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.mp4"), []byte("video"), 0o644))
+
+	errorFile := filepath.Join(dir, WatchErrorDir, "broken.mp4.error.txt")
+	waitForFile(t, errorFile)
+	assert.FileExists(t, filepath.Join(dir, WatchErrorDir, "broken.mp4"))
+
+	content, err := os.ReadFile(errorFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "unsupported codec")
+}
+
+func TestFolderWatcher_WaitsUntilFileIsStable(t *testing.T) {
+	dir := t.TempDir()
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	startWatcher(t, bp, nil, WatchFolder{Path: dir})
+
+	path := filepath.Join(dir, "recording.wav")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+
+	// Keep writing for longer than the stable duration
+	for i := 0; i < 10; i++ {
+		_, err := file.Write([]byte("chunk"))
+		require.NoError(t, err)
+		time.Sleep(30 * time.Millisecond)
+		assert.Empty(t, bp.JobSnapshots(), "File must not be enqueued while it is growing")
+	}
+	require.NoError(t, file.Close())
+
+	waitForFile(t, filepath.Join(dir, WatchDoneDir, "recording.wav"))
+	assert.Len(t, bp.JobSnapshots(), 1, "The file should be enqueued exactly once")
+}
+
+func TestFolderWatcher_AppliesTemplate(t *testing.T) {
+	dir := t.TempDir()
+	templates, err := NewTemplateManager(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, templates.CreateTemplateFromOptions("Podcast DE", "", "Podcast", ScribeOptions{
+		TargetLanguage:  "German",
+		CreateSubtitles: true,
+	}))
+
+	outputRoot := t.TempDir()
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	startWatcher(t, bp, templates, WatchFolder{Path: dir, Template: "Podcast DE", OutputDir: outputRoot, Pipeline: NormalizedPipelineName})
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "episode.mp3"), []byte("audio"), 0o644))
+	waitForFile(t, filepath.Join(dir, WatchDoneDir, "episode.mp3"))
+
+	jobs := bp.JobSnapshots()
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "German", jobs[0].Options.TargetLanguage)
+	assert.True(t, jobs[0].Options.CreateSubtitles)
+	assert.Equal(t, filepath.Join(outputRoot, "episode_mp3"), jobs[0].Options.OutputDir)
+	assert.Equal(t, NormalizedPipelineName, jobs[0].Options.Pipeline)
+}
+
+func TestNewFolderWatcher_Validation(t *testing.T) {
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	defer bp.Shutdown()
+
+	_, err := NewFolderWatcher(bp, nil, nil, nil)
+	assert.Error(t, err, "At least one folder is required")
+
+	_, err = NewFolderWatcher(bp, nil, nil, []WatchFolder{{Path: filepath.Join(t.TempDir(), "missing")}})
+	assert.Error(t, err)
+
+	_, err = NewFolderWatcher(bp, nil, nil, []WatchFolder{{Path: t.TempDir(), Template: "YouTube Video"}})
+	assert.Error(t, err, "Templates require a TemplateManager")
+
+	_, err = NewFolderWatcher(bp, nil, nil, []WatchFolder{{Path: t.TempDir(), Pipeline: "no-such-pipeline"}})
+	assert.Error(t, err, "Pipelines must be registered")
+
+	dir := t.TempDir()
+	_, err = NewFolderWatcher(bp, nil, nil, []WatchFolder{{Path: dir}})
+	require.NoError(t, err)
+	assert.DirExists(t, filepath.Join(dir, WatchDoneDir))
+	assert.DirExists(t, filepath.Join(dir, WatchErrorDir))
+}
+
+func TestIsWatchableFile(t *testing.T) {
+	assert.True(t, isWatchableFile("/in/video.MP4"))
+	assert.True(t, isWatchableFile("/in/audio.flac"))
+	assert.False(t, isWatchableFile("/in/video.mp4.part"))
+	assert.False(t, isWatchableFile("/in/.hidden.mp4"))
+	assert.False(t, isWatchableFile("/in/readme.txt"))
+}
@@ -0,0 +1,320 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook request headers.
+const (
+	WebhookSignatureHeader = "X-Scribe-Signature" // "sha256=<hex HMAC-SHA256 of the body>"
+	WebhookEventHeader     = "X-Scribe-Event"     // Payload event name, e.g. "job.succeeded"
+	WebhookDeliveryHeader  = "X-Scribe-Delivery"  // Unique ID, identical across retries of one delivery
+)
+
+// WebhookConfig configures webhook notifications for a BatchProcessor.
+type WebhookConfig struct {
+	URLs    []string      // Global URLs notified for every finished job
+	Secret  string        // HMAC-SHA256 key for the signature header (empty = unsigned)
+	Timeout time.Duration // Per-request timeout (0 = 10 seconds)
+	Retry   RetryPolicy   // Delivery retries (zero value = DefaultWebhookRetryPolicy)
+	Client  *http.Client  // HTTP client (nil = http.DefaultClient)
+}
+
+// DefaultWebhookRetryPolicy returns the retry policy used for webhook deliveries.
+func DefaultWebhookRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		Multiplier:     2.0,
+		Jitter:         0.2,
+	}
+}
+
+// WithWebhook notifies url when the job finishes, in addition to any global webhooks.
+func WithWebhook(url string) JobOption {
+	return func(job *BatchJob) {
+		job.Webhooks = append(job.Webhooks, url)
+	}
+}
+
+// ValidateWebhookURL checks that rawURL is an absolute http(s) URL.
+func ValidateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL %q: %w", rawURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q: must be an absolute http or https URL", rawURL)
+	}
+	return nil
+}
+
+// WebhookPayload is the JSON body sent to webhook URLs when a job finishes.
+type WebhookPayload struct {
+	Event           string        `json:"event"` // "job.succeeded", "job.failed" or "job.cancelled"
+	JobID           string        `json:"job_id"`
+	Status          string        `json:"status"`
+	Timestamp       time.Time     `json:"timestamp"`
+	Attempts        int           `json:"attempts"`
+	Error           string        `json:"error,omitempty"`
+	ErrorClass      string        `json:"error_class,omitempty"`
+	QueuedAt        time.Time     `json:"queued_at"`
+	StartTime       time.Time     `json:"start_time"`
+	EndTime         time.Time     `json:"end_time"`
+	DurationSeconds float64       `json:"duration_seconds"`
+	StageTimings    []StageTiming `json:"stage_timings,omitempty"`
+	Options         ScribeOptions `json:"options"`
+	Result          *ScribeResult `json:"result,omitempty"`
+}
+
+// newWebhookPayload describes a finished job.
+func newWebhookPayload(event BatchEvent, job BatchJob) WebhookPayload {
+	payload := WebhookPayload{
+		Event:     webhookEventName(event.Type),
+		JobID:     job.ID,
+		Status:    job.Status.String(),
+		Timestamp: event.Time,
+		Attempts:  job.Attempts,
+		QueuedAt:  job.QueuedAt,
+		StartTime: job.StartTime,
+		EndTime:   job.EndTime,
+		Options:   job.Options,
+		Result:    job.Result,
+	}
+
+	if err := event.Error; err != nil {
+		payload.Error = err.Error()
+		payload.ErrorClass = ClassifyError(err).String()
+	}
+	if !job.StartTime.IsZero() && job.EndTime.After(job.StartTime) {
+		payload.DurationSeconds = job.EndTime.Sub(job.StartTime).Seconds()
+	}
+	if job.Result != nil {
+		payload.StageTimings = job.Result.StageTimings
+	}
+
+	return payload
+}
+
+// webhookEventName maps a terminal event type to its payload event name.
+func webhookEventName(t BatchEventType) string {
+	switch t {
+	case EventJobSucceeded:
+		return "job.succeeded"
+	case EventJobFailed:
+		return "job.failed"
+	default:
+		return "job.cancelled"
+	}
+}
+
+// SignWebhookPayload returns the signature header value for body.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature is a valid signature of body.
+// Receivers should use it to authenticate deliveries.
+func VerifyWebhookSignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhookPayload(secret, body)), []byte(signature))
+}
+
+// webhookNotifier delivers webhook notifications for terminal job events.
+type webhookNotifier struct {
+	bp  *BatchProcessor
+	sub *Subscription
+
+	mu     sync.RWMutex
+	config WebhookConfig
+
+	ctx        context.Context // Cancelled on Shutdown to stop retries
+	cancel     context.CancelFunc
+	deliveries sync.WaitGroup // In-flight deliveries
+	done       chan struct{}  // Closed when the event loop exits
+}
+
+// newWebhookNotifier subscribes to bp's events and starts delivering.
+func newWebhookNotifier(bp *BatchProcessor) *webhookNotifier {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	n := &webhookNotifier{
+		bp:     bp,
+		sub:    bp.Subscribe(0),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+// setConfig replaces the webhook configuration.
+func (n *webhookNotifier) setConfig(config WebhookConfig) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.config = config
+}
+
+// run delivers notifications for every terminal event until the subscription closes.
+func (n *webhookNotifier) run() {
+	defer close(n.done)
+
+	for event := range n.sub.Events() {
+		if !event.Type.IsTerminal() {
+			continue
+		}
+
+		job, ok := n.bp.JobSnapshot(event.JobID)
+		if !ok {
+			continue
+		}
+
+		n.mu.RLock()
+		config := n.config
+		n.mu.RUnlock()
+
+		targets := make([]string, 0, len(config.URLs)+len(job.Webhooks))
+		targets = append(targets, config.URLs...)
+		targets = append(targets, job.Webhooks...)
+		if len(targets) == 0 {
+			continue
+		}
+
+		body, err := json.Marshal(newWebhookPayload(event, job))
+		if err != nil {
+			componentLogger("webhook").Error("Failed to encode payload", "job_id", job.ID, "error", err)
+			continue
+		}
+
+		for _, target := range targets {
+			n.deliveries.Add(1)
+			go func(target string) {
+				defer n.deliveries.Done()
+				n.deliver(config, target, webhookEventName(event.Type), body)
+			}(target)
+		}
+	}
+}
+
+// deliver posts body to target, retrying transient failures with backoff.
+func (n *webhookNotifier) deliver(config WebhookConfig, target, eventName string, body []byte) {
+	policy := config.Retry
+	if policy.MaxAttempts == 0 {
+		policy = DefaultWebhookRetryPolicy()
+	}
+	deliveryID := uuid.New().String()
+	logger := componentLogger("webhook").With("delivery_id", deliveryID, "url", target)
+
+	for attempt := 1; ; attempt++ {
+		err := n.post(config, target, eventName, deliveryID, body)
+		if err == nil {
+			return
+		}
+
+		class := ClassifyError(err)
+		if class != ErrorClassTransient || attempt >= policy.attempts() {
+			logger.Warn("Delivery failed", "attempts", attempt, "error", err)
+			return
+		}
+
+		delay := policy.Backoff(attempt)
+		logger.Info("Delivery failed; retrying", "attempt", attempt, "error", err, "delay", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-n.ctx.Done():
+			timer.Stop()
+			logger.Warn("Delivery abandoned on shutdown")
+			return
+		}
+	}
+}
+
+// post sends a single delivery attempt.
+func (n *webhookNotifier) post(config WebhookConfig, target, eventName, deliveryID string, body []byte) error {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	// Attempts use their own timeout so a final attempt still runs during shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return NewScribeError(ErrorClassInvalidInput, "webhook", fmt.Errorf("failed to create request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AkashicScribe-Webhook")
+	req.Header.Set(WebhookEventHeader, eventName)
+	req.Header.Set(WebhookDeliveryHeader, deliveryID)
+	if config.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(config.Secret, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return NewScribeError(ErrorClassTransient, "webhook", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // Allow connection reuse
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return NewScribeError(classifyHTTPStatus(resp.StatusCode), "webhook",
+		fmt.Errorf("receiver returned %s", resp.Status))
+}
+
+// abort stops retry loops; each pending delivery finishes its current attempt.
+func (n *webhookNotifier) abort() {
+	n.cancel()
+}
+
+// wait blocks until the event loop has exited and all deliveries have finished.
+func (n *webhookNotifier) wait() {
+	<-n.done
+	n.deliveries.Wait()
+}
+
+// SetWebhooks configures global webhook URLs, the signing secret and delivery options.
+func (bp *BatchProcessor) SetWebhooks(config WebhookConfig) error {
+	for _, target := range config.URLs {
+		if err := ValidateWebhookURL(target); err != nil {
+			return err
+		}
+	}
+	bp.webhooks.setConfig(config)
+	return nil
+}
+
+// WebhookConfig returns the webhook settings described by the configuration.
+func (c *Config) WebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		URLs:   c.WebhookURLs,
+		Secret: c.WebhookSecret,
+	}
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookDelivery is a request received by webhookReceiver.
+type webhookDelivery struct {
+	header  http.Header
+	body    []byte
+	payload WebhookPayload
+}
+
+// webhookReceiver records deliveries and answers with scripted status codes.
+type webhookReceiver struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	deliveries []webhookDelivery
+	statuses   []int // Status codes for successive requests; 200 once exhausted
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	r := &webhookReceiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		var payload WebhookPayload
+		json.Unmarshal(body, &payload)
+
+		r.mu.Lock()
+		r.deliveries = append(r.deliveries, webhookDelivery{header: req.Header.Clone(), body: body, payload: payload})
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		r.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *webhookReceiver) received() []webhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]webhookDelivery(nil), r.deliveries...)
+}
+
+// fastWebhookRetry retries quickly so tests don't wait for real backoff.
+var fastWebhookRetry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 1}
+
+func TestWebhooks_GlobalAndPerJob(t *testing.T) {
+	global := newWebhookReceiver(t)
+	perJob := newWebhookReceiver(t)
+
+	bp := NewBatchProcessor(NewMockScribeEngine(), 2)
+	require.NoError(t, bp.SetWebhooks(WebhookConfig{URLs: []string{global.URL}, Secret: "s3cret", Retry: fastWebhookRetry}))
+
+	withHook := bp.AddJob(ScribeOptions{InputFile: "a.mp4", TargetLanguage: "Spanish"}, WithWebhook(perJob.URL))
+	bp.AddJob(ScribeOptions{InputFile: "b.mp4", TargetLanguage: "French"})
+	bp.Wait()
+
+	assert.Len(t, global.received(), 2, "Global webhook should see every job")
+
+	deliveries := perJob.received()
+	require.Len(t, deliveries, 1, "Per-job webhook should only see its own job")
+
+	delivery := deliveries[0]
+	assert.Equal(t, withHook, delivery.payload.JobID)
+	assert.Equal(t, "job.succeeded", delivery.payload.Event)
+	assert.Equal(t, "Completed", delivery.payload.Status)
+	require.NotNil(t, delivery.payload.Result)
+	assert.Contains(t, delivery.payload.Result.Translation, "Spanish")
+	assert.NotEmpty(t, delivery.payload.StageTimings)
+	assert.Positive(t, delivery.payload.DurationSeconds)
+
+	assert.Equal(t, "job.succeeded", delivery.header.Get(WebhookEventHeader))
+	assert.NotEmpty(t, delivery.header.Get(WebhookDeliveryHeader))
+	assert.True(t, VerifyWebhookSignature("s3cret", delivery.body, delivery.header.Get(WebhookSignatureHeader)))
+}
+
+func TestWebhooks_FailedJobPayload(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+
+	engine := newScriptedEngine(func(ctx context.Context, call int, options ScribeOptions) (*ScribeResult, error) {
+		return nil, NewScribeError(ErrorClassInvalidInput, "input", errors.New("input file not found"))
+	})
+	bp := NewBatchProcessor(engine, 1)
+	require.NoError(t, bp.SetWebhooks(WebhookConfig{URLs: []string{receiver.URL}}))
+
+	bp.AddJob(ScribeOptions{InputFile: "missing.mp4"})
+	bp.Wait()
+
+	deliveries := receiver.received()
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "job.failed", deliveries[0].payload.Event)
+	assert.Equal(t, "input file not found", deliveries[0].payload.Error)
+	assert.Equal(t, "InvalidInput", deliveries[0].payload.ErrorClass)
+	assert.Nil(t, deliveries[0].payload.Result)
+	assert.Empty(t, deliveries[0].header.Get(WebhookSignatureHeader), "Unsigned without a secret")
+}
+
+func TestWebhooks_RetriesTransientFailures(t *testing.T) {
+	receiver := newWebhookReceiver(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	require.NoError(t, bp.SetWebhooks(WebhookConfig{URLs: []string{receiver.URL}, Retry: fastWebhookRetry}))
+
+	bp.AddJob(ScribeOptions{InputFile: "a.mp4"})
+	bp.Wait()
+
+	deliveries := receiver.received()
+	require.Len(t, deliveries, 3, "Two failures followed by a success")
+
+	id := deliveries[0].header.Get(WebhookDeliveryHeader)
+	for _, d := range deliveries {
+		assert.Equal(t, id, d.header.Get(WebhookDeliveryHeader), "Retries should reuse the delivery ID")
+	}
+}
+
+func TestWebhooks_DoesNotRetryClientErrors(t *testing.T) {
+	receiver := newWebhookReceiver(t, http.StatusBadRequest, http.StatusBadRequest)
+
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	require.NoError(t, bp.SetWebhooks(WebhookConfig{URLs: []string{receiver.URL}, Retry: fastWebhookRetry}))
+
+	bp.AddJob(ScribeOptions{InputFile: "a.mp4"})
+	bp.Wait()
+
+	assert.Len(t, receiver.received(), 1)
+}
+
+func TestWebhooks_CancelledQueuedJob(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	bp.Pause()
+	jobID := bp.AddJob(ScribeOptions{InputFile: "a.mp4"}, WithWebhook(receiver.URL))
+	require.NoError(t, bp.CancelJob(jobID))
+	bp.Resume()
+	bp.Wait()
+
+	deliveries := receiver.received()
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "job.cancelled", deliveries[0].payload.Event)
+}
+
+func TestSetWebhooks_RejectsInvalidURLs(t *testing.T) {
+	bp := NewBatchProcessor(NewMockScribeEngine(), 1)
+	defer bp.Shutdown()
+
+	assert.Error(t, bp.SetWebhooks(WebhookConfig{URLs: []string{"ftp://example.com/hook"}}))
+	assert.Error(t, bp.SetWebhooks(WebhookConfig{URLs: []string{"/relative"}}))
+	assert.NoError(t, bp.SetWebhooks(WebhookConfig{URLs: []string{"https://example.com/hook"}}))
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"job.succeeded"}`)
+	signature := SignWebhookPayload("key", body)
+
+	assert.True(t, VerifyWebhookSignature("key", body, signature))
+	assert.False(t, VerifyWebhookSignature("other", body, signature))
+	assert.False(t, VerifyWebhookSignature("key", []byte(`{"event":"job.failed"}`), signature))
+}
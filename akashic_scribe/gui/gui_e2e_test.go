@@ -259,7 +259,7 @@ func (suite *GUITestSuite) TestProgressUpdatesHandling() {
 			{Percentage: 0.3, Message: "Transcription in progress..."},
 			{Percentage: 0.6, Message: "Starting translation..."},
 			{Percentage: 0.9, Message: "Translation in progress..."},
-			{Percentage: 1.0, Message: "Scribing complete.\n{\"Transcription\":\"Test transcription\",\"Translation\":\"Test translation\"}"},
+			{Percentage: 1.0, Message: "Scribing complete.", Result: &core.ScribeResult{Transcription: "Test transcription", Translation: "Test translation"}},
 		}
 
 		for _, update := range progressUpdates {
@@ -280,6 +280,9 @@ func (suite *GUITestSuite) TestProgressUpdatesHandling() {
 	assert.Equal(0.0, updates[0].Percentage, "First update should be 0%")
 	assert.Equal(1.0, updates[4].Percentage, "Last update should be 100%")
 	assert.Contains(updates[4].Message, "Scribing complete", "Final message should indicate completion")
+	if assert.NotNil(updates[4].Result, "Final update should carry the result") {
+		assert.Equal("Test translation", updates[4].Result.Translation)
+	}
 
 	suite.T().Log("✅ Progress updates handling test passed")
 }
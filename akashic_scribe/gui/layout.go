@@ -3,11 +3,16 @@ package gui
 import (
 	"akashic_scribe/core"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -16,6 +21,7 @@ import (
 	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/google/uuid"
 )
 
 // openDirectory opens a directory in the system's file manager in a cross-platform way.
@@ -118,11 +124,153 @@ func createSettingsView(window fyne.Window, options *ScribeOptions) fyne.CanvasO
 		widget.NewLabelWithStyle("Output Directory", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		current,
 		container.NewHBox(pickBtn, resetBtn),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("API Keys", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 	)
+	for _, provider := range providers().CredentialProviders() {
+		content.Add(createAPIKeyRow(window, provider))
+	}
+	content.Add(widget.NewSeparator())
+	content.Add(widget.NewLabelWithStyle("Diagnostics", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	content.Add(createDiagnosticsRow(window))
 
 	return widget.NewCard("Settings", "Configure application preferences.", content)
 }
 
+// createDiagnosticsRow builds the settings row that checks the external tools
+// and providers and shows the report with fixes for this system.
+func createDiagnosticsRow(window fyne.Window) fyne.CanvasObject {
+	var runBtn *widget.Button
+	runBtn = widget.NewButtonWithIcon("Run Diagnostics", theme.SearchIcon(), func() {
+		runBtn.Disable()
+		runBtn.SetText("Checking...")
+		go func() {
+			ctx := core.WithProviders(core.WithCredentials(context.Background(), credentialStore()), providers())
+			report := core.Diagnose(ctx)
+			fyne.Do(func() {
+				runBtn.SetText("Run Diagnostics")
+				runBtn.Enable()
+
+				title := "All Checks Passed"
+				if !report.OK() {
+					title = "Problems Found"
+				} else if len(report.Problems()) > 0 {
+					title = "Checks Passed with Warnings"
+				}
+				text := widget.NewLabel(report.String())
+				text.TextStyle = fyne.TextStyle{Monospace: true}
+				text.Wrapping = fyne.TextWrapWord
+				scroll := container.NewVScroll(text)
+				scroll.SetMinSize(fyne.NewSize(560, 320))
+				dialog.ShowCustom(title, "Close", scroll, window)
+			})
+		}()
+	})
+	return container.NewHBox(runBtn)
+}
+
+// dependencyFixes describes the failed tool checks of report and how to fix them.
+func dependencyFixes(report *core.DiagnosticsReport) string {
+	var b strings.Builder
+	for _, check := range report.Problems() {
+		if check.Status != core.CheckFailed {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s\n", check.Name, check.Detail)
+		if check.Fix != "" {
+			fmt.Fprintf(&b, "Fix: %s\n", check.Fix)
+		}
+	}
+	if b.Len() == 0 {
+		return "A required tool could not be run. Run the diagnostics under Settings for details."
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// credentialStore returns the store API keys are saved to and read from:
+// environment variables, then an encrypted file in the config directory.
+var credentialStore = sync.OnceValue(func() core.CredentialChain {
+	path, err := core.GetDefaultConfigPath()
+	if err != nil {
+		slog.Warn("Config directory unavailable; reading API keys from the environment only", "error", err)
+		return core.CredentialChain{core.EnvCredentialStore{}}
+	}
+	return core.NewCredentialStore(filepath.Dir(path), nil)
+})
+
+// savedConfig returns the saved configuration, or the defaults if it cannot
+// be loaded.
+var savedConfig = sync.OnceValue(func() *core.Config {
+	path, err := core.GetDefaultConfigPath()
+	if err != nil {
+		return core.DefaultConfig()
+	}
+	config, err := core.LoadConfig(path)
+	if err != nil {
+		slog.Warn("Failed to load settings; using defaults", "error", err)
+		return core.DefaultConfig()
+	}
+	return config
+})
+
+// providers returns the provider endpoints, fallback chains and HTTP clients
+// from the saved configuration. The configured provider limits are applied
+// on first use.
+var providers = sync.OnceValue(func() *core.Providers {
+	config := savedConfig()
+	core.ConfigureProviderLimits(config.ProviderLimits())
+	return config.NewProviders()
+})
+
+// createAPIKeyRow builds the settings row that saves or removes the API key of provider.
+// Saved keys are never shown; the row only says where the current key comes from.
+func createAPIKeyRow(window fyne.Window, provider string) fyne.CanvasObject {
+	status := widget.NewLabel("")
+	refresh := func() {
+		_, source, err := credentialStore().Lookup(provider)
+		switch {
+		case errors.Is(err, core.ErrCredentialNotFound):
+			status.SetText("Not set")
+		case err != nil:
+			status.SetText("Unreadable: " + err.Error())
+		case source.Name() == (core.EnvCredentialStore{}).Name():
+			status.SetText("Set by " + core.CredentialEnvVar(provider))
+		default:
+			status.SetText("Saved")
+		}
+	}
+	refresh()
+
+	entry := widget.NewPasswordEntry()
+	entry.SetPlaceHolder("Paste a new " + provider + " API key")
+
+	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		key := strings.TrimSpace(entry.Text)
+		if key == "" {
+			return
+		}
+		if err := credentialStore().Set(provider, core.Secret(key)); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		entry.SetText("")
+		refresh()
+	})
+	removeBtn := widget.NewButtonWithIcon("Remove", theme.DeleteIcon(), func() {
+		if err := credentialStore().Delete(provider); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		refresh()
+	})
+
+	return container.NewBorder(nil, nil,
+		widget.NewLabel(provider),
+		container.NewHBox(status, saveBtn, removeBtn),
+		entry,
+	)
+}
+
 // createInputStep builds the UI for Step 1: The Offering.
 func createInputStep(window fyne.Window, options *ScribeOptions) *widget.Card {
 	// A label to display the name of the selected file.
@@ -166,12 +314,29 @@ func createInputStep(window fyne.Window, options *ScribeOptions) *widget.Card {
 		}
 	}
 
+	// Platform captions replace transcription for links that have them.
+	autoCaptionsCheck := widget.NewCheck("...even the machine-written ones", func(checked bool) {
+		options.AllowAutoCaptions = checked
+	})
+	autoCaptionsCheck.Disable()
+	captionsCheck := widget.NewCheck("Use the platform's captions when available", func(checked bool) {
+		options.UsePlatformCaptions = checked
+		if checked {
+			autoCaptionsCheck.Enable()
+		} else {
+			autoCaptionsCheck.SetChecked(false)
+			autoCaptionsCheck.Disable()
+		}
+	})
+
 	// Assemble the components in a vertical box.
 	inputContainer := container.NewVBox(
 		fileSelectBtn,
 		selectedFileLabel,
 		widget.NewSeparator(),
 		urlEntry,
+		captionsCheck,
+		autoCaptionsCheck,
 	)
 
 	return widget.NewCard("Step 1: The Offering", "Provide the source material.", inputContainer)
@@ -201,9 +366,16 @@ func createConfigStep(window fyne.Window, options *ScribeOptions) *widget.Card {
 	})
 	targetLangSelect.PlaceHolder = "Select Target Language"
 
+	// --- Pipeline Selection ---
+	pipelineSelect := widget.NewSelect(core.PipelineNames(), func(s string) {
+		options.Pipeline = s
+	})
+	pipelineSelect.SetSelected(core.DefaultPipelineName)
+
 	langContainer := container.New(layout.NewFormLayout(),
 		widget.NewLabel("Original Language:"), originLangSelect,
 		widget.NewLabel("Translate To:"), targetLangSelect,
+		widget.NewLabel("Pipeline:"), pipelineSelect,
 	)
 
 	// --- Subtitle Configuration ---
@@ -400,6 +572,76 @@ func createConfigStep(window fyne.Window, options *ScribeOptions) *widget.Card {
 	return widget.NewCard("Step 2: The Incantation", "Define the transformation.", configContent)
 }
 
+// createPlaylistSelection builds the inputs that select which videos of a
+// playlist or channel link are scribed. The returned function reads them
+// into a validated filter; empty inputs select everything.
+func createPlaylistSelection() (fyne.CanvasObject, func() (core.PlaylistFilter, error)) {
+	startEntry := widget.NewEntry()
+	startEntry.SetPlaceHolder("1")
+	endEntry := widget.NewEntry()
+	endEntry.SetPlaceHolder("last")
+	afterEntry := widget.NewEntry()
+	afterEntry.SetPlaceHolder("YYYY-MM-DD")
+	beforeEntry := widget.NewEntry()
+	beforeEntry.SetPlaceHolder("YYYY-MM-DD")
+	maxEntry := widget.NewEntry()
+	maxEntry.SetPlaceHolder("no limit")
+
+	form := widget.NewForm(
+		widget.NewFormItem("First video", startEntry),
+		widget.NewFormItem("Last video", endEntry),
+		widget.NewFormItem("Uploaded on or after", afterEntry),
+		widget.NewFormItem("Uploaded on or before", beforeEntry),
+		widget.NewFormItem("At most", maxEntry),
+	)
+	accordion := widget.NewAccordion(widget.NewAccordionItem("Playlist Selection", form))
+
+	parseNumber := func(name string, entry *widget.Entry) (int, error) {
+		text := strings.TrimSpace(entry.Text)
+		if text == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a whole number, got %q", name, text)
+		}
+		return n, nil
+	}
+	parseDate := func(name string, entry *widget.Entry) (time.Time, error) {
+		text := strings.TrimSpace(entry.Text)
+		if text == "" {
+			return time.Time{}, nil
+		}
+		date, err := time.Parse(time.DateOnly, text)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s must be a date like 2024-01-31, got %q", name, text)
+		}
+		return date, nil
+	}
+
+	filter := func() (core.PlaylistFilter, error) {
+		var f core.PlaylistFilter
+		var err error
+		if f.Start, err = parseNumber("First video", startEntry); err != nil {
+			return f, err
+		}
+		if f.End, err = parseNumber("Last video", endEntry); err != nil {
+			return f, err
+		}
+		if f.After, err = parseDate("Uploaded on or after", afterEntry); err != nil {
+			return f, err
+		}
+		if f.Before, err = parseDate("Uploaded on or before", beforeEntry); err != nil {
+			return f, err
+		}
+		if f.MaxEntries, err = parseNumber("At most", maxEntry); err != nil {
+			return f, err
+		}
+		return f, f.Validate()
+	}
+	return accordion, filter
+}
+
 // createExecutionStep builds the UI for Step 3: The Ritual.
 //
 // === BACKEND INTEGRATION PHASE ===
@@ -424,124 +666,137 @@ func createExecutionStep(window fyne.Window, options *ScribeOptions, engine core
 	downloadContainer := container.NewVBox()
 	viewStack := container.NewStack()
 
+	playlistSelection, playlistFilter := createPlaylistSelection()
+	var startView fyne.CanvasObject
 	var startButton *widget.Button
 	resetButton := widget.NewButton("Scribe Another", func() {
-		viewStack.Objects = []fyne.CanvasObject{startButton}
+		viewStack.Objects = []fyne.CanvasObject{startView}
 		viewStack.Refresh()
 	})
 
-	startButton = widget.NewButtonWithIcon("Begin Scribing", theme.ConfirmIcon(), func() {
-		// The 'options' struct is now always up-to-date.
-		fmt.Println("🏰 ALBEDO ASSISTANT - SCRIBE OPTIONS GATHERED:")
-		fmt.Println(options.String())
-
-		// Basic validation before starting.
-		if options.InputFile == "" && options.InputURL == "" {
-			dialog.ShowInformation("Missing Input", "Please select a file or provide a URL before starting.", window)
-			return
-		}
-		if options.OriginLanguage == "" || options.TargetLanguage == "" {
-			dialog.ShowInformation("Missing Language", "Please select both an original and a target language.", window)
-			return
-		}
-
+	// process runs the engine on each job in turn and shows the progress and
+	// results. A playlist URL has one job per video.
+	process := func(jobs []core.ScribeOptions) {
 		progress.SetValue(0)
 		statusLabel.SetText("Status: Ready for backend integration...")
 		downloadContainer.RemoveAll()
 		viewStack.Objects = []fyne.CanvasObject{progressContainer}
 		viewStack.Refresh()
 
-		// === BACKEND INTEGRATION POINT ===
-		// Use the real backend engine and progress channel
-		progressChan := make(chan core.ProgressUpdate)
-
-		// Create a context for the processing operation
+		// Create a context for the processing operation with the configured
+		// prices and command hooks, as BatchProcessor gives its jobs
 		// TODO: In the future, this can be a cancellable context with a Cancel button
-		ctx := context.Background()
+		ctx := core.WithProviders(core.WithCredentials(context.Background(), credentialStore()), providers())
+		ctx = core.WithPriceTable(ctx, savedConfig().PriceTable())
 
-		// Start backend processing in a goroutine
+		// Start backend processing in a goroutine; results are returned
+		// directly rather than through the progress channel
 		go func() {
-			err := engine.StartProcessing(ctx, *options, progressChan)
-			if err != nil {
-				// Create user-friendly error message
-				errorTitle := "Processing Error"
-				errorMsg := err.Error()
-
-				// Categorize common errors for better user experience
-				if strings.Contains(errorMsg, "yt-dlp not found") {
-					errorTitle = "Missing Dependency: yt-dlp"
-					errorMsg = "yt-dlp is required to download videos from URLs. Please install it using:\nwinget install yt-dlp"
-				} else if strings.Contains(errorMsg, "ffmpeg not found") {
-					errorTitle = "Missing Dependency: FFmpeg"
-					errorMsg = "FFmpeg is required to process video files. Please install it using:\nwinget install ffmpeg"
-				} else if strings.Contains(errorMsg, "input file not found") {
-					errorTitle = "File Not Found"
-					errorMsg = "The selected video file could not be found. Please check the file path and try again."
-				} else if strings.Contains(errorMsg, "failed to download") {
-					errorTitle = "Download Failed"
-					errorMsg = "Failed to download the video from the provided URL. Please check the URL and your internet connection."
+			var results []*core.ScribeResult
+			for i, opts := range jobs {
+				job := core.BatchJob{ID: fmt.Sprintf("job_%s", uuid.New().String()), Options: opts, Attempts: 1, StartTime: time.Now()}
+				hooks := savedConfig().CommandHooks
+				jobCtx := ctx
+				if len(hooks) > 0 {
+					jobCtx = core.WithCommandHooks(ctx, job.ID, hooks)
 				}
 
-				fyne.CurrentApp().SendNotification(&fyne.Notification{
-					Title:   errorTitle,
-					Content: errorMsg,
-				})
-				fyne.Do(func() {
-					statusLabel.SetText("Status: " + errorTitle)
-					progress.SetValue(0)
-					viewStack.Objects = []fyne.CanvasObject{startButton}
-					viewStack.Refresh()
-				})
-			}
-		}()
-
-		// Listen for progress updates and update the UI accordingly
-		go func() {
-			defer close(progressChan) // Close when done reading
-			var finalTranscription, finalTranslation, outputDir string
-			for update := range progressChan {
-				fyne.Do(func() {
-					progress.SetValue(update.Percentage)
-					statusLabel.SetText(fmt.Sprintf("Status: %s", update.Message))
-				})
-				// Try to parse result JSON if present in the final message
-				if update.Percentage >= 1.0 && len(update.Message) > 0 {
-					// Extract output directory
-					lines := strings.Split(update.Message, "\n")
-					for _, line := range lines {
-						if strings.HasPrefix(line, "Output saved to: ") {
-							outputDir = strings.TrimPrefix(line, "Output saved to: ")
-							break
-						}
-					}
-					// Look for result JSON
-					if idx := strings.Index(update.Message, "{"); idx != -1 {
-						resultJSON := update.Message[idx:]
-						type resultStruct struct {
-							Transcription string `json:"Transcription"`
-							Translation   string `json:"Translation"`
+				// === BACKEND INTEGRATION POINT ===
+				// Use the real backend engine and progress channel
+				progressChan := make(chan core.ProgressUpdate)
+
+				// Listen for progress updates and update the UI accordingly
+				listenerDone := make(chan struct{})
+				go func() {
+					defer close(listenerDone)
+					for update := range progressChan {
+						status := update.Message
+						if update.ETA > 0 {
+							status = fmt.Sprintf("%s (about %s left)", status, update.ETA)
 						}
-						var result resultStruct
-						if err := json.Unmarshal([]byte(resultJSON), &result); err == nil {
-							finalTranscription = result.Transcription
-							finalTranslation = result.Translation
+						if len(jobs) > 1 {
+							status = fmt.Sprintf("Video %d of %d: %s", i+1, len(jobs), status)
 						}
+						value := (float64(i) + update.Percentage) / float64(len(jobs))
+						fyne.Do(func() {
+							progress.SetValue(value)
+							statusLabel.SetText("Status: " + status)
+						})
 					}
+				}()
+
+				result, err := engine.ProcessWithContext(jobCtx, opts, progressChan)
+				close(progressChan)
+				<-listenerDone
+
+				// Run the job_complete hooks as BatchProcessor does for its jobs
+				job.EndTime = time.Now()
+				job.Status, job.Result = core.JobCompleted, result
+				if err != nil {
+					job.Status, job.Error, job.ErrorClass = core.JobFailed, err, core.ClassifyError(err)
 				}
+				core.RunJobCompleteHooks(hooks, job)
+
+				if err != nil {
+					// Create user-friendly error message
+					errorTitle := "Processing Error"
+					errorMsg := err.Error()
+
+					// Categorize common errors for better user experience
+					if core.ClassifyError(err) == core.ErrorClassDependency {
+						errorTitle = "Missing Dependencies"
+						errorMsg = dependencyFixes(core.DiagnoseTools(context.Background()))
+					} else if strings.Contains(errorMsg, "input file not found") {
+						errorTitle = "File Not Found"
+						errorMsg = "The selected video file could not be found. Please check the file path and try again."
+					} else if strings.Contains(errorMsg, "no API key") {
+						errorTitle = "Missing API Key"
+						errorMsg = "Dubbing needs a provider API key. Save one under Settings > API Keys and try again."
+					} else if strings.Contains(errorMsg, "failed to download") {
+						errorTitle = "Download Failed"
+						errorMsg = "Failed to download the video from the provided URL. Please check the URL and your internet connection."
+					}
+					if len(jobs) > 1 {
+						errorMsg = fmt.Sprintf("Video %d of %d (%s): %s", i+1, len(jobs), opts.InputURL, errorMsg)
+					}
+
+					fyne.CurrentApp().SendNotification(&fyne.Notification{
+						Title:   errorTitle,
+						Content: errorMsg,
+					})
+					fyne.Do(func() {
+						statusLabel.SetText("Status: " + errorTitle)
+						progress.SetValue(0)
+						viewStack.Objects = []fyne.CanvasObject{startButton}
+						viewStack.Refresh()
+					})
+					return
+				}
+				results = append(results, result)
 			}
+
+			// Playlist videos share the playlist's output folder
+			outputDir := results[0].OutputDir
+			var text strings.Builder
+			if len(results) == 1 {
+				fmt.Fprintf(&text, "Transcription:\n%s\n\nTranslation:\n%s", results[0].Transcription, results[0].Translation)
+			} else {
+				outputDir = filepath.Dir(outputDir)
+				for i, result := range results {
+					fmt.Fprintf(&text, "%s\n\nTranscription:\n%s\n\nTranslation:\n%s\n\n", jobs[i].InputURL, result.Transcription, result.Translation)
+				}
+			}
+
 			// Show completion UI
 			fyne.Do(func() {
+				progress.SetValue(1.0)
 				statusLabel.SetText("Status: Scribing complete.")
 				downloadContainer.Add(widget.NewLabelWithStyle("Scribing Complete.", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}))
 				if outputDir != "" {
 					downloadContainer.Add(widget.NewLabelWithStyle("Files saved to: "+outputDir, fyne.TextAlignCenter, fyne.TextStyle{}))
 				}
 				entry := widget.NewMultiLineEntry()
-				if finalTranscription != "" || finalTranslation != "" {
-					entry.SetText(fmt.Sprintf("Transcription:\n%s\n\nTranslation:\n%s", finalTranscription, finalTranslation))
-				} else {
-					entry.SetText("Transcription:\n(See logs)\n\nTranslation:\n(See logs)")
-				}
+				entry.SetText(strings.TrimSpace(text.String()))
 				entry.Disable()
 				downloadContainer.Add(entry)
 				downloadContainer.Add(widget.NewButton("Open Output Folder", func() {
@@ -557,9 +812,86 @@ func createExecutionStep(window fyne.Window, options *ScribeOptions, engine core
 				viewStack.Refresh()
 			})
 		}()
+	}
+
+	startButton = widget.NewButtonWithIcon("Begin Scribing", theme.ConfirmIcon(), func() {
+		// The 'options' struct is now always up-to-date.
+		slog.Info("Scribe options gathered", "options", options.String())
+
+		// Basic validation before starting.
+		if options.InputFile == "" && options.InputURL == "" {
+			dialog.ShowInformation("Missing Input", "Please select a file or provide a URL before starting.", window)
+			return
+		}
+		if options.OriginLanguage == "" || options.TargetLanguage == "" {
+			dialog.ShowInformation("Missing Language", "Please select both an original and a target language.", window)
+			return
+		}
+		filter, err := playlistFilter()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+
+		// Interactive runs write next to the input file unless told otherwise
+		opts := *options
+		if opts.OutputDir == "" {
+			opts.OutputDir = core.DefaultOutputDir(opts)
+		}
+
+		// Show the expected cost and runtime and ask before processing
+		startButton.Disable()
+		startButton.SetText("Estimating...")
+		go func() {
+			// Playlist and channel URLs become one job per video
+			jobs := []core.ScribeOptions{opts}
+			var err error
+			if opts.InputURL != "" {
+				jobs, err = core.ExpandPlaylist(context.Background(), engine, opts, filter)
+			}
+			if err != nil {
+				fyne.Do(func() {
+					startButton.SetText("Begin Scribing")
+					startButton.Enable()
+					dialog.ShowError(err, window)
+				})
+				return
+			}
+
+			estimateCtx := core.WithPriceTable(context.Background(), savedConfig().PriceTable())
+			var total core.Estimate
+			for _, job := range jobs {
+				var est *core.Estimate
+				if est, err = core.EstimateJob(estimateCtx, job); err != nil {
+					break
+				}
+				total.Add(est)
+			}
+			fyne.Do(func() {
+				startButton.SetText("Begin Scribing")
+				startButton.Enable()
+
+				var message string
+				if err != nil {
+					message = "Could not estimate this job:\n" + err.Error() + "\n\nBegin scribing anyway?"
+				} else {
+					message = fmt.Sprintf("Media: %s\nEstimated cost: $%.4f\nEstimated runtime: about %s\n\nBegin scribing?",
+						total.MediaDuration.Round(time.Second), total.TotalCost, total.Runtime)
+				}
+				if len(jobs) > 1 {
+					message = fmt.Sprintf("Playlist: %d videos\n", len(jobs)) + message
+				}
+				dialog.ShowConfirm("Pre-flight Estimate", message, func(confirmed bool) {
+					if confirmed {
+						process(jobs)
+					}
+				}, window)
+			})
+		}()
 	})
 	startButton.Importance = widget.HighImportance
-	viewStack.Add(startButton)
+	startView = container.NewVBox(playlistSelection, startButton)
+	viewStack.Add(startView)
 
 	return widget.NewCard("Step 3: The Ritual", "Initiate the process and receive the results.", viewStack)
 }
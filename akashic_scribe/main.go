@@ -1,6 +1,9 @@
 package main
 
 import (
+	"log/slog"
+	"os"
+
 	"akashic_scribe/core"
 	"akashic_scribe/gui"
 
@@ -17,6 +20,13 @@ func main() {
 	myWindow := myApp.NewWindow("Akashic Scribe")
 	// myApp.Settings().SetTheme(gui.NewAkashicTheme())
 
+	// Log at the level from the saved configuration, if any
+	if configPath, err := core.GetDefaultConfigPath(); err == nil {
+		if config, err := core.LoadConfig(configPath); err == nil {
+			slog.SetDefault(core.NewLogger(os.Stderr, config.SlogLevel()))
+		}
+	}
+
 	// Initialize the core engine
 	scribeEngine := core.NewRealScribeEngine()
 
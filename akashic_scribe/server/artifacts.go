@@ -0,0 +1,113 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+
+	"akashic_scribe/core"
+)
+
+// ArtifactResponse describes a downloadable output file.
+type ArtifactResponse struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	URL      string    `json:"url"`
+}
+
+// completedJob returns the snapshot of a finished job with an output directory
+// under the server's output root, writing an error response if there is none.
+func (s *Server) completedJob(w http.ResponseWriter, jobID string) (core.BatchJob, bool) {
+	job, ok := s.processor.JobSnapshot(jobID)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", jobID))
+		return job, false
+	}
+	if job.Status != core.JobCompleted || job.Result == nil || job.Result.OutputDir == "" {
+		writeError(w, http.StatusConflict, fmt.Errorf("job %s has no artifacts (status: %s)", jobID, job.Status))
+		return job, false
+	}
+
+	// Only files under the server's output root are ever served
+	dir, dirErr := resolvePath(job.Result.OutputDir)
+	root, rootErr := resolvePath(s.outputRoot)
+	if dirErr != nil || rootErr != nil || !isWithin(root, dir) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s has no artifacts on this server", jobID))
+		return job, false
+	}
+	return job, true
+}
+
+// handleListArtifacts lists the files in a completed job's output directory.
+func (s *Server) handleListArtifacts(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.completedJob(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+
+	entries, err := os.ReadDir(job.Result.OutputDir)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("output directory not available: %w", err))
+		return
+	}
+
+	artifacts := make([]ArtifactResponse, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		artifacts = append(artifacts, ArtifactResponse{
+			Name:     entry.Name(),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+			URL:      APIPrefix + "/jobs/" + job.ID + "/artifacts/" + entry.Name(),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, artifacts)
+}
+
+// handleGetArtifact downloads a single file from a completed job's output directory.
+func (s *Server) handleGetArtifact(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.completedJob(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+
+	// os.Root confines the lookup to the output directory, rejecting ".." and symlink escapes
+	root, err := os.OpenRoot(job.Result.OutputDir)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("output directory not available: %w", err))
+		return
+	}
+	defer root.Close()
+
+	name := r.PathValue("name")
+	file, err := root.Open(name)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, fs.ErrNotExist) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, fmt.Errorf("artifact %q not available", name))
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		writeError(w, http.StatusNotFound, fmt.Errorf("artifact %q not available", name))
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", info.Name()))
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
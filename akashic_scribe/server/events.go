@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"akashic_scribe/core"
+)
+
+// sseKeepAlive is how often a comment is sent on idle event streams so
+// proxies don't close the connection.
+const sseKeepAlive = 15 * time.Second
+
+// EventResponse is the data of a server-sent event.
+type EventResponse struct {
+	Type          string             `json:"type"`
+	JobID         string             `json:"job_id"`
+	Time          time.Time          `json:"time"`
+	Stage         string             `json:"stage,omitempty"`
+	Progress      float64            `json:"progress"`
+	StageProgress float64            `json:"stage_progress,omitempty"`
+	ETASeconds    float64            `json:"eta_seconds,omitempty"`
+	Message       string             `json:"message,omitempty"`
+	Attempt       int                `json:"attempt,omitempty"`
+	Error         string             `json:"error,omitempty"`
+	Result        *core.ScribeResult `json:"result,omitempty"`
+}
+
+// newEventResponse converts a BatchEvent into its API representation.
+func newEventResponse(event core.BatchEvent) EventResponse {
+	resp := EventResponse{
+		Type:          event.Type.String(),
+		JobID:         event.JobID,
+		Time:          event.Time,
+		Stage:         event.Stage,
+		Progress:      event.Progress,
+		StageProgress: event.StageProgress,
+		ETASeconds:    event.ETA.Seconds(),
+		Message:       event.Message,
+		Attempt:       event.Attempt,
+		Result:        event.Result,
+	}
+	if event.Error != nil {
+		resp.Error = event.Error.Error()
+	}
+	return resp
+}
+
+// terminalEvent returns the terminal event describing a finished job.
+func terminalEvent(job core.BatchJob) core.BatchEvent {
+	event := core.BatchEvent{
+		JobID:    job.ID,
+		Time:     job.EndTime,
+		Progress: job.Progress,
+		Message:  job.StatusMsg,
+		Attempt:  job.Attempts,
+		Error:    job.Error,
+		Result:   job.Result,
+	}
+	switch job.Status {
+	case core.JobCompleted:
+		event.Type = core.EventJobSucceeded
+	case core.JobFailed:
+		event.Type = core.EventJobFailed
+	default:
+		event.Type = core.EventJobCancelled
+	}
+	return event
+}
+
+// handleJobEvents streams the events of one job until it finishes.
+func (s *Server) handleJobEvents(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+
+	// Subscribe before checking the job so no terminal event can slip in between
+	sub := s.processor.Subscribe(0)
+	defer s.processor.Unsubscribe(sub)
+
+	job, ok := s.processor.JobSnapshot(jobID)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", jobID))
+		return
+	}
+
+	stream, ok := newEventStream(w)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	switch job.Status {
+	case core.JobCompleted, core.JobFailed, core.JobCancelled:
+		stream.send(terminalEvent(job))
+		return
+	}
+
+	s.streamEvents(r, stream, sub, func(event core.BatchEvent) (send, done bool) {
+		if event.JobID != jobID {
+			return false, false
+		}
+		return true, event.Type.IsTerminal()
+	})
+}
+
+// handleAllEvents streams the events of every job until the client disconnects.
+func (s *Server) handleAllEvents(w http.ResponseWriter, r *http.Request) {
+	sub := s.processor.Subscribe(0)
+	defer s.processor.Unsubscribe(sub)
+
+	stream, ok := newEventStream(w)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	s.streamEvents(r, stream, sub, func(core.BatchEvent) (send, done bool) {
+		return true, false
+	})
+}
+
+// streamEvents forwards subscription events accepted by filter until filter
+// reports done, the subscription closes or the client goes away.
+func (s *Server) streamEvents(r *http.Request, stream *eventStream, sub *core.Subscription, filter func(core.BatchEvent) (send, done bool)) {
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			send, done := filter(event)
+			if send && !stream.send(event) {
+				return
+			}
+			if done {
+				return
+			}
+		case <-keepAlive.C:
+			if !stream.comment("keep-alive") {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// eventStream writes server-sent events.
+type eventStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// newEventStream writes the SSE response headers.
+func newEventStream(w http.ResponseWriter) (*eventStream, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &eventStream{w: w, flusher: flusher}, true
+}
+
+// send writes an event and reports whether the client is still connected.
+func (es *eventStream) send(event core.BatchEvent) bool {
+	data, err := json.Marshal(newEventResponse(event))
+	if err != nil {
+		return false
+	}
+
+	if _, err := fmt.Fprintf(es.w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+		return false
+	}
+	es.flusher.Flush()
+	return true
+}
+
+// comment writes an SSE comment line.
+func (es *eventStream) comment(text string) bool {
+	if _, err := fmt.Fprintf(es.w, ": %s\n\n", text); err != nil {
+		return false
+	}
+	es.flusher.Flush()
+	return true
+}
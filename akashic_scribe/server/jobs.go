@@ -0,0 +1,292 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"akashic_scribe/core"
+)
+
+// maxRequestBody limits the size of job submission bodies.
+const maxRequestBody = 1 << 20
+
+// JobRequest is the body of POST /jobs.
+//
+// Either Options or Template (or both) must be given. With a template, the
+// template's options are applied and only the input and output fields of
+// Options are used.
+type JobRequest struct {
+	Template string              `json:"template,omitempty"`
+	Options  *core.ScribeOptions `json:"options,omitempty"`
+	Priority string              `json:"priority,omitempty"` // "low", "normal", "high" or "urgent"
+	Webhook  string              `json:"webhook,omitempty"`  // URL notified when the job finishes
+	Playlist *PlaylistRequest    `json:"playlist,omitempty"` // Selects the videos of a playlist or channel URL
+}
+
+// PlaylistRequest selects the entries of a playlist or channel URL that
+// become jobs (see core.PlaylistFilter).
+type PlaylistRequest struct {
+	Start      int    `json:"start,omitempty"`       // First entry (1-based)
+	End        int    `json:"end,omitempty"`         // Last entry (1-based, inclusive)
+	After      string `json:"after,omitempty"`       // YYYY-MM-DD, inclusive
+	Before     string `json:"before,omitempty"`      // YYYY-MM-DD, inclusive
+	MaxEntries int    `json:"max_entries,omitempty"` // Maximum number of entries
+}
+
+// filter converts the request into a validated core.PlaylistFilter.
+func (p *PlaylistRequest) filter() (core.PlaylistFilter, error) {
+	if p == nil {
+		return core.PlaylistFilter{}, nil
+	}
+	filter := core.PlaylistFilter{Start: p.Start, End: p.End, MaxEntries: p.MaxEntries}
+	var err error
+	if p.After != "" {
+		if filter.After, err = time.Parse(time.DateOnly, p.After); err != nil {
+			return filter, fmt.Errorf("invalid playlist after date %q: want YYYY-MM-DD", p.After)
+		}
+	}
+	if p.Before != "" {
+		if filter.Before, err = time.Parse(time.DateOnly, p.Before); err != nil {
+			return filter, fmt.Errorf("invalid playlist before date %q: want YYYY-MM-DD", p.Before)
+		}
+	}
+	return filter, filter.Validate()
+}
+
+// JobResponse describes a job's state.
+type JobResponse struct {
+	ID         string             `json:"id"`
+	Status     string             `json:"status"`
+	Priority   string             `json:"priority"`
+	Progress   float64            `json:"progress"`
+	Message    string             `json:"message,omitempty"`
+	Attempts   int                `json:"attempts"`
+	Error      string             `json:"error,omitempty"`
+	ErrorClass string             `json:"error_class,omitempty"`
+	QueuedAt   time.Time          `json:"queued_at"`
+	StartTime  *time.Time         `json:"start_time,omitempty"`
+	EndTime    *time.Time         `json:"end_time,omitempty"`
+	Options    core.ScribeOptions `json:"options"`
+	Result     *core.ScribeResult `json:"result,omitempty"`
+
+	PlaylistJobs []string `json:"playlist_jobs,omitempty"` // On submission of a playlist URL: all jobs created for it, in playlist order
+}
+
+// newJobResponse converts a job snapshot into its API representation.
+func newJobResponse(job core.BatchJob) JobResponse {
+	resp := JobResponse{
+		ID:       job.ID,
+		Status:   job.Status.String(),
+		Priority: job.Priority.String(),
+		Progress: job.Progress,
+		Message:  job.StatusMsg,
+		Attempts: job.Attempts,
+		QueuedAt: job.QueuedAt,
+		Options:  job.Options,
+		Result:   job.Result,
+	}
+	if job.Error != nil {
+		resp.Error = job.Error.Error()
+		resp.ErrorClass = job.ErrorClass.String()
+	}
+	if !job.StartTime.IsZero() {
+		resp.StartTime = &job.StartTime
+	}
+	if !job.EndTime.IsZero() {
+		resp.EndTime = &job.EndTime
+	}
+	return resp
+}
+
+// handleCreateJob submits a new job. A playlist or channel URL is expanded
+// into one job per video; the response describes the first of them.
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	var req JobRequest
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxRequestBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	options, err := s.resolveOptions(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	priority, err := core.ParseJobPriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Outputs go to <output root>/<job ID>, or for playlist videos to
+	// <output root>/<playlist ID>/<video ID>/<job ID>
+	options.OutputDir = s.outputRoot
+	jobOpts := []core.JobOption{core.WithPriority(priority), core.WithOutputDirPerJob()}
+	if req.Webhook != "" {
+		if err := core.ValidateWebhookURL(req.Webhook); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		jobOpts = append(jobOpts, core.WithWebhook(req.Webhook))
+	}
+
+	filter, err := req.Playlist.filter()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Playlist != nil && options.InputURL == "" {
+		writeError(w, http.StatusBadRequest, errors.New("playlist selection requires an input URL"))
+		return
+	}
+
+	var jobIDs []string
+	if options.InputURL != "" {
+		// Playlist and channel URLs become one job per video
+		jobIDs, err = s.processor.AddURLJobs(r.Context(), options, filter, jobOpts...)
+		if err != nil {
+			status := http.StatusBadGateway
+			if core.ClassifyError(err) == core.ErrorClassInvalidInput {
+				status = http.StatusBadRequest
+			}
+			writeError(w, status, err)
+			return
+		}
+	} else {
+		jobIDs = []string{s.processor.AddJob(options, jobOpts...)}
+	}
+
+	job, _ := s.processor.JobSnapshot(jobIDs[0])
+	resp := newJobResponse(job)
+	if len(jobIDs) > 1 {
+		resp.PlaylistJobs = jobIDs
+	}
+	w.Header().Set("Location", APIPrefix+"/jobs/"+jobIDs[0])
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// resolveOptions builds the ScribeOptions for a job request.
+func (s *Server) resolveOptions(req JobRequest) (core.ScribeOptions, error) {
+	var options core.ScribeOptions
+	if req.Options != nil {
+		options = *req.Options
+	}
+	if options.OutputDir != "" {
+		return options, errors.New("OutputDir is assigned by the server and cannot be set")
+	}
+
+	if req.Template != "" {
+		if s.templates == nil {
+			return options, errors.New("templates are not available on this server")
+		}
+
+		s.templMu.Lock()
+		err := s.templates.ApplyTemplate(req.Template, &options)
+		s.templMu.Unlock()
+		if err != nil {
+			return options, fmt.Errorf("failed to apply template %q: %w", req.Template, err)
+		}
+	} else if req.Options == nil {
+		return options, errors.New("either options or template is required")
+	}
+
+	if options.InputFile == "" && options.InputURL == "" {
+		return options, errors.New("options must include InputFile or InputURL")
+	}
+	if options.InputFile != "" {
+		path, err := s.resolveInputFile(options.InputFile)
+		if err != nil {
+			return options, err
+		}
+		options.InputFile = path
+	}
+	if _, err := core.LookupPipeline(options.Pipeline); err != nil {
+		return options, err
+	}
+
+	return options, nil
+}
+
+// resolveInputFile resolves symlinks in a job's input file and checks that it
+// lies under the server's input root.
+func (s *Server) resolveInputFile(input string) (string, error) {
+	if s.inputRoot == "" {
+		return "", errors.New("local input files are not enabled on this server; submit an InputURL")
+	}
+
+	path, err := resolvePath(input)
+	if err != nil {
+		return "", fmt.Errorf("input file not accessible on server: %w", err)
+	}
+	root, err := resolvePath(s.inputRoot)
+	if err != nil {
+		return "", fmt.Errorf("input root not accessible on server: %w", err)
+	}
+	if !isWithin(root, path) {
+		return "", errors.New("input file must be under the server's input root")
+	}
+	return path, nil
+}
+
+// resolvePath returns the absolute path of an existing file with symlinks resolved.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// isWithin reports whether path is root or lies under it. Both must be
+// absolute and clean.
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// handleListJobs lists all jobs in the order they were submitted.
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	jobs := s.processor.JobSnapshots()
+
+	resp := make([]JobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		resp = append(resp, newJobResponse(job))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleGetJob returns a job's status and result.
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.processor.JobSnapshot(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, newJobResponse(job))
+}
+
+// handleCancelJob cancels a pending, paused or running job.
+func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+	if _, ok := s.processor.JobSnapshot(jobID); !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", jobID))
+		return
+	}
+
+	if err := s.processor.CancelJob(jobID); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+
+	job, _ := s.processor.JobSnapshot(jobID)
+	writeJSON(w, http.StatusAccepted, newJobResponse(job))
+}
@@ -0,0 +1,247 @@
+// Package server exposes a BatchProcessor over a local HTTP/REST API.
+//
+// Endpoints (all under /api/v1, bearer token required when configured):
+//
+//	GET    /health                          Liveness check (no auth)
+//	POST   /jobs                            Submit a job (ScribeOptions JSON or a template name)
+//	GET    /jobs                            List all jobs
+//	GET    /jobs/{id}                       Job status and result
+//	DELETE /jobs/{id}                       Cancel a job
+//	GET    /jobs/{id}/events                Server-sent events for one job
+//	GET    /jobs/{id}/artifacts             List output files
+//	GET    /jobs/{id}/artifacts/{name}      Download an output file
+//	GET    /events                          Server-sent events for all jobs
+//	GET    /report?format=json|csv|html     Batch report
+//
+// Prometheus metrics are served at /metrics, outside the API prefix, with the
+// same authentication.
+//
+// Each job writes to its own directory under the server's output root, and
+// local input files are only accepted from under its input root.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"akashic_scribe/core"
+)
+
+// APIPrefix is the path prefix of all API endpoints.
+const APIPrefix = "/api/v1"
+
+// Server serves the REST API for a BatchProcessor.
+type Server struct {
+	processor  *core.BatchProcessor
+	templates  *core.TemplateManager // Optional; required for template-based submissions
+	templMu    sync.Mutex            // TemplateManager is not safe for concurrent use
+	token      string                // Bearer token; empty disables authentication
+	metrics    *core.Metrics         // Served at /metrics
+	outputRoot string                // Each job writes to a directory named after its ID under it
+	inputRoot  string                // Local input files must be under it; empty disables them
+	mux        *http.ServeMux
+}
+
+// DefaultOutputRoot is the directory job outputs go under when WithOutputRoot
+// is not given, relative to the working directory.
+const DefaultOutputRoot = "akashic_output"
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithToken requires every API request (except /health) to carry
+// "Authorization: Bearer <token>".
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// WithTemplates enables job submission by template name.
+func WithTemplates(templates *core.TemplateManager) Option {
+	return func(s *Server) {
+		s.templates = templates
+	}
+}
+
+// WithMetrics serves metrics instead of core.DefaultMetrics at /metrics.
+func WithMetrics(metrics *core.Metrics) Option {
+	return func(s *Server) {
+		s.metrics = metrics
+	}
+}
+
+// WithOutputRoot makes jobs write their outputs to a directory named after
+// the job ID under root, nested in <playlist ID>/<video ID> for playlist
+// videos. Clients cannot choose the output directory.
+func WithOutputRoot(root string) Option {
+	return func(s *Server) {
+		s.outputRoot = root
+	}
+}
+
+// WithInputRoot allows jobs to process local files under root. Without it,
+// jobs may only process URLs.
+func WithInputRoot(root string) Option {
+	return func(s *Server) {
+		s.inputRoot = root
+	}
+}
+
+// New creates a server backed by processor.
+func New(processor *core.BatchProcessor, opts ...Option) *Server {
+	s := &Server{
+		processor:  processor,
+		metrics:    core.DefaultMetrics(),
+		outputRoot: DefaultOutputRoot,
+		mux:        http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("GET "+APIPrefix+"/health", s.handleHealth)
+	s.mux.Handle("POST "+APIPrefix+"/jobs", s.authenticated(s.handleCreateJob))
+	s.mux.Handle("GET "+APIPrefix+"/jobs", s.authenticated(s.handleListJobs))
+	s.mux.Handle("GET "+APIPrefix+"/jobs/{id}", s.authenticated(s.handleGetJob))
+	s.mux.Handle("DELETE "+APIPrefix+"/jobs/{id}", s.authenticated(s.handleCancelJob))
+	s.mux.Handle("GET "+APIPrefix+"/jobs/{id}/events", s.authenticated(s.handleJobEvents))
+	s.mux.Handle("GET "+APIPrefix+"/jobs/{id}/artifacts", s.authenticated(s.handleListArtifacts))
+	s.mux.Handle("GET "+APIPrefix+"/jobs/{id}/artifacts/{name}", s.authenticated(s.handleGetArtifact))
+	s.mux.Handle("GET "+APIPrefix+"/events", s.authenticated(s.handleAllEvents))
+	s.mux.Handle("GET "+APIPrefix+"/report", s.authenticated(s.handleReport))
+	s.mux.Handle("GET /metrics", s.authenticated(s.handleMetrics))
+
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves on addr until ctx is cancelled, then shuts down gracefully.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	if s.token == "" {
+		logger().Warn("No API token configured, authentication is disabled")
+	}
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		logger().Info("Listening", "addr", addr)
+		errChan <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("server shutdown failed: %w", err)
+	}
+	return nil
+}
+
+// authenticated wraps a handler with bearer token authentication.
+// Event streams may pass the token as ?access_token= since browsers'
+// EventSource cannot set headers.
+func (s *Server) authenticated(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token == "" {
+			next(w, r)
+			return
+		}
+
+		provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok && r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/events") {
+			provided, ok = r.URL.Query().Get("access_token"), true
+		}
+
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="akashic-scribe"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid API token"))
+			return
+		}
+		next(w, r)
+	})
+}
+
+// handleHealth reports that the server is running.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReport writes a batch report in the requested format.
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	format := core.ReportFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = core.ReportJSON
+	}
+
+	contentTypes := map[core.ReportFormat]string{
+		core.ReportJSON: "application/json",
+		core.ReportCSV:  "text/csv; charset=utf-8",
+		core.ReportHTML: "text/html; charset=utf-8",
+	}
+	contentType, ok := contentTypes[format]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported report format: %s", format))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if err := s.processor.Report().Write(w, format); err != nil {
+		logger().Error("Failed to write report", "error", err)
+	}
+}
+
+// handleMetrics writes the metrics in the Prometheus text format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := s.metrics.WritePrometheus(w); err != nil {
+		logger().Error("Failed to write metrics", "error", err)
+	}
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger().Error("Failed to encode response", "error", err)
+	}
+}
+
+// logger returns the default logger tagged with the server component.
+func logger() *slog.Logger {
+	return slog.Default().With("component", "server")
+}
+
+// errorResponse is the body of all error responses.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError writes an error as a JSON response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,459 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"akashic_scribe/core"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "secret-token"
+
+// outputEngine wraps the mock engine and writes real files into the job's output directory.
+type outputEngine struct {
+	*core.MockScribeEngine
+}
+
+func (e *outputEngine) ProcessWithContext(ctx context.Context, options core.ScribeOptions, progress chan<- core.ProgressUpdate) (*core.ScribeResult, error) {
+	result, err := e.MockScribeEngine.ProcessWithContext(ctx, options, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	result.OutputDir = options.OutputDir
+	if err := os.MkdirAll(result.OutputDir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(result.OutputDir, "translation.txt"), []byte(result.Translation), 0o644); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ResolvePlaylist resolves URLs with a list parameter to a two-video playlist.
+func (e *outputEngine) ResolvePlaylist(ctx context.Context, url string) (*core.Playlist, error) {
+	if !strings.Contains(url, "list=") {
+		return &core.Playlist{Entries: []core.PlaylistEntry{{Index: 1, URL: url}}}, nil
+	}
+	return &core.Playlist{ID: "PL1", IsPlaylist: true, Entries: []core.PlaylistEntry{
+		{Index: 1, ID: "aaa", URL: "https://example.com/watch?v=aaa"},
+		{Index: 2, ID: "bbb", URL: "https://example.com/watch?v=bbb"},
+	}}, nil
+}
+
+// newTestServer starts a server backed by the output engine. Input files are
+// allowed under the returned directory; outputs go to its "output" subdirectory.
+func newTestServer(t *testing.T, opts ...Option) (*httptest.Server, *core.BatchProcessor, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	processor := core.NewBatchProcessor(&outputEngine{MockScribeEngine: core.NewMockScribeEngine()}, 2)
+	t.Cleanup(processor.Shutdown)
+
+	opts = append([]Option{WithToken(testToken), WithInputRoot(dir), WithOutputRoot(filepath.Join(dir, "output"))}, opts...)
+	ts := httptest.NewServer(New(processor, opts...))
+	t.Cleanup(ts.Close)
+
+	return ts, processor, dir
+}
+
+// do sends an authenticated request.
+func do(t *testing.T, method, url string, body any) *http.Response {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// decode reads a JSON response body into v.
+func decode(t *testing.T, resp *http.Response, v any) {
+	t.Helper()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
+
+// inputFile creates an input file for a job.
+func inputFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("video"), 0o644))
+	return path
+}
+
+func TestServer_Authentication(t *testing.T) {
+	ts, _, _ := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + APIPrefix + "/jobs")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+APIPrefix+"/jobs", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = http.Get(ts.URL + APIPrefix + "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Health check should not require a token")
+
+	assert.Equal(t, http.StatusOK, do(t, http.MethodGet, ts.URL+APIPrefix+"/jobs", nil).StatusCode)
+}
+
+func TestServer_SubmitAndFetchJob(t *testing.T) {
+	ts, processor, dir := newTestServer(t)
+	input := inputFile(t, dir, "talk.mp4")
+
+	resp := do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", JobRequest{
+		Options:  &core.ScribeOptions{InputFile: input, TargetLanguage: "Spanish"},
+		Priority: "high",
+	})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var created JobResponse
+	decode(t, resp, &created)
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, "High", created.Priority)
+	assert.Equal(t, APIPrefix+"/jobs/"+created.ID, resp.Header.Get("Location"))
+
+	processor.Wait()
+
+	var job JobResponse
+	decode(t, do(t, http.MethodGet, ts.URL+APIPrefix+"/jobs/"+created.ID, nil), &job)
+	assert.Equal(t, "Completed", job.Status)
+	require.NotNil(t, job.Result)
+	assert.Contains(t, job.Result.Translation, "Spanish")
+	assert.NotNil(t, job.EndTime)
+
+	var jobs []JobResponse
+	decode(t, do(t, http.MethodGet, ts.URL+APIPrefix+"/jobs", nil), &jobs)
+	assert.Len(t, jobs, 1)
+
+	assert.Equal(t, http.StatusNotFound, do(t, http.MethodGet, ts.URL+APIPrefix+"/jobs/job_missing", nil).StatusCode)
+}
+
+func TestServer_SubmitValidation(t *testing.T) {
+	ts, _, _ := newTestServer(t)
+
+	tests := []struct {
+		name string
+		body any
+	}{
+		{"empty request", JobRequest{}},
+		{"missing input", JobRequest{Options: &core.ScribeOptions{TargetLanguage: "Spanish"}}},
+		{"missing input file", JobRequest{Options: &core.ScribeOptions{InputFile: "/does/not/exist.mp4"}}},
+		{"input file outside input root", JobRequest{Options: &core.ScribeOptions{InputFile: inputFile(t, t.TempDir(), "other.mp4")}}},
+		{"output directory", JobRequest{Options: &core.ScribeOptions{InputURL: "https://example.com/v", OutputDir: "/etc"}}},
+		{"bad priority", JobRequest{Options: &core.ScribeOptions{InputURL: "https://example.com/v"}, Priority: "asap"}},
+		{"template without manager", JobRequest{Template: "YouTube Video"}},
+		{"bad webhook", JobRequest{Options: &core.ScribeOptions{InputURL: "https://example.com/v"}, Webhook: "mailto:me@example.com"}},
+		{"unknown pipeline", JobRequest{Options: &core.ScribeOptions{InputURL: "https://example.com/v", Pipeline: "nope"}}},
+		{"unknown field", map[string]any{"option": map[string]any{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", tt.body)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+			var body errorResponse
+			decode(t, resp, &body)
+			assert.NotEmpty(t, body.Error)
+		})
+	}
+}
+
+func TestServer_SubmitWithTemplate(t *testing.T) {
+	templates, err := core.NewTemplateManager(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, templates.CreateTemplateFromOptions("German Subs", "", "Custom", core.ScribeOptions{
+		TargetLanguage:  "German",
+		CreateSubtitles: true,
+		SubtitleFormat:  "vtt",
+	}))
+
+	ts, processor, _ := newTestServer(t, WithTemplates(templates))
+
+	resp := do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", JobRequest{
+		Template: "German Subs",
+		Options:  &core.ScribeOptions{InputURL: "https://example.com/watch?v=1", TargetLanguage: "Ignored"},
+	})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var created JobResponse
+	decode(t, resp, &created)
+	assert.Equal(t, "German", created.Options.TargetLanguage, "Template options should win")
+	assert.Equal(t, "https://example.com/watch?v=1", created.Options.InputURL, "Input should be kept")
+	assert.True(t, created.Options.CreateSubtitles)
+
+	processor.Wait()
+
+	resp = do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", JobRequest{Template: "Missing"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServer_CancelJob(t *testing.T) {
+	ts, processor, dir := newTestServer(t)
+
+	processor.Pause()
+	var created JobResponse
+	decode(t, do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", JobRequest{
+		Options: &core.ScribeOptions{InputFile: inputFile(t, dir, "a.mp4")},
+	}), &created)
+
+	resp := do(t, http.MethodDelete, ts.URL+APIPrefix+"/jobs/"+created.ID, nil)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var cancelled JobResponse
+	decode(t, resp, &cancelled)
+	assert.Equal(t, "Cancelled", cancelled.Status)
+
+	assert.Equal(t, http.StatusConflict, do(t, http.MethodDelete, ts.URL+APIPrefix+"/jobs/"+created.ID, nil).StatusCode)
+	assert.Equal(t, http.StatusNotFound, do(t, http.MethodDelete, ts.URL+APIPrefix+"/jobs/job_missing", nil).StatusCode)
+	processor.Resume()
+}
+
+func TestServer_Artifacts(t *testing.T) {
+	ts, processor, dir := newTestServer(t)
+
+	var created JobResponse
+	decode(t, do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", JobRequest{
+		Options: &core.ScribeOptions{InputFile: inputFile(t, dir, "b.mp4"), TargetLanguage: "French"},
+	}), &created)
+	processor.Wait()
+
+	var artifacts []ArtifactResponse
+	decode(t, do(t, http.MethodGet, ts.URL+APIPrefix+"/jobs/"+created.ID+"/artifacts", nil), &artifacts)
+	require.Len(t, artifacts, 1)
+	assert.Equal(t, "translation.txt", artifacts[0].Name)
+
+	resp := do(t, http.MethodGet, ts.URL+artifacts[0].URL, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "French")
+	assert.Contains(t, resp.Header.Get("Content-Disposition"), "translation.txt")
+
+	// Escaping the output directory must not be possible
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644))
+	resp = do(t, http.MethodGet, ts.URL+APIPrefix+"/jobs/"+created.ID+"/artifacts/..%2Fsecret.txt", nil)
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+
+	resp = do(t, http.MethodGet, ts.URL+APIPrefix+"/jobs/"+created.ID+"/artifacts/missing.txt", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServer_JobsGetOwnOutputDirectories(t *testing.T) {
+	ts, processor, dir := newTestServer(t)
+
+	var ids []string
+	for range 2 {
+		var created JobResponse
+		decode(t, do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", JobRequest{
+			Options: &core.ScribeOptions{InputURL: "https://example.com/v"},
+		}), &created)
+		assert.Equal(t, filepath.Join(dir, "output", created.ID), created.Options.OutputDir)
+		ids = append(ids, created.ID)
+	}
+	processor.Wait()
+
+	for _, id := range ids {
+		var artifacts []ArtifactResponse
+		decode(t, do(t, http.MethodGet, ts.URL+APIPrefix+"/jobs/"+id+"/artifacts", nil), &artifacts)
+		assert.Len(t, artifacts, 1)
+	}
+}
+
+func TestServer_SubmitPlaylist(t *testing.T) {
+	ts, processor, dir := newTestServer(t)
+
+	resp := do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", JobRequest{
+		Options: &core.ScribeOptions{InputURL: "https://example.com/playlist?list=PL1", TargetLanguage: "Spanish"},
+	})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var created JobResponse
+	decode(t, resp, &created)
+	require.Len(t, created.PlaylistJobs, 2, "Each video of the playlist gets its own job")
+	assert.Equal(t, created.ID, created.PlaylistJobs[0])
+
+	var single JobResponse
+	decode(t, do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", JobRequest{
+		Options: &core.ScribeOptions{InputURL: "https://example.com/watch?v=ccc"},
+	}), &single)
+	assert.Empty(t, single.PlaylistJobs, "Single videos are not expanded")
+	processor.Wait()
+
+	for i, id := range created.PlaylistJobs {
+		var job JobResponse
+		decode(t, do(t, http.MethodGet, ts.URL+APIPrefix+"/jobs/"+id, nil), &job)
+		assert.Equal(t, "Completed", job.Status)
+		assert.Equal(t, []string{"https://example.com/watch?v=aaa", "https://example.com/watch?v=bbb"}[i], job.Options.InputURL)
+		videoID := []string{"aaa", "bbb"}[i]
+		assert.Equal(t, filepath.Join(dir, "output", "PL1", videoID, id), job.Options.OutputDir,
+			"Playlist videos keep their stable directories")
+	}
+}
+
+func TestServer_SubmitPlaylistSelection(t *testing.T) {
+	ts, processor, _ := newTestServer(t)
+	playlistURL := "https://example.com/playlist?list=PL1"
+
+	var created JobResponse
+	resp := do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", JobRequest{
+		Options:  &core.ScribeOptions{InputURL: playlistURL},
+		Playlist: &PlaylistRequest{Start: 2},
+	})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	decode(t, resp, &created)
+	assert.Empty(t, created.PlaylistJobs, "Only one video is selected")
+	assert.Equal(t, "https://example.com/watch?v=bbb", created.Options.InputURL)
+
+	for name, req := range map[string]JobRequest{
+		"inverted range": {Options: &core.ScribeOptions{InputURL: playlistURL}, Playlist: &PlaylistRequest{Start: 2, End: 1}},
+		"inverted dates": {Options: &core.ScribeOptions{InputURL: playlistURL}, Playlist: &PlaylistRequest{After: "2024-02-01", Before: "2024-01-01"}},
+		"bad date":       {Options: &core.ScribeOptions{InputURL: playlistURL}, Playlist: &PlaylistRequest{After: "01/02/2024"}},
+		"no URL":         {Options: &core.ScribeOptions{TargetLanguage: "Spanish"}, Playlist: &PlaylistRequest{MaxEntries: 1}},
+	} {
+		resp := do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", req)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+		resp.Body.Close()
+	}
+	processor.Wait()
+}
+
+func TestServer_LocalInputsDisabledWithoutInputRoot(t *testing.T) {
+	dir := t.TempDir()
+	processor := core.NewBatchProcessor(core.NewMockScribeEngine(), 1)
+	t.Cleanup(processor.Shutdown)
+	ts := httptest.NewServer(New(processor, WithToken(testToken), WithOutputRoot(dir)))
+	t.Cleanup(ts.Close)
+
+	resp := do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", JobRequest{
+		Options: &core.ScribeOptions{InputFile: inputFile(t, dir, "talk.mp4")},
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// readSSE reads server-sent events until the stream ends.
+func readSSE(t *testing.T, body io.Reader) []EventResponse {
+	t.Helper()
+
+	var events []EventResponse
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event EventResponse
+		require.NoError(t, json.Unmarshal([]byte(data), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestServer_JobEvents(t *testing.T) {
+	ts, processor, dir := newTestServer(t)
+
+	processor.Pause()
+	var created JobResponse
+	decode(t, do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", JobRequest{
+		Options: &core.ScribeOptions{InputFile: inputFile(t, dir, "c.mp4"), TargetLanguage: "Japanese"},
+	}), &created)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// EventSource clients pass the token as a query parameter
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		ts.URL+APIPrefix+"/jobs/"+created.ID+"/events?access_token="+testToken, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	processor.Resume()
+
+	events := readSSE(t, resp.Body)
+	require.NotEmpty(t, events)
+	assert.Equal(t, "JobStarted", events[0].Type)
+	last := events[len(events)-1]
+	assert.Equal(t, "JobSucceeded", last.Type, "Stream should end with the terminal event")
+	require.NotNil(t, last.Result)
+
+	// A finished job yields its terminal event immediately
+	resp = do(t, http.MethodGet, ts.URL+APIPrefix+"/jobs/"+created.ID+"/events", nil)
+	events = readSSE(t, resp.Body)
+	require.Len(t, events, 1)
+	assert.Equal(t, "JobSucceeded", events[0].Type)
+}
+
+func TestServer_Report(t *testing.T) {
+	ts, processor, dir := newTestServer(t)
+
+	do(t, http.MethodPost, ts.URL+APIPrefix+"/jobs", JobRequest{
+		Options: &core.ScribeOptions{InputFile: inputFile(t, dir, "d.mp4")},
+	})
+	processor.Wait()
+
+	var report core.BatchReport
+	decode(t, do(t, http.MethodGet, ts.URL+APIPrefix+"/report", nil), &report)
+	assert.Equal(t, 1, report.Completed)
+
+	resp := do(t, http.MethodGet, ts.URL+APIPrefix+"/report?format=csv", nil)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	assert.Equal(t, http.StatusBadRequest, do(t, http.MethodGet, ts.URL+APIPrefix+"/report?format=xlsx", nil).StatusCode)
+}
+
+func TestServer_Metrics(t *testing.T) {
+	metrics := core.NewMetrics()
+	metrics.RecordJob("completed", 2*time.Second)
+	metrics.ObserveStage("default", core.StageTranscribe, core.MetricStatusOK, 3*time.Second)
+	ts, _, _ := newTestServer(t, WithMetrics(metrics))
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = do(t, http.MethodGet, ts.URL+"/metrics", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain; version=0.0.4")
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `akashic_jobs_total{status="completed"} 1`)
+	assert.Contains(t, string(body), `akashic_stage_duration_seconds_count{pipeline="default",stage="transcribe",status="ok"} 1`)
+}